@@ -0,0 +1,115 @@
+package aggretastic
+
+import "fmt"
+
+// NewMetric returns a metric aggregation of the given kind calculated over the field.
+// The kind is the name Elasticsearch uses for the aggregation:
+// "avg", "sum", "min", "max", "stats", "extended_stats", "value_count", "cardinality",
+// "percentiles", "percentile_ranks", "median_absolute_deviation", "boxplot",
+// "string_stats", "geo_bounds", "geo_centroid" or "rate".
+// The concrete aggregation (e.g. *AvgAggregation) is returned, so it can be configured further
+// after a type assertion.
+func NewMetric(kind string, field string) (Aggregation, error) {
+	switch kind {
+	case "avg":
+		return NewAvgAggregation().Field(field), nil
+	case "sum":
+		return NewSumAggregation().Field(field), nil
+	case "min":
+		return NewMinAggregation().Field(field), nil
+	case "max":
+		return NewMaxAggregation().Field(field), nil
+	case "stats":
+		return NewStatsAggregation().Field(field), nil
+	case "extended_stats":
+		return NewExtendedStatsAggregation().Field(field), nil
+	case "value_count":
+		return NewValueCountAggregation().Field(field), nil
+	case "cardinality":
+		return NewCardinalityAggregation().Field(field), nil
+	case "percentiles":
+		return NewPercentilesAggregation().Field(field), nil
+	case "percentile_ranks":
+		return NewPercentileRanksAggregation().Field(field), nil
+	case "median_absolute_deviation":
+		return NewMedianAbsoluteDeviationAggregation().Field(field), nil
+	case "boxplot":
+		return NewBoxplotAggregation().Field(field), nil
+	case "string_stats":
+		return NewStringStatsAggregation().Field(field), nil
+	case "geo_bounds":
+		return NewGeoBoundsAggregation().Field(field), nil
+	case "geo_centroid":
+		return NewGeoCentroidAggregation().Field(field), nil
+	case "rate":
+		return NewRateAggregation().Field(field), nil
+	default:
+		return nil, fmt.Errorf("unknown metric aggregation kind %q", kind)
+	}
+}
+
+// NewBucket returns a fresh bucket aggregation of the given kind, ready to be configured.
+// The kind is the name Elasticsearch uses for the aggregation:
+// "adjacency_matrix", "auto_date_histogram", "children", "composite", "date_histogram",
+// "date_range", "diversified_sampler", "filter", "filters", "geo_distance", "geohash_grid",
+// "global", "histogram", "ip_range", "missing", "multi_terms", "nested", "range",
+// "rare_terms", "reverse_nested", "sampler", "significant_terms", "significant_text",
+// "terms" or "variable_width_histogram".
+// The concrete aggregation (e.g. *TermsAggregation) is returned, so it can be configured
+// after a type assertion.
+func NewBucket(kind string) (Aggregation, error) {
+	switch kind {
+	case "adjacency_matrix":
+		return NewAdjacencyMatrixAggregation(), nil
+	case "auto_date_histogram":
+		return NewAutoDateHistogramAggregation(), nil
+	case "children":
+		return NewChildrenAggregation(), nil
+	case "composite":
+		return NewCompositeAggregation(), nil
+	case "date_histogram":
+		return NewDateHistogramAggregation(), nil
+	case "date_range":
+		return NewDateRangeAggregation(), nil
+	case "diversified_sampler":
+		return NewDiversifiedSamplerAggregation(), nil
+	case "filter":
+		return NewFilterAggregation(), nil
+	case "filters":
+		return NewFiltersAggregation(), nil
+	case "geo_distance":
+		return NewGeoDistanceAggregation(), nil
+	case "geohash_grid":
+		return NewGeoHashGridAggregation(), nil
+	case "global":
+		return NewGlobalAggregation(), nil
+	case "histogram":
+		return NewHistogramAggregation(), nil
+	case "ip_range":
+		return NewIPRangeAggregation(), nil
+	case "missing":
+		return NewMissingAggregation(), nil
+	case "multi_terms":
+		return NewMultiTermsAggregation(), nil
+	case "nested":
+		return NewNestedAggregation(), nil
+	case "range":
+		return NewRangeAggregation(), nil
+	case "rare_terms":
+		return NewRareTermsAggregation(), nil
+	case "reverse_nested":
+		return NewReverseNestedAggregation(), nil
+	case "sampler":
+		return NewSamplerAggregation(), nil
+	case "significant_terms":
+		return NewSignificantTermsAggregation(), nil
+	case "significant_text":
+		return NewSignificantTextAggregation(), nil
+	case "terms":
+		return NewTermsAggregation(), nil
+	case "variable_width_histogram":
+		return NewVariableWidthHistogramAggregation(), nil
+	default:
+		return nil, fmt.Errorf("unknown bucket aggregation kind %q", kind)
+	}
+}
@@ -0,0 +1,55 @@
+package aggretastic
+
+import "testing"
+
+func TestNewMetric(t *testing.T) {
+	kinds := []string{"avg", "sum", "min", "max", "stats", "extended_stats", "value_count", "cardinality",
+		"percentiles", "percentile_ranks", "median_absolute_deviation", "boxplot",
+		"string_stats", "geo_bounds", "geo_centroid", "rate"}
+
+	for _, kind := range kinds {
+		t.Run(kind, func(t *testing.T) {
+			agg, err := NewMetric(kind, "price")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if agg.AggregationType() != kind {
+				t.Fatalf("expected the type %q, got %q", kind, agg.AggregationType())
+			}
+			if field := agg.(FieldGetter).GetField(); field != "price" {
+				t.Fatalf("expected the field %q, got %q", "price", field)
+			}
+		})
+	}
+
+	if _, err := NewMetric("median", "price"); err == nil {
+		t.Fatal("expected an error for an unknown kind")
+	}
+}
+
+func TestNewBucket(t *testing.T) {
+	kinds := []string{"adjacency_matrix", "auto_date_histogram", "children", "composite", "date_histogram",
+		"date_range", "diversified_sampler", "filter", "filters", "geo_distance", "geohash_grid",
+		"global", "histogram", "ip_range", "missing", "multi_terms", "nested", "range",
+		"rare_terms", "reverse_nested", "sampler", "significant_terms", "significant_text",
+		"terms", "variable_width_histogram"}
+
+	for _, kind := range kinds {
+		t.Run(kind, func(t *testing.T) {
+			agg, err := NewBucket(kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if agg.AggregationType() != kind {
+				t.Fatalf("expected the type %q, got %q", kind, agg.AggregationType())
+			}
+			if IsNotInjectable(agg) {
+				t.Fatal("expected a bucket aggregation to take subAggregations")
+			}
+		})
+	}
+
+	if _, err := NewBucket("bucket"); err == nil {
+		t.Fatal("expected an error for an unknown kind")
+	}
+}
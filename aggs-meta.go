@@ -0,0 +1,76 @@
+package aggretastic
+
+// metaHolder keeps the meta data of an aggregation.
+// Aggregations embed it and render the meta data with renderMeta() in their Source().
+type metaHolder struct {
+	meta map[string]interface{}
+}
+
+// renderMeta adds the meta data (if available) to the aggregation source
+func (m *metaHolder) renderMeta(source map[string]interface{}) {
+	if len(m.meta) > 0 {
+		source["meta"] = m.meta
+	}
+}
+
+// addMeta adds the key to the meta data, the map is created on the first call
+func (m *metaHolder) addMeta(key string, value interface{}) {
+	if m.meta == nil {
+		m.meta = make(map[string]interface{})
+	}
+	m.meta[key] = value
+}
+
+// cloneMeta makes the meta data map a copy, so it's not shared with the original aggregation
+func (m *metaHolder) cloneMeta() {
+	if m.meta == nil {
+		return
+	}
+
+	meta := make(map[string]interface{}, len(m.meta))
+	for key, value := range m.meta {
+		meta[key] = value
+	}
+	m.meta = meta
+}
+
+// setMeta replaces the meta data, e.g. when an aggregation is restored from its source
+func (m *metaHolder) setMeta(meta map[string]interface{}) {
+	m.meta = meta
+}
+
+// metaSetter is implemented by the aggregations embedding metaHolder
+// (all the aggregations of this package except the ones made with Wrap)
+type metaSetter interface {
+	addMeta(key string, value interface{})
+	markDirty()
+}
+
+// StampMeta adds the key to the meta data of root and all its subAggs supporting meta,
+// e.g. to tag the whole request with an id Elasticsearch echoes back in the response.
+// It returns the number of the aggregations stamped.
+func StampMeta(root Aggregation, key string, value interface{}) int {
+	if isNilAgg(root) {
+		return 0
+	}
+
+	stamped := 0
+	stamp := func(agg Aggregation) {
+		if m, ok := agg.(metaSetter); ok {
+			m.addMeta(key, value)
+			m.markDirty()
+			stamped++
+		}
+	}
+
+	stamp(root)
+	root.Walk(func(path []string, agg Aggregation) bool {
+		if isNilAgg(agg) {
+			return false
+		}
+		stamp(agg)
+		return true
+	})
+
+	return stamped
+}
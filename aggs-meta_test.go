@@ -0,0 +1,79 @@
+package aggretastic
+
+import "testing"
+
+func TestAddMeta(t *testing.T) {
+	agg := NewTermsAggregation().Field("product").AddMeta("label", "Products")
+	agg.AddMeta("unit", "pcs")
+
+	want := `{"meta":{"label":"Products","unit":"pcs"},"terms":{"field":"product"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	agg.Meta(map[string]interface{}{"label": "Items"}).AddMeta("unit", "kg")
+	want = `{"meta":{"label":"Items","unit":"kg"},"terms":{"field":"product"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestAddMetaOnPipeline(t *testing.T) {
+	agg := NewDerivativeAggregation().BucketsPath("sales").AddMeta("k", 1)
+
+	want := `{"derivative":{"buckets_path":"sales"},"meta":{"k":1}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestAddMetaDoesNotChangeClone(t *testing.T) {
+	agg := NewTermsAggregation().Field("product").AddMeta("label", "Products")
+	Clone(agg).(*TermsAggregation).AddMeta("unit", "pcs")
+
+	want := `{"meta":{"label":"Products"},"terms":{"field":"product"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestStampMeta(t *testing.T) {
+	root := NewTermsAggregation().Field("user").
+		SubAggregation("by_hour", NewHistogramAggregation().Field("hour").Interval(1).
+			SubAggregation("by_day", NewTermsAggregation().Field("day").
+				SubAggregation("avg", NewAvgAggregation().Field("x")))).
+		SubAggregation("wrapped", Wrap("wrapped", NewAvgAggregation().Field("y")))
+	root.CacheSource(true)
+	_ = root.String()
+
+	if stamped := StampMeta(root, "request", "42"); stamped != 4 {
+		t.Fatalf("expected 4 aggregations stamped, got %d", stamped)
+	}
+
+	want := `{"aggregations":{"by_hour":{"aggregations":{"by_day":{"aggregations":{"avg":{"avg":{"field":"x"},"meta":{"request":"42"}}},"meta":{"request":"42"},"terms":{"field":"day"}}},"histogram":{"field":"hour","interval":1},"meta":{"request":"42"}},"wrapped":{"avg":{"field":"y"}}},"meta":{"request":"42"},"terms":{"field":"user"}}`
+	if root.String() != want {
+		t.Fatalf("expected %s, got %s", want, root)
+	}
+
+	if stamped := StampMeta(nil, "request", "42"); stamped != 0 {
+		t.Fatalf("expected nothing stamped, got %d", stamped)
+	}
+}
+
+func TestEveryAggregationRendersMeta(t *testing.T) {
+	for name, agg := range snapshotCases() {
+		t.Run(name, func(t *testing.T) {
+			if StampMeta(agg, "tenant", "acme") == 0 {
+				t.Fatal("expected the aggregation to support meta")
+			}
+			src, err := agg.Source()
+			if err != nil {
+				t.Fatal(err)
+			}
+			meta, _ := src.(map[string]interface{})["meta"].(map[string]interface{})
+			if meta["tenant"] != "acme" {
+				t.Fatalf("expected the meta rendered, got %s", agg)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package aggretastic
+
+import "sync"
+
+// versioned is implemented by the aggregations of this package:
+// the version is increased by every change made through their methods (setters, Inject, Pop etc.)
+type versioned interface {
+	sourceVersion() uint64
+}
+
+// dirtyMarker is implemented by the aggregations of this package, see markDirty
+type dirtyMarker interface {
+	markDirty()
+}
+
+// sourceCache keeps the last rendered source of a tree together with
+// the versions of all the aggregations it was rendered from
+type sourceCache struct {
+	mu       sync.Mutex
+	value    interface{}
+	versions map[versioned]uint64
+	nodes    int
+}
+
+// CacheSource turns the memoization of Source() on or off (it's off by default).
+// With the cache on, Source() renders the tree once and returns the same value
+// until the aggregation or any of its subAggs is changed through their methods.
+// The returned value is shared between the calls, so it must not be modified.
+//
+// Changes the tree doesn't know about are not noticed: modifying the objects passed
+// to the setters (queries, scripts, meta maps, composite value sources etc.) or
+// the map returned by GetAllSubs. Call CacheSource(true) again to drop the cached value then.
+// Trees containing aggregations made with Wrap are never cached.
+func (a *tree) CacheSource(enabled bool) {
+	if enabled {
+		a.cache = &sourceCache{}
+	} else {
+		a.cache = nil
+	}
+}
+
+func (a *tree) sourceVersion() uint64 {
+	return a.version
+}
+
+// markDirty is called by every method changing the aggregation,
+// so the cached sources containing it are rendered again
+func (a *tree) markDirty() {
+	a.version++
+}
+
+// cachedSource returns the cached source if it's still valid, otherwise renders it with render
+func (a *tree) cachedSource(render func(depth int) (interface{}, error)) (interface{}, error) {
+	c := a.cache
+	if c == nil {
+		return render(0)
+	}
+
+	root, ok := a.root.(Aggregation)
+	if !ok {
+		return render(0)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.versions != nil && c.valid(root) {
+		return c.value, nil
+	}
+
+	src, err := render(0)
+	if err != nil {
+		c.value, c.versions, c.nodes = nil, nil, 0
+		return nil, err
+	}
+
+	c.value, c.versions, c.nodes = src, make(map[versioned]uint64), 0
+	if !c.record(root, 0) {
+		c.value, c.versions, c.nodes = nil, nil, 0
+	}
+
+	return src, nil
+}
+
+// record remembers the versions of agg and its subAggs. It returns false if the tree can't be cached.
+func (c *sourceCache) record(agg Aggregation, depth int) bool {
+	v, ok := agg.(versioned)
+	if !ok || agg.Unwrap() != nil || depth > MaxAggregationDepth {
+		return false
+	}
+	c.versions[v] = v.sourceVersion()
+	c.nodes++
+
+	for _, subAgg := range agg.GetAllSubs() {
+		if isNilAgg(subAgg) || !c.record(subAgg, depth+1) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// valid reports whether the tree of root consists of the same aggregations of the same versions as recorded
+func (c *sourceCache) valid(root Aggregation) bool {
+	nodes := 0
+
+	var check func(agg Aggregation, depth int) bool
+	check = func(agg Aggregation, depth int) bool {
+		v, ok := agg.(versioned)
+		if !ok || depth > MaxAggregationDepth {
+			return false
+		}
+		if version, ok := c.versions[v]; !ok || version != v.sourceVersion() {
+			return false
+		}
+		nodes++
+
+		for _, subAgg := range agg.GetAllSubs() {
+			if isNilAgg(subAgg) || !check(subAgg, depth+1) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	// removed subAggs are noticed by the number of aggregations in the tree
+	return check(root, 0) && nodes == c.nodes
+}
@@ -0,0 +1,119 @@
+package aggretastic
+
+import (
+	"reflect"
+	"testing"
+)
+
+// sameValue reports whether both sources are the very same map
+func sameValue(a, b interface{}) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+func TestCacheSource(t *testing.T) {
+	avg := NewAvgAggregation().Field("x")
+	inner := NewTermsAggregation().Field("y").SubAggregation("avg", avg)
+	root := NewTermsAggregation().Field("a").SubAggregation("inner", inner)
+	root.CacheSource(true)
+
+	first, _ := root.Source()
+	second, _ := root.Source()
+	if !sameValue(first, second) {
+		t.Fatal("expected the source cached")
+	}
+
+	tests := []struct {
+		name   string
+		change func()
+		want   string
+	}{
+		{"setter of a subAgg", func() { avg.Field("z") },
+			`{"aggregations":{"inner":{"aggregations":{"avg":{"avg":{"field":"z"}}},"terms":{"field":"y"}}},"terms":{"field":"a"}}`},
+		{"pop", func() { root.Pop("inner", "avg") },
+			`{"aggregations":{"inner":{"terms":{"field":"y"}}},"terms":{"field":"a"}}`},
+		{"subAggregation", func() { inner.SubAggregation("m", NewMaxAggregation().Field("m")) },
+			`{"aggregations":{"inner":{"aggregations":{"m":{"max":{"field":"m"}}},"terms":{"field":"y"}}},"terms":{"field":"a"}}`},
+		{"pop match", func() { inner.PopMatch("*") },
+			`{"aggregations":{"inner":{"terms":{"field":"y"}}},"terms":{"field":"a"}}`},
+		{"removal from GetAllSubs", func() {
+			inner.SubAggregation("m", NewMaxAggregation().Field("m"))
+			_ = root.String()
+			delete(inner.GetAllSubs(), "m")
+		}, `{"aggregations":{"inner":{"terms":{"field":"y"}}},"terms":{"field":"a"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_ = root.String()
+			test.change()
+			if root.String() != test.want {
+				t.Fatalf("expected %s, got %s", test.want, root)
+			}
+		})
+	}
+}
+
+func TestCacheSourceSkipsWrapped(t *testing.T) {
+	root := NewTermsAggregation().Field("a").SubAggregation("w", Wrap("w", NewAvgAggregation().Field("w")))
+	root.CacheSource(true)
+
+	first, _ := root.Source()
+	second, _ := root.Source()
+	if sameValue(first, second) {
+		t.Fatal("expected the trees with wrapped aggregations not cached")
+	}
+}
+
+func TestCacheSourceSkipsTypedNil(t *testing.T) {
+	var typedNil *AvgAggregation
+	root := NewTermsAggregation().Field("a").SubAggregation("avg", NewAvgAggregation().Field("x"))
+	root.subAggregations["broken"] = typedNil
+	root.CacheSource(true)
+
+	first, err := root.Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := root.Source()
+	if sameValue(first, second) {
+		t.Fatal("expected the trees with typed nil subAggs not cached")
+	}
+	if want := `{"aggregations":{"avg":{"avg":{"field":"x"}}},"terms":{"field":"a"}}`; root.String() != want {
+		t.Fatalf("expected %s, got %s", want, root)
+	}
+}
+
+func TestCacheSourceOff(t *testing.T) {
+	root := NewTermsAggregation().Field("a")
+	root.CacheSource(true)
+	root.CacheSource(false)
+
+	first, _ := root.Source()
+	second, _ := root.Source()
+	if sameValue(first, second) {
+		t.Fatal("expected the source not cached")
+	}
+}
+
+func BenchmarkSourceUncached(b *testing.B) {
+	root := newWideTree()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := root.Source(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSourceCached(b *testing.B) {
+	root := newWideTree()
+	root.CacheSource(true)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := root.Source(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
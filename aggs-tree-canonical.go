@@ -0,0 +1,57 @@
+package aggretastic
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/olivere/elastic"
+	"strings"
+)
+
+// CanonicalSource returns the JSON of agg.Source() in a stable form:
+// object keys are sorted at every level of nesting, so two aggregations
+// building the same source always give the same string.
+// It's handy to compare aggregations or to snapshot-test them.
+func CanonicalSource(agg Aggregation) (string, error) {
+	return canonicalSource(agg)
+}
+
+// sourceString is the String() of the aggregations: the canonical source or an <error: ...> marker
+func sourceString(agg elastic.Aggregation) string {
+	s, err := canonicalSource(agg)
+	if err != nil {
+		return "<error: " + err.Error() + ">"
+	}
+
+	return s
+}
+
+func canonicalSource(agg elastic.Aggregation) (string, error) {
+	src, err := agg.Source()
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := json.Marshal(src)
+	if err != nil {
+		return "", err
+	}
+
+	// values with custom MarshalJSON may keep any key order,
+	// decoding into generic maps and encoding again sorts them all
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return "", err
+	}
+
+	// buckets paths are full of ">", so HTML characters are kept as is to stay readable
+	var canonical bytes.Buffer
+	enc := json.NewEncoder(&canonical)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(generic); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(canonical.String(), "\n"), nil
+}
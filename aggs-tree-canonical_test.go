@@ -0,0 +1,79 @@
+package aggretastic
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// rawJSON is an aggregation rendering its source as is, with the keys in any order
+type rawJSON string
+
+func (r rawJSON) Source() (interface{}, error) {
+	return json.RawMessage(r), nil
+}
+
+func TestCanonicalSourceIsStable(t *testing.T) {
+	a := NewTermsAggregation().Field("x").Size(3)
+	a.SubAggregation("z", NewMaxAggregation().Field("z"))
+	a.SubAggregation("b", Wrap("b", rawJSON(`{"custom":{"z":[{"y":1,"x":2}],"a":{"d":true,"c":null}}}`)))
+
+	b := NewTermsAggregation().Size(3).Field("x")
+	b.SubAggregation("b", Wrap("b", rawJSON(`{"custom":{"a":{"c":null,"d":true},"z":[{"x":2,"y":1}]}}`)))
+	b.SubAggregation("z", NewMaxAggregation().Field("z"))
+
+	sa, err := CanonicalSource(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb, err := CanonicalSource(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"aggregations":{"b":{"custom":{"a":{"c":null,"d":true},"z":[{"x":2,"y":1}]}},"z":{"max":{"field":"z"}}},"terms":{"field":"x","size":3}}`
+	if sa != want || sb != want {
+		t.Fatalf("expected both %s, got %s and %s", want, sa, sb)
+	}
+}
+
+func TestCanonicalSourceKeepsPathsReadable(t *testing.T) {
+	agg := NewMaxBucketAggregation().BucketsPath("sales>total")
+
+	s, err := CanonicalSource(agg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"max_bucket":{"buckets_path":"sales>total"}}`
+	if s != want {
+		t.Fatalf("expected %s, got %s", want, s)
+	}
+}
+
+func TestCanonicalSourceFailsWithSource(t *testing.T) {
+	if _, err := CanonicalSource(NewMatrixStatsAggregation()); err == nil {
+		t.Fatal("expected the error of Source")
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name string
+		agg  Aggregation
+		want string
+	}{
+		{"tree", NewTermsAggregation().Field("user").SubAggregation("avg", NewAvgAggregation().Field("x")),
+			`{"aggregations":{"avg":{"avg":{"field":"x"}}},"terms":{"field":"user"}}`},
+		{"pipeline", NewDerivativeAggregation().BucketsPath("sales"), `{"derivative":{"buckets_path":"sales"}}`},
+		{"wrapped", Wrap("avg", NewAvgAggregation().Field("x")), `{"avg":{"field":"x"}}`},
+		{"error", NewScriptedMetricAggregation(), `<error: elastic: ScriptedMetricAggregation requires a map script>`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := fmt.Sprint(test.agg); got != test.want {
+				t.Fatalf("expected %s, got %s", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,428 @@
+package aggretastic
+
+import "github.com/olivere/elastic"
+
+// Clone returns a deep copy of agg: the aggregation itself and all its subAggregations are copied,
+// so the copy can be changed and injected anywhere without affecting the original tree.
+// The slices and maps of the parameters (ranges, orders, sorters, buckets paths, meta data etc.)
+// are copied as well, so the setters of the copy don't change the original and vice versa.
+// The objects given to the setters (scripts, queries, sorters, value sources) are shared.
+// The copy isn't injected anywhere, so its GetName() is empty.
+// It returns nil for nil or unknown aggregations and for the trees deeper than MaxAggregationDepth.
+func Clone(agg Aggregation) Aggregation {
+	if isNilAgg(agg) || deeperThanMax(agg) {
+		return nil
+	}
+
+	return cloneTree(agg)
+}
+
+func cloneTree(agg Aggregation) Aggregation {
+	c := cloneAgg(agg)
+	if m, ok := c.(interface{ cloneMeta() }); ok {
+		m.cloneMeta()
+	}
+
+	return c
+}
+
+func cloneAgg(agg Aggregation) Aggregation {
+	switch agg := agg.(type) {
+	case *wrapped:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *AdjacencyMatrixAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.filters = copyQueries(agg.filters)
+		return &c
+	case *AutoDateHistogramAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *AvgAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *AvgBucketAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.bucketsPaths = copyStrings(agg.bucketsPaths)
+		return &c
+	case *BoxplotAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *BucketScriptAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.bucketsPathsMap = copyPaths(agg.bucketsPathsMap)
+		return &c
+	case *BucketSelectorAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.bucketsPathsMap = copyPaths(agg.bucketsPathsMap)
+		return &c
+	case *BucketSortAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.sorters = append([]elastic.Sorter(nil), agg.sorters...)
+		return &c
+	case *CardinalityAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *ChildrenAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *CompositeAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.sources = append([]CompositeAggregationValuesSource(nil), agg.sources...)
+		c.after = copyValues(agg.after)
+		return &c
+	case *CumulativeCardinalityAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.bucketsPaths = copyStrings(agg.bucketsPaths)
+		return &c
+	case *CumulativeSumAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.bucketsPaths = copyStrings(agg.bucketsPaths)
+		return &c
+	case *DateHistogramAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *DateRangeAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.entries = append([]DateRangeAggregationEntry(nil), agg.entries...)
+		return &c
+	case *DerivativeAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.bucketsPaths = copyStrings(agg.bucketsPaths)
+		return &c
+	case *DiversifiedSamplerAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *ExtendedStatsAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *FilterAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.filterRaw = copyValues(agg.filterRaw)
+		return &c
+	case *FiltersAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.unnamedFilters = append([]elastic.Query(nil), agg.unnamedFilters...)
+		c.namedFilters = append(NamedFilters(nil), agg.namedFilters...)
+		return &c
+	case *GeoBoundsAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *GeoCentroidAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *GeoDistanceAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.ranges = append([]geoDistAggRange(nil), agg.ranges...)
+		return &c
+	case *GeoHashGridAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *GlobalAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *HistogramAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.order = append([]TermsOrder(nil), agg.order...)
+		return &c
+	case *IPRangeAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.entries = append([]IPRangeAggregationEntry(nil), agg.entries...)
+		return &c
+	case *InferenceBucketAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.inferenceConfig = copyValues(agg.inferenceConfig)
+		c.bucketsPathsMap = copyPaths(agg.bucketsPathsMap)
+		return &c
+	case *MatrixStatsAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.fields = copyStrings(agg.fields)
+		return &c
+	case *MaxAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *MaxBucketAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.bucketsPaths = copyStrings(agg.bucketsPaths)
+		return &c
+	case *MedianAbsoluteDeviationAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *MinAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *MinBucketAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.bucketsPaths = copyStrings(agg.bucketsPaths)
+		return &c
+	case *MissingAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *MovAvgAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.bucketsPaths = copyStrings(agg.bucketsPaths)
+		return &c
+	case *MovingPercentilesAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.bucketsPaths = copyStrings(agg.bucketsPaths)
+		return &c
+	case *MultiTermsAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.terms = append([]MultiTermsField(nil), agg.terms...)
+		c.order = append([]TermsOrder(nil), agg.order...)
+		return &c
+	case *NestedAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *NormalizeAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.bucketsPaths = copyStrings(agg.bucketsPaths)
+		return &c
+	case *PercentileRanksAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.values = copyFloats(agg.values)
+		return &c
+	case *PercentilesAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.percentiles = copyFloats(agg.percentiles)
+		return &c
+	case *PercentilesBucketAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.percents = copyFloats(agg.percents)
+		c.bucketsPaths = copyStrings(agg.bucketsPaths)
+		return &c
+	case *RangeAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.entries = append([]rangeAggregationEntry(nil), agg.entries...)
+		return &c
+	case *RareTermsAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.includeExclude = agg.includeExclude.clone()
+		return &c
+	case *RateAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		return &c
+	case *ReverseNestedAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *SamplerAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *ScriptedMetricAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.params = copyValues(agg.params)
+		return &c
+	case *SerialDiffAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.bucketsPaths = copyStrings(agg.bucketsPaths)
+		return &c
+	case *SignificantTermsAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.filterRaw = copyValues(agg.filterRaw)
+		return &c
+	case *SignificantTextAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.sourceFieldNames = copyStrings(agg.sourceFieldNames)
+		c.includeExclude = agg.includeExclude.clone()
+		c.filterRaw = copyValues(agg.filterRaw)
+		c.bucketCountThresholds = agg.bucketCountThresholds.clone()
+		return &c
+	case *StatsAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *StatsBucketAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.bucketsPaths = copyStrings(agg.bucketsPaths)
+		return &c
+	case *StringStatsAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *SumAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *SumBucketAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.bucketsPaths = copyStrings(agg.bucketsPaths)
+		return &c
+	case *TTestAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		return &c
+	case *TermsAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		c.includeExclude = agg.includeExclude.clone()
+		c.order = append([]TermsOrder(nil), agg.order...)
+		return &c
+	case *TopHitsAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.sorters = append([]elastic.Sorter(nil), agg.sorters...)
+		c.sourceIncludes = copyStrings(agg.sourceIncludes)
+		c.sourceExcludes = copyStrings(agg.sourceExcludes)
+		c.docvalueFields = copyStrings(agg.docvalueFields)
+		c.storedFields = copyStrings(agg.storedFields)
+		c.scriptFields = append([]topHitsScriptField(nil), agg.scriptFields...)
+		return &c
+	case *TopMetricsAggregation:
+		c := *agg
+		c.notInjectable = newNotInjectable(&c)
+		c.fields = copyStrings(agg.fields)
+		c.sorters = append([]elastic.Sorter(nil), agg.sorters...)
+		return &c
+	case *ValueCountAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	case *VariableWidthHistogramAggregation:
+		c := *agg
+		c.tree = agg.tree.cloneFor(&c)
+		return &c
+	}
+
+	return nil
+}
+
+// cloneFor returns a copy of the tree with the given root and cloned subAggregations.
+// The clone itself isn't injected anywhere, so its name is empty, while the cloned subAggregations keep theirs.
+func (a *tree) cloneFor(root Aggregation) *tree {
+	t := nilAggregationTree(root)
+	for name, subAgg := range a.subAggregations {
+		t.setSub(name, cloneTree(subAgg))
+	}
+
+	return t
+}
+
+// CopyInto injects a clone of the subAgg found by sourcePath at destPath, the source stays as is.
+// Nothing is changed if any of the paths can't be resolved.
+func (a *tree) CopyInto(sourcePath []string, destPath []string) error {
+	if len(sourcePath) == 0 || len(destPath) == 0 {
+		return ErrNoPath
+	}
+
+	src := a.Select(sourcePath...)
+	if IsNilTree(src) {
+		return ErrPathNotSelectable
+	}
+
+	c := Clone(src)
+	if c == nil {
+		return ErrAggNotCloneable
+	}
+
+	return a.Inject(c, destPath...)
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+
+	return append(make([]string, 0, len(s)), s...)
+}
+
+func copyFloats(s []float64) []float64 {
+	if s == nil {
+		return nil
+	}
+
+	return append(make([]float64, 0, len(s)), s...)
+}
+
+func copyValues(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	c := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		c[key] = value
+	}
+
+	return c
+}
+
+func copyPaths(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+
+	c := make(map[string]string, len(m))
+	for key, value := range m {
+		c[key] = value
+	}
+
+	return c
+}
+
+func copyQueries(m map[string]elastic.Query) map[string]elastic.Query {
+	if m == nil {
+		return nil
+	}
+
+	c := make(map[string]elastic.Query, len(m))
+	for key, value := range m {
+		c[key] = value
+	}
+
+	return c
+}
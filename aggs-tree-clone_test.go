@@ -0,0 +1,137 @@
+package aggretastic
+
+import (
+	"testing"
+)
+
+func TestCloneCopiesTree(t *testing.T) {
+	root := NewTermsAggregation().Field("user").AddMeta("team", "ops")
+	root.SubAggregation("avg", NewAvgAggregation().Field("price"))
+
+	c, ok := Clone(root).(*TermsAggregation)
+	if !ok {
+		t.Fatalf("expected a *TermsAggregation clone, got %T", Clone(root))
+	}
+	if c.String() != root.String() {
+		t.Fatalf("expected the clone to render %s, got %s", root, c)
+	}
+	if c.GetName() != "" {
+		t.Fatalf("expected the clone to have no name, got %q", c.GetName())
+	}
+
+	c.Field("other").AddMeta("team", "dev")
+	c.SubAggregation("max", NewMaxAggregation().Field("price"))
+	c.Select("avg").(*AvgAggregation).Field("cost")
+
+	if want := `{"aggregations":{"avg":{"avg":{"field":"price"}}},"meta":{"team":"ops"},"terms":{"field":"user"}}`; root.String() != want {
+		t.Fatalf("expected the original to stay %s, got %s", want, root)
+	}
+}
+
+func TestCloneDoesNotShareSlices(t *testing.T) {
+	// every aggregation is built with spare capacity in its slices, so appending to
+	// a slice shared by the clone and the original would overwrite the other's element
+	tests := []struct {
+		name   string
+		agg    Aggregation
+		change func(agg Aggregation, value string)
+	}{
+		{
+			name: "range entries",
+			agg:  NewRangeAggregation().Field("price").AddRange(nil, 10).AddRange(10, 20).AddRange(20, nil),
+			change: func(agg Aggregation, value string) {
+				agg.(*RangeAggregation).AddRangeWithKey(value, 40, 50)
+			},
+		},
+		{
+			name: "terms orders and include values",
+			agg:  NewTermsAggregation().Field("user").OrderByCountDesc().OrderByKeyAsc().OrderByKeyDesc().IncludeValues("a", "b", "c"),
+			change: func(agg Aggregation, value string) {
+				agg.(*TermsAggregation).OrderByAggregation(value, true).IncludeValues(value)
+			},
+		},
+		{
+			name: "top hits fields and sorters",
+			agg:  NewTopHitsAggregation().Sort("a", true).Sort("b", true).Sort("c", true).StoredFields("x", "y", "z").DocValueFields("x", "y", "z"),
+			change: func(agg Aggregation, value string) {
+				agg.(*TopHitsAggregation).Sort(value, false).StoredFields(value).DocValueFields(value)
+			},
+		},
+		{
+			name: "pipeline buckets paths",
+			agg:  NewSumBucketAggregation().BucketsPath("a>b", "c>d").BucketsPath("e>f"),
+			change: func(agg Aggregation, value string) {
+				agg.(*SumBucketAggregation).BucketsPath(value)
+			},
+		},
+		{
+			name: "significant text thresholds",
+			agg:  NewSignificantTextAggregation().Field("text").MinDocCount(3),
+			change: func(agg Aggregation, value string) {
+				agg.(*SignificantTextAggregation).ShardSize(len(value))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			original := test.agg.String()
+
+			c := Clone(test.agg)
+			test.change(c, "clone")
+			changed := c.String()
+			test.change(test.agg, "original!")
+
+			if c.String() != changed {
+				t.Fatalf("expected the clone to stay %s after changing the original, got %s", changed, c)
+			}
+			if changed == original {
+				t.Fatalf("expected the clone to change, still %s", changed)
+			}
+
+			test.change(c, "again")
+			if test.agg.String() == c.String() || test.agg.String() == original {
+				t.Fatalf("expected the original to have its own change, got %s", test.agg)
+			}
+		})
+	}
+}
+
+func TestCopyInto(t *testing.T) {
+	root := NewGlobalAggregation().
+		SubAggregation("by_day", NewHistogramAggregation().Field("day").Interval(1).SubAggregation("max", NewMaxAggregation().Field("x"))).
+		SubAggregation("by_hour", NewHistogramAggregation().Field("hour").Interval(1))
+
+	if err := root.CopyInto([]string{"by_day", "max"}, []string{"by_hour", "max"}); err != nil {
+		t.Fatal(err)
+	}
+	copied := root.Select("by_hour", "max")
+	if copied == nil || copied == root.Select("by_day", "max") {
+		t.Fatal("expected a copy of the aggregation injected")
+	}
+	if copied.String() != root.Select("by_day", "max").String() {
+		t.Fatalf("expected %s, got %s", root.Select("by_day", "max"), copied)
+	}
+
+	tests := []struct {
+		name       string
+		sourcePath []string
+		destPath   []string
+		want       error
+	}{
+		{"missing source", []string{"by_day", "min"}, []string{"by_hour", "min"}, ErrPathNotSelectable},
+		{"missing destination", []string{"by_day", "max"}, []string{"by_week", "max"}, ErrPathNotSelectable},
+		{"no path", nil, []string{"by_hour", "min"}, ErrNoPath},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := root.String()
+			if err := root.CopyInto(test.sourcePath, test.destPath); err != test.want {
+				t.Fatalf("expected %v, got %v", test.want, err)
+			}
+			if root.String() != before {
+				t.Fatalf("expected the tree unchanged, got %s", root)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package aggretastic
+
+// Cost is a rough estimation of how expensive an aggregation tree is to execute
+type Cost struct {
+	BucketAggs   int
+	MetricAggs   int
+	PipelineAggs int
+	// MaxDepth is the number of levels of subAggregations below the root (0 for a single aggregation)
+	MaxDepth int
+	// Score weighs all the aggregations together:
+	// every metric or pipeline aggregation adds 1 and every bucket aggregation adds 10,
+	// doubled for every bucket aggregation above it, as nested buckets multiply each other.
+	Score int
+	// Truncated is set when the tree is deeper than MaxAggregationDepth (e.g. cyclic),
+	// the aggregations below that depth aren't counted then
+	Truncated bool
+}
+
+// maxCostDoublings keeps the Score of absurdly deep trees from overflowing
+const maxCostDoublings = 30
+
+// EstimateCost walks the whole tree of root (root included) and estimates its Cost.
+// Aggregations which are neither bucket nor pipeline ones (e.g. made with Wrap) are counted as metrics.
+// Like the other traversals it stops at MaxAggregationDepth, see Cost.Truncated.
+func EstimateCost(root Aggregation) Cost {
+	var cost Cost
+	if !isNilAgg(root) {
+		estimateCost(&cost, root, 0, 0)
+	}
+
+	return cost
+}
+
+func estimateCost(cost *Cost, agg Aggregation, depth, bucketsAbove int) {
+	if depth > cost.MaxDepth {
+		cost.MaxDepth = depth
+	}
+
+	switch {
+	case IsBucketAggregation(agg):
+		cost.BucketAggs++
+		cost.Score += 10 << uint(minInt(bucketsAbove, maxCostDoublings))
+		bucketsAbove++
+	case IsPipelineAggregation(agg):
+		cost.PipelineAggs++
+		cost.Score++
+	default:
+		cost.MetricAggs++
+		cost.Score++
+	}
+
+	subs := agg.GetAllSubs()
+	if len(subs) > 0 && depth+1 >= MaxAggregationDepth {
+		cost.Truncated = true
+		return
+	}
+
+	for _, subAgg := range subs {
+		if !isNilAgg(subAgg) {
+			estimateCost(cost, subAgg, depth+1, bucketsAbove)
+		}
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,32 @@
+package aggretastic
+
+import "testing"
+
+func TestEstimateCost(t *testing.T) {
+	a := NewTermsAggregation().Field("a")
+	h := NewHistogramAggregation().Field("h").Interval(1)
+	a.SubAggregation("h", h)
+	h.SubAggregation("m", NewMaxAggregation().Field("m"))
+	h.SubAggregation("d", NewDerivativeAggregation().BucketsPath("m"))
+	h.GetAllSubs()["nil"] = nil
+
+	want := Cost{BucketAggs: 2, MetricAggs: 1, PipelineAggs: 1, MaxDepth: 2, Score: 32}
+	if c := EstimateCost(a); c != want {
+		t.Fatalf("expected %+v, got %+v", want, c)
+	}
+	if c := EstimateCost(nil); c != (Cost{}) {
+		t.Fatalf("expected zero cost of nil, got %+v", c)
+	}
+}
+
+func TestEstimateCostIsBoundedByMaxDepth(t *testing.T) {
+	a := NewTermsAggregation().Field("a")
+	b := NewTermsAggregation().Field("b")
+	a.SubAggregation("b", b)
+	b.GetAllSubs()["a"] = a
+
+	c := EstimateCost(a)
+	if !c.Truncated || c.BucketAggs != MaxAggregationDepth || c.MaxDepth != MaxAggregationDepth-1 {
+		t.Fatalf("expected the estimation truncated at depth %d, got %+v", MaxAggregationDepth, c)
+	}
+}
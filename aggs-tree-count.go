@@ -0,0 +1,80 @@
+package aggretastic
+
+import "strings"
+
+// Count returns the number of subAggs in the tree on all the levels (the aggregation itself is not counted)
+func (a *tree) Count() int {
+	return countSubs(a.subAggregations)
+}
+
+// Depth returns the number of levels of subAggs below the aggregation (0 if it has none)
+func (a *tree) Depth() int {
+	return depthOfSubs(a.subAggregations)
+}
+
+// ListPaths returns the paths of all the subAggs in the tree joined with PathSeparator
+// (depth-first, sorted by name), e.g. "genders", "genders>avg_height"
+func (a *tree) ListPaths() []string {
+	return listSubPaths(a.subAggregations)
+}
+
+// Count returns the number of aggregations in the map together with all their subAggs
+func (a *Aggregations) Count() int {
+	if a == nil {
+		return 0
+	}
+
+	return countSubs(*a)
+}
+
+// Depth returns the number of levels of the aggregations in the map,
+// the top-level ones included (1 for a map of aggregations without subAggs, 0 for an empty map)
+func (a *Aggregations) Depth() int {
+	if a == nil {
+		return 0
+	}
+
+	return depthOfSubs(*a)
+}
+
+// ListPaths returns the paths of all the aggregations in the map and their subAggs
+// joined with PathSeparator and prefixed with the top-level name (depth-first, sorted by name)
+func (a *Aggregations) ListPaths() []string {
+	if a == nil {
+		return nil
+	}
+
+	return listSubPaths(*a)
+}
+
+func countSubs(subs map[string]Aggregation) int {
+	count := 0
+	walkSubs(subs, nil, func(path []string, agg Aggregation) bool {
+		count++
+		return true
+	})
+
+	return count
+}
+
+func depthOfSubs(subs map[string]Aggregation) int {
+	depth := 0
+	walkSubs(subs, nil, func(path []string, agg Aggregation) bool {
+		if len(path) > depth {
+			depth = len(path)
+		}
+		return true
+	})
+
+	return depth
+}
+
+func listSubPaths(subs map[string]Aggregation) []string {
+	var paths []string
+	walkSubs(subs, nil, func(path []string, agg Aggregation) bool {
+		paths = append(paths, strings.Join(path, PathSeparator))
+		return true
+	})
+
+	return paths
+}
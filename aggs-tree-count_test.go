@@ -0,0 +1,38 @@
+package aggretastic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTreeCountDepthListPaths(t *testing.T) {
+	agg := NewTermsAggregation().Field("a").
+		SubAggregation("y", NewTermsAggregation().Field("y").SubAggregation("z", NewAvgAggregation().Field("z")))
+
+	if agg.Count() != 2 || agg.Depth() != 2 {
+		t.Fatalf("expected count 2 and depth 2, got %d and %d", agg.Count(), agg.Depth())
+	}
+	if want := []string{"y", "y>z"}; !reflect.DeepEqual(agg.ListPaths(), want) {
+		t.Fatalf("expected %v, got %v", want, agg.ListPaths())
+	}
+}
+
+func TestAggregationsCountDepthListPaths(t *testing.T) {
+	aggs := Aggregations{
+		"b": NewAvgAggregation().Field("b"),
+		"a": NewTermsAggregation().Field("a").
+			SubAggregation("y", NewTermsAggregation().Field("y").SubAggregation("z", NewAvgAggregation().Field("z"))),
+	}
+
+	if aggs.Count() != 4 || aggs.Depth() != 3 {
+		t.Fatalf("expected count 4 and depth 3, got %d and %d", aggs.Count(), aggs.Depth())
+	}
+	if want := []string{"a", "a>y", "a>y>z", "b"}; !reflect.DeepEqual(aggs.ListPaths(), want) {
+		t.Fatalf("expected %v, got %v", want, aggs.ListPaths())
+	}
+
+	empty := Aggregations{}
+	if empty.Count() != 0 || empty.Depth() != 0 || empty.ListPaths() != nil {
+		t.Fatalf("expected nothing in empty aggregations, got %d, %d, %v", empty.Count(), empty.Depth(), empty.ListPaths())
+	}
+}
@@ -0,0 +1,128 @@
+package aggretastic
+
+import "sort"
+
+// PathChangeKind tells how the aggregation at a path differs between two trees
+type PathChangeKind int
+
+const (
+	PathAdded PathChangeKind = iota
+	PathRemoved
+	PathModified
+)
+
+func (k PathChangeKind) String() string {
+	switch k {
+	case PathAdded:
+		return "added"
+	case PathRemoved:
+		return "removed"
+	case PathModified:
+		return "modified"
+	default:
+		return "unknown"
+	}
+}
+
+// PathChange is a difference between two trees found by Diff.
+// The empty Path stands for the root aggregations.
+type PathChange struct {
+	Kind PathChangeKind
+	Path Path
+}
+
+// String returns the change in a readable form, e.g. "added genders>avg_height"
+func (c PathChange) String() string {
+	if len(c.Path) == 0 {
+		return c.Kind.String() + " <root>"
+	}
+
+	return c.Kind.String() + " " + c.Path.String()
+}
+
+// Diff returns the differences between the trees of a and b ordered by path:
+// the subAggs present in b only are added, the ones present in a only are removed,
+// and the aggregations present in both are modified if their own sources differ.
+// The sources are compared without subAggs, so a change deep in the tree
+// is reported at its path only and not at every parent of it.
+// Aggregations which can't be rendered are compared by their errors.
+func Diff(a, b Aggregation) []PathChange {
+	before := diffNodes(a)
+	after := diffNodes(b)
+
+	keys := make([]string, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	var changes []PathChange
+	for _, key := range keys {
+		old, inBefore := before[key]
+		cur, inAfter := after[key]
+
+		switch {
+		case !inBefore:
+			changes = append(changes, PathChange{Kind: PathAdded, Path: cur.path})
+		case !inAfter:
+			changes = append(changes, PathChange{Kind: PathRemoved, Path: old.path})
+		case sourceString(shallowSource{old.agg}) != sourceString(shallowSource{cur.agg}):
+			changes = append(changes, PathChange{Kind: PathModified, Path: cur.path})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return lessPath(changes[i].Path, changes[j].Path)
+	})
+
+	return changes
+}
+
+type diffNode struct {
+	path Path
+	agg  Aggregation
+}
+
+// diffNodes returns root and all its subAggs keyed by their paths
+func diffNodes(root Aggregation) map[string]diffNode {
+	nodes := make(map[string]diffNode)
+	if IsNilTree(root) {
+		return nodes
+	}
+
+	// the names can't contain PathSeparator in Elasticsearch, so the joined path is unique
+	nodes[""] = diffNode{path: Path{}, agg: root}
+	root.Walk(func(path []string, agg Aggregation) bool {
+		if agg == nil {
+			return false
+		}
+		nodes[Path(path).String()] = diffNode{path: path, agg: agg}
+		return true
+	})
+
+	return nodes
+}
+
+// shallowSource renders agg without its subAggregations
+type shallowSource struct {
+	agg Aggregation
+}
+
+func (s shallowSource) Source() (interface{}, error) {
+	return sourceAt(s.agg, shallowDepth)
+}
+
+// lessPath orders the paths name by name, the parents go before their subAggs
+func lessPath(a, b Path) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+
+	return len(a) < len(b)
+}
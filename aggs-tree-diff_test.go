@@ -0,0 +1,37 @@
+package aggretastic
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	saved := NewTermsAggregation().Field("user").
+		SubAggregation("by_day", NewTermsAggregation().Field("day").SubAggregation("avg", NewAvgAggregation().Field("price"))).
+		SubAggregation("gone", NewMaxAggregation().Field("price"))
+	edited := Clone(saved).(*TermsAggregation)
+
+	if changes := Diff(saved, edited); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+
+	edited.Field("account")
+	edited.Pop("gone")
+	edited.Select("by_day", "avg").(*AvgAggregation).Field("cost")
+	if err := edited.Inject(NewTopHitsAggregation(), "by_day", "hits"); err != nil {
+		t.Fatal(err)
+	}
+	if err := edited.Inject(NewSumAggregation().Field("price"), "total"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "[modified <root> modified by_day>avg added by_day>hits removed gone added total]"
+	if got := fmt.Sprint(Diff(saved, edited)); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	changes := Diff(nil, NewAvgAggregation().Field("x"))
+	if len(changes) != 1 || changes[0].String() != "added <root>" {
+		t.Fatalf("expected the root added, got %v", changes)
+	}
+}
@@ -0,0 +1,43 @@
+package aggretastic
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Dump writes an indented outline of the subAggregations tree to w:
+// one line per subAggregation with its name and concrete type, e.g.
+//
+//	by_country (*TermsAggregation)
+//	  avg_price (*AvgAggregation)
+//
+// Names are sorted on every level so the output is stable.
+// It is meant for debugging only and doesn't touch Source().
+// Trees deeper than MaxAggregationDepth are written down to it and ErrMaxDepthExceeded is returned.
+func (a *tree) Dump(w io.Writer) error {
+	return dumpSubs(w, a.subAggregations, 0)
+}
+
+func dumpSubs(w io.Writer, subs map[string]Aggregation, depth int) error {
+	if len(subs) > 0 && depth >= MaxAggregationDepth {
+		return ErrMaxDepthExceeded
+	}
+	names := sortedNames(subs)
+
+	for _, name := range names {
+		sub := subs[name]
+		if _, err := fmt.Fprintf(w, "%s%s (%s)\n", strings.Repeat("  ", depth), name, aggTypeName(sub)); err != nil {
+			return err
+		}
+
+		if isNilAgg(sub) {
+			continue
+		}
+		if err := dumpSubs(w, sub.GetAllSubs(), depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
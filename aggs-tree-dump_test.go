@@ -0,0 +1,25 @@
+package aggretastic
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	root := NewTermsAggregation().Field("country").
+		SubAggregation("price", NewAvgAggregation().Field("price")).
+		SubAggregation("by_month", NewDateHistogramAggregation().Field("date").Interval("1M").
+			SubAggregation("change", NewDerivativeAggregation().BucketsPath("_count")))
+
+	var buf bytes.Buffer
+	if err := root.Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "by_month (*DateHistogramAggregation)\n" +
+		"  change (*DerivativeAggregation)\n" +
+		"price (*AvgAggregation)\n"
+	if buf.String() != want {
+		t.Fatalf("expected\n%s\ngot\n%s", want, buf.String())
+	}
+}
@@ -0,0 +1,49 @@
+package aggretastic
+
+// FieldGetter is implemented by the aggregations working on a single field
+type FieldGetter interface {
+	GetField() string
+}
+
+// FieldSetter is implemented by the aggregations working on a single field,
+// so generic code can change the field without knowing the concrete aggregation
+type FieldSetter interface {
+	SetField(field string)
+}
+
+// RewriteFields replaces the fields of root and all its subAggs according to mapping
+// (the old name to the new one), e.g. after a reindex renamed "user.id" to "user_id".
+// Only the aggregations implementing both FieldGetter and FieldSetter are rewritten.
+// It returns the number of the aggregations changed.
+func RewriteFields(root Aggregation, mapping map[string]string) int {
+	if isNilAgg(root) {
+		return 0
+	}
+
+	changed := 0
+	rewrite := func(agg Aggregation) {
+		getter, ok := agg.(FieldGetter)
+		if !ok {
+			return
+		}
+		setter, ok := agg.(FieldSetter)
+		if !ok {
+			return
+		}
+		if field, ok := mapping[getter.GetField()]; ok && field != getter.GetField() {
+			setter.SetField(field)
+			changed++
+		}
+	}
+
+	rewrite(root)
+	root.Walk(func(path []string, agg Aggregation) bool {
+		if isNilAgg(agg) {
+			return false
+		}
+		rewrite(agg)
+		return true
+	})
+
+	return changed
+}
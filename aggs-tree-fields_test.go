@@ -0,0 +1,48 @@
+package aggretastic
+
+import "testing"
+
+func TestRewriteFields(t *testing.T) {
+	agg := NewTermsAggregation().Field("user.id").
+		SubAggregation("prices", NewHistogramAggregation().Field("price").Interval(1).
+			SubAggregation("avg", NewAvgAggregation().Field("user.age")).
+			SubAggregation("max", NewMaxAggregation().Field("user.age"))).
+		SubAggregation("hits", NewTopHitsAggregation()).
+		SubAggregation("other", NewCardinalityAggregation().Field("other"))
+
+	changed := RewriteFields(agg, map[string]string{"user.id": "user_id", "user.age": "user_age", "missing": "x"})
+	if changed != 3 {
+		t.Fatalf("expected 3 fields rewritten, got %d", changed)
+	}
+
+	want := `{"aggregations":{"hits":{"top_hits":{}},"other":{"cardinality":{"field":"other"}},"prices":{"aggregations":{"avg":{"avg":{"field":"user_age"}},"max":{"max":{"field":"user_age"}}},"histogram":{"field":"price","interval":1}}},"terms":{"field":"user_id"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	if changed := RewriteFields(agg, map[string]string{"user.id": "user_id"}); changed != 0 {
+		t.Fatalf("expected nothing rewritten again, got %d", changed)
+	}
+}
+
+var (
+	_ FieldGetter = (*StatsAggregation)(nil)
+	_ FieldSetter = (*StatsAggregation)(nil)
+)
+
+func TestFieldInterfaces(t *testing.T) {
+	var agg Aggregation = NewStatsAggregation().Field("grade")
+
+	agg.(FieldSetter).SetField("score")
+	if got := agg.(FieldGetter).GetField(); got != "score" {
+		t.Fatalf("expected %s, got %s", "score", got)
+	}
+	want := `{"stats":{"field":"score"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	if _, ok := Aggregation(NewTopHitsAggregation()).(FieldGetter); ok {
+		t.Fatal("expected TopHitsAggregation to have no field")
+	}
+}
@@ -0,0 +1,71 @@
+package aggretastic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PathSeparator joins the path segments in the keys of maps returned by tree helpers.
+// It's the same separator Elasticsearch uses between aggregation names in a buckets path.
+const PathSeparator = ">"
+
+// FindByType returns every subAgg of root which type is typeName: either the Elasticsearch
+// type returned by AggregationType(), e.g. "terms", or the concrete Go type, e.g. "TermsAggregation"
+// (or "*TermsAggregation").
+// The result is keyed by the subAgg path joined with PathSeparator.
+// The root itself is not matched as it has no path.
+func FindByType(root Aggregation, typeName string) map[string]Aggregation {
+	result := make(map[string]Aggregation)
+	if root == nil {
+		return result
+	}
+
+	typeName = strings.TrimPrefix(typeName, "*")
+	root.Walk(func(path []string, agg Aggregation) bool {
+		if isNilAgg(agg) {
+			return false
+		}
+		if agg.AggregationType() == typeName || strings.TrimPrefix(aggTypeName(agg), "*") == typeName {
+			result[strings.Join(path, PathSeparator)] = agg
+		}
+		return true
+	})
+
+	return result
+}
+
+// aggTypeName returns the concrete type name of agg without the package name, e.g. "*TermsAggregation"
+func aggTypeName(agg Aggregation) string {
+	return strings.Replace(fmt.Sprintf("%T", agg), "aggretastic.", "", 1)
+}
+
+// RelativePath returns the buckets_path from the scope of from to the subAgg to,
+// e.g. "sales_per_month>sales" for a pipeline aggregation injected into from
+// that points to the "sales" metric of the "sales_per_month" bucket aggregation.
+// from is the parent of the pipeline aggregation; to is found in its subtree by reference.
+// The path to a single value of a multi-value metric is built by adding it after a dot,
+// e.g. RelativePath(...) + ".avg".
+// It returns ErrAggNotReachable if to isn't in the subtree of from.
+func RelativePath(from Aggregation, to Aggregation) (string, error) {
+	if from == nil || to == nil {
+		return "", ErrAggNotReachable
+	}
+
+	var found []string
+	from.Walk(func(path []string, agg Aggregation) bool {
+		if found != nil {
+			return false
+		}
+		if agg == to {
+			found = append([]string{}, path...)
+			return false
+		}
+		return true
+	})
+
+	if found == nil {
+		return "", ErrAggNotReachable
+	}
+
+	return strings.Join(found, PathSeparator), nil
+}
@@ -0,0 +1,23 @@
+package aggretastic
+
+import "testing"
+
+func TestRelativePath(t *testing.T) {
+	root := NewFilterAggregation().FilterRaw(map[string]interface{}{"match_all": map[string]interface{}{}})
+	perMonth := NewDateHistogramAggregation().Field("date").Interval("month")
+	sales := NewSumAggregation().Field("price")
+	root.SubAggregation("sales_per_month", perMonth)
+	perMonth.SubAggregation("sales", sales)
+
+	path, err := RelativePath(root, sales)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "sales_per_month>sales" {
+		t.Fatalf("expected %q, got %q", "sales_per_month>sales", path)
+	}
+
+	if _, err := RelativePath(perMonth, root); err != ErrAggNotReachable {
+		t.Fatalf("expected %v, got %v", ErrAggNotReachable, err)
+	}
+}
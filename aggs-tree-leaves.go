@@ -0,0 +1,114 @@
+package aggretastic
+
+// IsBucketAggregation reports whether agg is a bucket aggregation, i.e. a container
+// which splits documents into buckets for its subAggregations (terms, histogram, filter etc.)
+func IsBucketAggregation(agg Aggregation) bool {
+	switch agg.(type) {
+	case *AdjacencyMatrixAggregation,
+		*AutoDateHistogramAggregation,
+		*ChildrenAggregation,
+		*CompositeAggregation,
+		*DateHistogramAggregation,
+		*DateRangeAggregation,
+		*DiversifiedSamplerAggregation,
+		*FilterAggregation,
+		*FiltersAggregation,
+		*GeoDistanceAggregation,
+		*GeoHashGridAggregation,
+		*GlobalAggregation,
+		*HistogramAggregation,
+		*IPRangeAggregation,
+		*MissingAggregation,
+		*MultiTermsAggregation,
+		*NestedAggregation,
+		*RangeAggregation,
+		*RareTermsAggregation,
+		*ReverseNestedAggregation,
+		*SamplerAggregation,
+		*SignificantTermsAggregation,
+		*SignificantTextAggregation,
+		*TermsAggregation,
+		*VariableWidthHistogramAggregation:
+		return true
+	}
+
+	return false
+}
+
+// IsPipelineAggregation reports whether agg is a pipeline aggregation, i.e. the one working
+// on the outputs of other aggregations (referenced by buckets_path) instead of documents
+func IsPipelineAggregation(agg Aggregation) bool {
+	switch agg.(type) {
+	case *AvgBucketAggregation,
+		*BucketScriptAggregation,
+		*BucketSelectorAggregation,
+		*BucketSortAggregation,
+		*CumulativeCardinalityAggregation,
+		*CumulativeSumAggregation,
+		*DerivativeAggregation,
+		*InferenceBucketAggregation,
+		*MaxBucketAggregation,
+		*MinBucketAggregation,
+		*MovAvgAggregation,
+		*MovingPercentilesAggregation,
+		*NormalizeAggregation,
+		*PercentilesBucketAggregation,
+		*SerialDiffAggregation,
+		*StatsBucketAggregation,
+		*SumBucketAggregation:
+		return true
+	}
+
+	return false
+}
+
+// AttachToLeaves injects a clone of every metric into every bucket aggregation
+// of the tree (including root) which has no subAggregations yet.
+// It returns the number of modified bucket aggregations. The metrics which are nil,
+// can't be cloned or have no name are skipped.
+func AttachToLeaves(root Aggregation, metrics map[string]Aggregation) int {
+	if isNilAgg(root) {
+		return 0
+	}
+
+	var names []string
+	for _, name := range sortedNames(metrics) {
+		if name != "" && !isNilAgg(metrics[name]) && Clone(metrics[name]) != nil {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return 0
+	}
+
+	var leaves []Aggregation
+	collect := func(agg Aggregation) {
+		if IsBucketAggregation(agg) && len(agg.GetAllSubs()) == 0 {
+			leaves = append(leaves, agg)
+		}
+	}
+
+	collect(root)
+	root.Walk(func(path []string, agg Aggregation) bool {
+		if isNilAgg(agg) {
+			return false
+		}
+		collect(agg)
+		return true
+	})
+
+	modified := 0
+	for _, leaf := range leaves {
+		injected := false
+		for _, name := range names {
+			if leaf.InjectStrict(Clone(metrics[name]), name) == nil {
+				injected = true
+			}
+		}
+		if injected {
+			modified++
+		}
+	}
+
+	return modified
+}
@@ -0,0 +1,63 @@
+package aggretastic
+
+import (
+	"testing"
+
+	"github.com/olivere/elastic"
+)
+
+func TestAttachToLeaves(t *testing.T) {
+	root := NewTermsAggregation().Field("user")
+	root.SubAggregation("by_day", NewDateHistogramAggregation().Field("ts").Interval("1d"))
+	root.SubAggregation("by_price", NewHistogramAggregation().Field("price").Interval(10).
+		SubAggregation("avg", NewAvgAggregation().Field("price")))
+
+	sum := NewSumAggregation().Field("amount")
+	modified := AttachToLeaves(root, map[string]Aggregation{"total": sum, "max": NewMaxAggregation().Field("amount")})
+	if modified != 1 {
+		t.Fatalf("expected 1 modified leaf, got %d", modified)
+	}
+
+	total := root.Select("by_day", "total")
+	if IsNilTree(total) || IsNilTree(root.Select("by_day", "max")) {
+		t.Fatalf("expected the metrics under the leaf, got %s", root)
+	}
+	if total == Aggregation(sum) {
+		t.Fatal("expected a clone of the metric to be attached")
+	}
+	if !IsNilTree(root.Select("by_price", "total")) {
+		t.Fatal("expected the bucket with subAggregations to be skipped")
+	}
+}
+
+func TestAttachToLeavesSkipsBadMetrics(t *testing.T) {
+	root := NewTermsAggregation().Field("user")
+	root.SubAggregation("by_day", NewDateHistogramAggregation().Field("ts").Interval("1d"))
+	root.SubAggregation("by_price", NewHistogramAggregation().Field("price").Interval(10))
+
+	var typedNil *AvgAggregation
+	metrics := map[string]Aggregation{
+		"avg":     NewAvgAggregation().Field("x"),
+		"":        NewSumAggregation().Field("x"),
+		"nil":     nil,
+		"typed":   typedNil,
+		"foreign": foreignAgg{Wrap("foreign", elastic.NewRawStringQuery(`{"avg":{"field":"x"}}`))},
+	}
+	if modified := AttachToLeaves(root, metrics); modified != 2 {
+		t.Fatalf("expected 2 modified leaves, got %d", modified)
+	}
+	for _, leaf := range []string{"by_day", "by_price"} {
+		if subs := root.Select(leaf).GetAllSubs(); len(subs) != 1 || IsNilTree(subs["avg"]) {
+			t.Fatalf("expected only the avg attached to %s, got %s", leaf, root)
+		}
+	}
+
+	if modified := AttachToLeaves(NewTermsAggregation().Field("user"), map[string]Aggregation{"nil": nil}); modified != 0 {
+		t.Fatalf("expected nothing modified, got %d", modified)
+	}
+}
+
+// foreignAgg is an Aggregation implemented outside of the package, which Clone doesn't know
+type foreignAgg struct {
+	Aggregation
+}
@@ -0,0 +1,88 @@
+package aggretastic
+
+import (
+	"sort"
+	"strings"
+)
+
+// SelectMatch returns every subAgg which path matches the pattern.
+// Every pattern segment matches a single path segment and may contain
+// `*` wildcards matching any run of characters, e.g. SelectMatch("*", "top_*").
+// All the other characters are matched literally. The result is ordered by path.
+func (a *tree) SelectMatch(pattern ...string) []Aggregation {
+	var result []Aggregation
+	matchSubs(a, a.subAggregations, pattern, func(owner dirtyMarker, subs map[string]Aggregation, name string) {
+		result = append(result, subs[name])
+	})
+
+	return result
+}
+
+// PopMatch returns every subAgg which path matches the pattern and removes them from tree.
+// See SelectMatch for the pattern syntax. The result is ordered by path.
+func (a *tree) PopMatch(pattern ...string) []Aggregation {
+	var result []Aggregation
+	matchSubs(a, a.subAggregations, pattern, func(owner dirtyMarker, subs map[string]Aggregation, name string) {
+		result = append(result, subs[name])
+		delete(subs, name)
+		if owner != nil {
+			owner.markDirty()
+		}
+	})
+
+	return result
+}
+
+// matchSubs calls fn with the parent (nil for the aggregations not of this package), its map of subAggs
+// and the name of every subAgg matching the pattern
+func matchSubs(owner dirtyMarker, subs map[string]Aggregation, pattern []string, fn func(owner dirtyMarker, subs map[string]Aggregation, name string)) {
+	if len(pattern) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(subs))
+	for name := range subs {
+		if matchWildcard(pattern[0], name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if len(pattern) == 1 {
+			fn(owner, subs, name)
+			continue
+		}
+
+		if subAgg := subs[name]; !isNilAgg(subAgg) {
+			subOwner, _ := subAgg.(dirtyMarker)
+			matchSubs(subOwner, subAgg.GetAllSubs(), pattern[1:], fn)
+		}
+	}
+}
+
+// matchWildcard reports whether name matches the pattern, where `*` matches any run
+// of characters (the empty one and "/" included) and the rest is matched literally
+func matchWildcard(pattern, name string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == name
+	}
+
+	// the text before the first `*` and after the last one is anchored,
+	// the parts in between are taken at their leftmost match
+	first, last := parts[0], parts[len(parts)-1]
+	if len(name) < len(first)+len(last) || !strings.HasPrefix(name, first) || !strings.HasSuffix(name, last) {
+		return false
+	}
+	name = name[len(first) : len(name)-len(last)]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, part)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(part):]
+	}
+
+	return true
+}
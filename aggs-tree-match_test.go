@@ -0,0 +1,91 @@
+package aggretastic
+
+import "testing"
+
+func newMatchTree() *TermsAggregation {
+	return NewTermsAggregation().Field("root").
+		SubAggregation("a", NewTermsAggregation().Field("a").
+			SubAggregation("top_1", NewAvgAggregation().Field("x")).
+			SubAggregation("keep", NewAvgAggregation().Field("y"))).
+		SubAggregation("b", NewTermsAggregation().Field("b").
+			SubAggregation("top_2", NewAvgAggregation().Field("z")))
+}
+
+func TestSelectMatch(t *testing.T) {
+	root := newMatchTree()
+
+	found := root.SelectMatch("*", "top_*")
+	if len(found) != 2 || found[0] != root.Select("a", "top_1") || found[1] != root.Select("b", "top_2") {
+		t.Fatalf("expected a>top_1 and b>top_2, got %v", found)
+	}
+}
+
+func TestPopMatch(t *testing.T) {
+	root := newMatchTree()
+	a, b := root.Select("a"), root.Select("b")
+	versionA, versionB, versionRoot := a.(versioned).sourceVersion(), b.(versioned).sourceVersion(), root.sourceVersion()
+
+	popped := root.PopMatch("*", "top_*")
+	if len(popped) != 2 {
+		t.Fatalf("expected 2 subAggs popped, got %d", len(popped))
+	}
+	if root.Select("a", "keep") == nil || root.Select("a", "top_1") != nil || root.Select("b", "top_2") != nil {
+		t.Fatalf("expected the matching subAggs removed only, got %v", root.ListPaths())
+	}
+	if a.(versioned).sourceVersion() == versionA || b.(versioned).sourceVersion() == versionB {
+		t.Fatal("expected the parents of the popped subAggs to be marked dirty")
+	}
+	if root.sourceVersion() != versionRoot {
+		t.Fatal("expected the root not changed to stay clean")
+	}
+}
+
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*", "", true},
+		{"*", "by/day", true},
+		{"top_*", "top_1", true},
+		{"top_*", "top/1", false},
+		{"*_sum", "by/day_sum", true},
+		{"a*b*c", "abc", true},
+		{"a*b*c", "a/xb/yc", true},
+		{"a*b*c", "acb", false},
+		{"a*a", "a", false},
+		{"top_?", "top_1", false},
+		{"top_?", "top_?", true},
+		{"[ab]", "a", false},
+		{"[ab]", "[ab]", true},
+		{"[a-", "[a-", true},
+		{`a\*`, `a\b`, true},
+		{`a\*`, "a*", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.name, func(t *testing.T) {
+			if got := matchWildcard(test.pattern, test.name); got != test.want {
+				t.Fatalf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestMatchTakesSpecialCharactersLiterally(t *testing.T) {
+	root := NewTermsAggregation().Field("root").
+		SubAggregation("[a-", NewAvgAggregation().Field("x")).
+		SubAggregation("by/day", NewAvgAggregation().Field("y")).
+		SubAggregation("b", NewAvgAggregation().Field("z"))
+
+	if found := root.SelectMatch("[a-"); len(found) != 1 || found[0] != root.Select("[a-") {
+		t.Fatalf("expected the subAgg named [a-, got %v", found)
+	}
+	if found := root.SelectMatch("?"); len(found) != 0 {
+		t.Fatalf("expected nothing matched, got %v", found)
+	}
+	if found := root.SelectMatch("by*"); len(found) != 1 || found[0] != root.Select("by/day") {
+		t.Fatalf("expected the subAgg named by/day, got %v", found)
+	}
+}
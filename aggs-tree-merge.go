@@ -0,0 +1,85 @@
+package aggretastic
+
+// Merge recursively merges the subAggregations of src into dst.
+// The subAggs dst doesn't have are injected into it, when both of them have a subAgg
+// with the same name the merge goes on with their subAggregations.
+// On conflicts (the same name refers to leaves or a leaf can't take the subAggs of the other one)
+// the subAgg of dst is kept unless overwrite is true.
+// A leaf of src never replaces a subAgg of dst having subAggs of its own: with overwrite
+// Merge returns ErrMergeConflict instead of dropping the subtree.
+// The nil subAggs of src are skipped, the trees deeper than MaxAggregationDepth make it
+// return ErrMaxDepthExceeded. On errors dst is left unchanged.
+// The subAggs of src are cloned, so further changes of dst don't affect src.
+func Merge(dst, src Aggregation, overwrite bool) error {
+	if IsNilTree(dst) || IsNilTree(src) {
+		return ErrPathNotSelectable
+	}
+
+	// the first pass only checks the trees, so dst is changed only when the merge can't fail
+	if err := mergeSubs(dst, src, overwrite, 0, false); err != nil {
+		return err
+	}
+
+	return mergeSubs(dst, src, overwrite, 0, true)
+}
+
+// mergeSubs merges the subAggs of src found at the depth into dst.
+// It only checks whether the merge is possible unless apply is true.
+func mergeSubs(dst, src Aggregation, overwrite bool, depth int, apply bool) error {
+	srcSubs := src.GetAllSubs()
+	if len(srcSubs) == 0 {
+		return nil
+	}
+	if depth >= MaxAggregationDepth {
+		return ErrMaxDepthExceeded
+	}
+
+	dstSubs := dst.GetAllSubs()
+	if dstSubs == nil {
+		return ErrAggIsNotInjectable
+	}
+
+	for _, name := range sortedNames(srcSubs) {
+		srcSub := srcSubs[name]
+		if isNilAgg(srcSub) {
+			continue
+		}
+		dstSub, ok := dstSubs[name]
+
+		switch {
+		case !ok || isNilAgg(dstSub):
+		case len(srcSub.GetAllSubs()) > 0 && !IsNotInjectable(dstSub):
+			if err := mergeSubs(dstSub, srcSub, overwrite, depth+1, apply); err != nil {
+				return err
+			}
+			continue
+		case !overwrite:
+			continue
+		case len(dstSub.GetAllSubs()) > 0:
+			return ErrMergeConflict
+		}
+
+		if !apply {
+			// the subAggs which can't be cloned are injected as they are
+			if Clone(srcSub) == nil && isReachable(dst, srcSub) {
+				return ErrCycleDetected
+			}
+			continue
+		}
+
+		if err := dst.Inject(cloneOrSelf(srcSub), name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cloneOrSelf returns a clone of agg or agg itself if it can't be cloned
+func cloneOrSelf(agg Aggregation) Aggregation {
+	if c := Clone(agg); c != nil {
+		return c
+	}
+
+	return agg
+}
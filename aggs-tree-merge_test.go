@@ -0,0 +1,96 @@
+package aggretastic
+
+import "testing"
+
+func newMergeSrc() *TermsAggregation {
+	src := NewTermsAggregation().Field("src")
+	src.SubAggregation("h", NewDateHistogramAggregation().Field("other").
+		SubAggregation("m", NewMaxAggregation().Field("b")).
+		SubAggregation("n", NewMinAggregation().Field("c")))
+	src.SubAggregation("x", NewSumAggregation().Field("x"))
+
+	return src
+}
+
+func TestMerge(t *testing.T) {
+	dst := NewTermsAggregation().Field("dst")
+	dst.SubAggregation("h", NewDateHistogramAggregation().Field("d").SubAggregation("m", NewMaxAggregation().Field("a")))
+	src := newMergeSrc()
+
+	if err := Merge(dst, src, false); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Select("h", "n") == nil || dst.Select("x") == nil {
+		t.Fatalf("expected the missing subAggs merged, got %v", dst.ListPaths())
+	}
+	if dst.Select("h", "m").(*MaxAggregation).field != "a" {
+		t.Fatal("expected the leaf of dst kept without overwrite")
+	}
+
+	if err := Merge(dst, src, true); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Select("h", "m").(*MaxAggregation).field != "b" {
+		t.Fatal("expected the leaf of src to win with overwrite")
+	}
+	if dst.Select("h", "n") == src.Select("h", "n") {
+		t.Fatal("expected the subAggs of src to be cloned")
+	}
+}
+
+func TestMergeSkipsNilSubs(t *testing.T) {
+	dst := NewTermsAggregation().Field("dst")
+	dst.SubAggregation("x", NewSumAggregation().Field("y"))
+	src := newMergeSrc()
+	src.GetAllSubs()["nil"] = nil
+	src.GetAllSubs()["x"] = nil
+
+	if err := Merge(dst, src, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := dst.GetAllSubs()["nil"]; ok {
+		t.Fatal("expected the nil subAgg of src skipped")
+	}
+	if dst.Select("x").(*SumAggregation).field != "y" {
+		t.Fatal("expected the nil subAgg of src not to overwrite dst")
+	}
+}
+
+func TestMergeDoesNotReplaceSubtreeWithLeaf(t *testing.T) {
+	dst := NewTermsAggregation().Field("dst")
+	dst.SubAggregation("a", NewSumAggregation().Field("a"))
+	dst.SubAggregation("x", NewTermsAggregation().Field("x").SubAggregation("m", NewMaxAggregation().Field("m")))
+	src := NewTermsAggregation().Field("src")
+	src.SubAggregation("a", NewSumAggregation().Field("b"))
+	src.SubAggregation("x", NewSumAggregation().Field("x"))
+	want := dst.String()
+
+	if err := Merge(dst, src, true); err != ErrMergeConflict {
+		t.Fatalf("expected ErrMergeConflict, got %v", err)
+	}
+	if dst.String() != want {
+		t.Fatalf("expected dst unchanged, got %s", dst)
+	}
+
+	if err := Merge(dst, src, false); err != nil {
+		t.Fatal(err)
+	}
+	if dst.String() != want {
+		t.Fatalf("expected dst kept without overwrite, got %s", dst)
+	}
+}
+
+func TestMergeIsBoundedByMaxDepth(t *testing.T) {
+	newCycle := func() *TermsAggregation {
+		a := NewTermsAggregation().Field("a")
+		b := NewTermsAggregation().Field("b")
+		a.SubAggregation("b", b)
+		b.GetAllSubs()["a"] = a
+		return a
+	}
+
+	dst, src := newCycle(), newCycle()
+	if err := Merge(dst, src, false); err != ErrMaxDepthExceeded {
+		t.Fatalf("expected ErrMaxDepthExceeded, got %v", err)
+	}
+}
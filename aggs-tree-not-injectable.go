@@ -1,21 +1,39 @@
 package aggretastic
 
-import (
-	"fmt"
-	"github.com/olivere/elastic"
-)
+import "github.com/olivere/elastic"
 
 type notInjectable struct {
-	root elastic.Aggregation
+	root    elastic.Aggregation
+	name    string
+	version uint64
 }
 
 func newNotInjectable(root elastic.Aggregation) *notInjectable {
 	return &notInjectable{root: root}
 }
 
+// IsNotInjectable reports whether agg can't have subAggregations.
+// Concrete aggregations embed *notInjectable, so the check relies on GetAllSubs()
+// instead of a type assertion.
 func IsNotInjectable(agg Aggregation) bool {
-	_, ok := agg.(*notInjectable)
-	return ok
+	return agg != nil && agg.GetAllSubs() == nil
+}
+
+func (a *notInjectable) GetName() string {
+	return a.name
+}
+
+func (a *notInjectable) setName(name string) {
+	a.name = name
+}
+
+func (a *notInjectable) sourceVersion() uint64 {
+	return a.version
+}
+
+// markDirty is called by every setter, so the cached sources containing the aggregation are rendered again
+func (a *notInjectable) markDirty() {
+	a.version++
 }
 
 func (a *notInjectable) Inject(subAggregation Aggregation, path ...string) error {
@@ -26,28 +44,45 @@ func (a *notInjectable) InjectX(subAggregation Aggregation, path ...string) erro
 	return ErrAggIsNotInjectable
 }
 
+func (a *notInjectable) InjectStrict(subAggregation Aggregation, path ...string) error {
+	return ErrAggIsNotInjectable
+}
+
 func (a *notInjectable) GetAllSubs() map[string]Aggregation {
 	return nil
 }
 
 func (a *notInjectable) Select(path ...string) Aggregation {
 	// nothing to select because of no subAggregations
-	s, _ := a.root.Source()
-	fmt.Printf("notInjectable.Select() is ignored. The aggregation doesn't allow to have subAggregations. Root: %s", s)
 	return nil
 }
 
 func (a *notInjectable) Pop(path ...string) Aggregation {
-	// nothing to select because of no subAggregations
-	s, _ := a.root.Source()
-	fmt.Printf("notInjectable.Select() is ignored. The aggregation doesn't allow to have subAggregations. Root: %s", s)
+	// nothing to pop because of no subAggregations
 	return nil
 }
 
+func (a *notInjectable) Walk(fn func(path []string, agg Aggregation) bool) {
+	// nothing to walk because of no subAggregations
+}
+
 func (a *notInjectable) Export() elastic.Aggregation {
 	return a.root
 }
 
+func (a *notInjectable) Validate() error {
+	// nothing is required unless the concrete aggregation says so
+	return nil
+}
+
+func (a *notInjectable) Unwrap() elastic.Aggregation {
+	return nil
+}
+
+func (a *notInjectable) String() string {
+	return sourceString(a.root)
+}
+
 func (a *notInjectable) Source() (interface{}, error) {
 	return a.root.Source()
 }
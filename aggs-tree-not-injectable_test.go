@@ -0,0 +1,34 @@
+package aggretastic
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestNotInjectableSelectAndPopAreSilent(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+
+	root := NewTermsAggregation().Field("user")
+	root.SubAggregation("avg", NewAvgAggregation().Field("price"))
+	root.SubAggregation("top", NewTopHitsAggregation().Size(1))
+	selected := root.Select("top", "x")
+	popped := root.Pop("top", "x")
+	_, parentPath := root.ParentOf("top", "x")
+
+	os.Stdout = stdout
+	w.Close()
+	out, _ := ioutil.ReadAll(r)
+
+	if selected != nil || popped != nil || parentPath != nil {
+		t.Fatalf("expected nothing to be found under a metric, got %v %v %v", selected, popped, parentPath)
+	}
+	if len(out) != 0 {
+		t.Fatalf("expected no output, got %q", out)
+	}
+}
@@ -0,0 +1,1060 @@
+package aggretastic
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/olivere/elastic"
+	"sort"
+)
+
+// parseAggregation makes the aggregation of the given type (e.g. "terms") out of the options
+// found under the type key of its source, without meta and subAggregations.
+// It returns false if the type isn't modeled by this package or some of the options
+// can't be set through its setters. Values like queries, sorters or composite value sources
+// are kept as they are (see rawSource), everything else is set with the type specific setters.
+func parseAggregation(typ string, value interface{}) (Aggregation, bool) {
+	// the filter aggregation has the query itself under its type key
+	if typ == "filter" {
+		query, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		return NewFilterAggregation().FilterRaw(plainMap(query)), true
+	}
+
+	options, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	parse, ok := sourceParsers[typ]
+	if !ok {
+		return nil, false
+	}
+
+	o := newSourceOptions(options)
+	agg := parse(o)
+
+	return agg, o.done() == nil
+}
+
+// sourceParsers parse the options of the aggregations by their type keys
+var sourceParsers = map[string]func(o *sourceOptions) Aggregation{
+	// metrics
+	"avg": func(o *sourceOptions) Aggregation {
+		a := NewAvgAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		return a
+	},
+	"boxplot": func(o *sourceOptions) Aggregation {
+		a := NewBoxplotAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.value("missing", func(v interface{}) { a.Missing(v) })
+		o.float("compression", func(v float64) { a.Compression(v) })
+		return a
+	},
+	"cardinality": func(o *sourceOptions) Aggregation {
+		a := NewCardinalityAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		o.int64("precision_threshold", func(v int64) { a.PrecisionThreshold(v) })
+		o.bool("rehash", func(v bool) { a.Rehash(v) })
+		return a
+	},
+	"extended_stats": func(o *sourceOptions) Aggregation {
+		a := NewExtendedStatsAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		return a
+	},
+	"geo_bounds": func(o *sourceOptions) Aggregation {
+		a := NewGeoBoundsAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.bool("wrap_longitude", func(v bool) { a.WrapLongitude(v) })
+		return a
+	},
+	"geo_centroid": func(o *sourceOptions) Aggregation {
+		a := NewGeoCentroidAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		return a
+	},
+	"matrix_stats": func(o *sourceOptions) Aggregation {
+		a := NewMatrixStatsAggregation()
+		o.strings("fields", func(v ...string) { a.Fields(v...) })
+		o.value("missing", func(v interface{}) { a.Missing(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		o.value("value_type", func(v interface{}) { a.ValueType(v) })
+		o.str("mode", func(v string) { a.Mode(v) })
+		return a
+	},
+	"max": func(o *sourceOptions) Aggregation {
+		a := NewMaxAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		return a
+	},
+	"median_absolute_deviation": func(o *sourceOptions) Aggregation {
+		a := NewMedianAbsoluteDeviationAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.value("missing", func(v interface{}) { a.Missing(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		o.float("compression", func(v float64) { a.Compression(v) })
+		return a
+	},
+	"min": func(o *sourceOptions) Aggregation {
+		a := NewMinAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		return a
+	},
+	"percentile_ranks": func(o *sourceOptions) Aggregation {
+		a := NewPercentileRanksAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		o.floats("values", func(v ...float64) { a.Values(v...) })
+		o.float("compression", func(v float64) { a.Compression(v) })
+		o.str("estimator", func(v string) { a.Estimator(v) })
+		return a
+	},
+	"percentiles": func(o *sourceOptions) Aggregation {
+		a := NewPercentilesAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		o.floats("percents", func(v ...float64) { a.Percentiles(v...) })
+		o.float("compression", func(v float64) { a.Compression(v) })
+		o.str("estimator", func(v string) { a.Estimator(v) })
+		return a
+	},
+	"rate": func(o *sourceOptions) Aggregation {
+		a := NewRateAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.str("unit", func(v string) { a.Unit(v) })
+		o.str("mode", func(v string) { a.Mode(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		return a
+	},
+	"scripted_metric": func(o *sourceOptions) Aggregation {
+		a := NewScriptedMetricAggregation()
+		o.script("init_script", func(v *elastic.Script) { a.InitScript(v) })
+		o.script("map_script", func(v *elastic.Script) { a.MapScript(v) })
+		o.script("combine_script", func(v *elastic.Script) { a.CombineScript(v) })
+		o.script("reduce_script", func(v *elastic.Script) { a.ReduceScript(v) })
+		o.object("params", func(v map[string]interface{}) { a.Params(v) })
+		return a
+	},
+	"stats": func(o *sourceOptions) Aggregation {
+		a := NewStatsAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.value("missing", func(v interface{}) { a.Missing(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		return a
+	},
+	"string_stats": func(o *sourceOptions) Aggregation {
+		a := NewStringStatsAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.value("missing", func(v interface{}) { a.Missing(v) })
+		o.bool("show_distribution", func(v bool) { a.ShowDistribution(v) })
+		return a
+	},
+	"sum": func(o *sourceOptions) Aggregation {
+		a := NewSumAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		return a
+	},
+	"t_test": func(o *sourceOptions) Aggregation {
+		a := NewTTestAggregation()
+		o.nested("a", func(p *sourceOptions) { a.A(parsePopulation(p)) })
+		o.nested("b", func(p *sourceOptions) { a.B(parsePopulation(p)) })
+		o.str("type", func(v string) { a.Type(v) })
+		return a
+	},
+	"top_hits": func(o *sourceOptions) Aggregation {
+		a := NewTopHitsAggregation()
+		o.int("from", func(v int) { a.From(v) })
+		o.int("size", func(v int) { a.Size(v) })
+		o.bool("track_scores", func(v bool) { a.TrackScores(v) })
+		o.bool("explain", func(v bool) { a.Explain(v) })
+		o.bool("version", func(v bool) { a.Version(v) })
+		if value, ok := o.opts["_source"].(bool); ok {
+			delete(o.opts, "_source")
+			a.FetchSource(value)
+		}
+		o.nested("_source", func(s *sourceOptions) {
+			var includes, excludes []string
+			s.strings("includes", func(v ...string) { includes = v })
+			s.strings("excludes", func(v ...string) { excludes = v })
+			a.FetchSourceIncludeExclude(includes, excludes)
+		})
+		if value, ok := o.opts["stored_fields"].(string); ok && value == "_none_" {
+			delete(o.opts, "stored_fields")
+			a.NoStoredFields()
+		}
+		o.strings("stored_fields", func(v ...string) { a.StoredFields(v...) })
+		o.strings("docvalue_fields", func(v ...string) { a.DocValueFields(v...) })
+		o.fields("script_fields", func(name string, f *sourceOptions) {
+			f.script("script", func(v *elastic.Script) { a.ScriptField(name, v) })
+		})
+		o.each("sort", func(s *sourceOptions) { a.SortWithInfo(parseSortInfo(s)) })
+		return a
+	},
+	"top_metrics": func(o *sourceOptions) Aggregation {
+		a := NewTopMetricsAggregation()
+		o.each("metrics", func(m *sourceOptions) {
+			m.str("field", func(v string) { a.Metrics(v) })
+		})
+		o.each("sort", func(s *sourceOptions) { a.SortWithInfo(parseSortInfo(s)) })
+		o.int("size", func(v int) { a.Size(v) })
+		return a
+	},
+	"value_count": func(o *sourceOptions) Aggregation {
+		a := NewValueCountAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.value("missing", func(v interface{}) { a.Missing(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		return a
+	},
+
+	// buckets
+	"adjacency_matrix": func(o *sourceOptions) Aggregation {
+		a := NewAdjacencyMatrixAggregation()
+		o.fields("filters", func(name string, q *sourceOptions) { a.Filters(name, q.raw()) })
+		o.str("separator", func(v string) { a.Separator(v) })
+		return a
+	},
+	"auto_date_histogram": func(o *sourceOptions) Aggregation {
+		a := NewAutoDateHistogramAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.value("missing", func(v interface{}) { a.Missing(v) })
+		o.int("buckets", func(v int) { a.Buckets(v) })
+		o.str("minimum_interval", func(v string) { a.MinimumInterval(v) })
+		o.str("time_zone", func(v string) { a.TimeZone(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		return a
+	},
+	"children": func(o *sourceOptions) Aggregation {
+		a := NewChildrenAggregation()
+		o.str("type", func(v string) { a.Type(v) })
+		return a
+	},
+	"composite": func(o *sourceOptions) Aggregation {
+		a := NewCompositeAggregation()
+		o.each("sources", func(s *sourceOptions) { a.Sources(s.raw()) })
+		o.int("size", func(v int) { a.Size(v) })
+		o.object("after", func(v map[string]interface{}) { a.AggregateAfter(v) })
+		return a
+	},
+	"date_histogram": func(o *sourceOptions) Aggregation {
+		a := NewDateHistogramAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.value("missing", func(v interface{}) { a.Missing(v) })
+		o.str("interval", func(v string) { a.Interval(v) })
+		o.int64("min_doc_count", func(v int64) { a.MinDocCount(v) })
+		o.nested("order", func(s *sourceOptions) { a.Order(parseOrder(s)) })
+		o.str("time_zone", func(v string) { a.TimeZone(v) })
+		o.str("offset", func(v string) { a.Offset(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		o.nested("extended_bounds", func(s *sourceOptions) {
+			min, max := parseBounds(s)
+			a.ExtendedBoundsOf(&ExtendedBounds{Min: min, Max: max})
+		})
+		o.nested("hard_bounds", func(s *sourceOptions) {
+			min, max := parseBounds(s)
+			a.HardBoundsOf(&HardBounds{Min: min, Max: max})
+		})
+		return a
+	},
+	"date_range": func(o *sourceOptions) Aggregation {
+		a := NewDateRangeAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.bool("keyed", func(v bool) { a.Keyed(v) })
+		o.bool("unmapped", func(v bool) { a.Unmapped(v) })
+		o.str("time_zone", func(v string) { a.TimeZone(v) })
+		o.str("format", func(v string) { a.Format(v) })
+		o.each("ranges", func(r *sourceOptions) { a.AddRangeWithKey(parseRange(r)) })
+		return a
+	},
+	"diversified_sampler": func(o *sourceOptions) Aggregation {
+		a := NewDiversifiedSamplerAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.int("shard_size", func(v int) { a.ShardSize(v) })
+		o.int("max_docs_per_value", func(v int) { a.MaxDocsPerValue(v) })
+		o.str("execution_hint", func(v string) { a.ExecutionHint(v) })
+		return a
+	},
+	"filters": func(o *sourceOptions) Aggregation {
+		a := NewFiltersAggregation()
+		if _, ok := o.opts["filters"].([]interface{}); ok {
+			o.each("filters", func(q *sourceOptions) { a.Filter(q.raw()) })
+		} else {
+			o.fields("filters", func(name string, q *sourceOptions) { a.AddNamedFilter(name, q.raw()) })
+		}
+		return a
+	},
+	"geo_distance": func(o *sourceOptions) Aggregation {
+		a := NewGeoDistanceAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.str("unit", func(v string) { a.Unit(v) })
+		o.str("distance_type", func(v string) { a.DistanceType(v) })
+		o.str("origin", func(v string) { a.Point(v) })
+		o.each("ranges", func(r *sourceOptions) { a.AddRangeWithKey(parseRange(r)) })
+		o.bool("keyed", func(v bool) { a.Keyed(v) })
+		return a
+	},
+	"geohash_grid": func(o *sourceOptions) Aggregation {
+		a := NewGeoHashGridAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.value("precision", func(v interface{}) { a.Precision(v) })
+		o.int("size", func(v int) { a.Size(v) })
+		o.int("shard_size", func(v int) { a.ShardSize(v) })
+		return a
+	},
+	"global": func(o *sourceOptions) Aggregation {
+		return NewGlobalAggregation()
+	},
+	"histogram": func(o *sourceOptions) Aggregation {
+		a := NewHistogramAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.value("missing", func(v interface{}) { a.Missing(v) })
+		o.float("interval", func(v float64) { a.Interval(v) })
+		if _, ok := o.opts["order"].([]interface{}); ok {
+			o.each("order", func(s *sourceOptions) { a.Order(parseOrder(s)) })
+		} else {
+			o.nested("order", func(s *sourceOptions) { a.Order(parseOrder(s)) })
+		}
+		o.float("offset", func(v float64) { a.Offset(v) })
+		o.int64("min_doc_count", func(v int64) { a.MinDocCount(v) })
+		o.nested("extended_bounds", func(s *sourceOptions) {
+			min, max := parseBounds(s)
+			a.ExtendedBoundsOf(&ExtendedBounds{Min: min, Max: max})
+		})
+		o.nested("hard_bounds", func(s *sourceOptions) {
+			min, max := parseBounds(s)
+			a.HardBoundsOf(&HardBounds{Min: min, Max: max})
+		})
+		o.bool("keyed", func(v bool) { a.Keyed(v) })
+		return a
+	},
+	"ip_range": func(o *sourceOptions) Aggregation {
+		a := NewIPRangeAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.bool("keyed", func(v bool) { a.Keyed(v) })
+		o.each("ranges", func(r *sourceOptions) {
+			var key, mask, from, to string
+			r.str("key", func(v string) { key = v })
+			r.str("mask", func(v string) { mask = v })
+			r.str("from", func(v string) { from = v })
+			r.str("to", func(v string) { to = v })
+			if mask != "" {
+				a.AddMaskRangeWithKey(key, mask)
+			} else {
+				a.AddRangeWithKey(key, from, to)
+			}
+		})
+		return a
+	},
+	"missing": func(o *sourceOptions) Aggregation {
+		a := NewMissingAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		return a
+	},
+	"multi_terms": func(o *sourceOptions) Aggregation {
+		a := NewMultiTermsAggregation()
+		o.each("terms", func(t *sourceOptions) {
+			var field MultiTermsField
+			t.str("field", func(v string) { field.Field = v })
+			t.value("missing", func(v interface{}) { field.Missing = v })
+			a.Terms(field)
+		})
+		o.int("size", func(v int) { a.Size(v) })
+		o.int("shard_size", func(v int) { a.ShardSize(v) })
+		o.int("min_doc_count", func(v int) { a.MinDocCount(v) })
+		o.each("order", func(s *sourceOptions) { a.Order(parseOrder(s)) })
+		return a
+	},
+	"nested": func(o *sourceOptions) Aggregation {
+		a := NewNestedAggregation()
+		o.str("path", func(v string) { a.Path(v) })
+		return a
+	},
+	"range": func(o *sourceOptions) Aggregation {
+		a := NewRangeAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.value("missing", func(v interface{}) { a.Missing(v) })
+		o.bool("keyed", func(v bool) { a.Keyed(v) })
+		o.bool("unmapped", func(v bool) { a.Unmapped(v) })
+		o.each("ranges", func(r *sourceOptions) { a.AddRangeWithKey(parseRange(r)) })
+		return a
+	},
+	"rare_terms": func(o *sourceOptions) Aggregation {
+		a := NewRareTermsAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.value("missing", func(v interface{}) { a.Missing(v) })
+		o.int64("max_doc_count", func(v int64) { a.MaxDocCount(v) })
+		o.float("precision", func(v float64) { a.Precision(v) })
+		o.str("include", func(v string) { a.Include(v) })
+		o.values("include", func(v ...interface{}) { a.IncludeValues(v...) })
+		o.str("exclude", func(v string) { a.Exclude(v) })
+		o.values("exclude", func(v ...interface{}) { a.ExcludeValues(v...) })
+		return a
+	},
+	"reverse_nested": func(o *sourceOptions) Aggregation {
+		a := NewReverseNestedAggregation()
+		o.str("path", func(v string) { a.Path(v) })
+		return a
+	},
+	"sampler": func(o *sourceOptions) Aggregation {
+		a := NewSamplerAggregation()
+		o.int("shard_size", func(v int) { a.ShardSize(v) })
+		o.int("max_docs_per_value", func(v int) { a.MaxDocsPerValue(v) })
+		o.str("execution_hint", func(v string) { a.ExecutionHint(v) })
+		return a
+	},
+	"significant_terms": func(o *sourceOptions) Aggregation {
+		a := NewSignificantTermsAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		// not a typo, see SignificantTermsAggregation.Source
+		o.int("size", func(v int) { a.RequiredSize(v) })
+		o.int("shard_size", func(v int) { a.ShardSize(v) })
+		o.int("min_doc_count", func(v int) { a.MinDocCount(v) })
+		o.int("shard_min_doc_count", func(v int) { a.ShardMinDocCount(v) })
+		o.str("execution_hint", func(v string) { a.ExecutionHint(v) })
+		o.object("background_filter", func(v map[string]interface{}) { a.BackgroundFilterRaw(v) })
+		o.heuristic(func(v SignificanceHeuristic) { a.SignificanceHeuristic(v) })
+		return a
+	},
+	"significant_text": func(o *sourceOptions) Aggregation {
+		a := NewSignificantTextAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.strings("source_fields", func(v ...string) { a.SourceFieldNames(v...) })
+		o.bool("filter_duplicate_text", func(v bool) { a.FilterDuplicateText(v) })
+		o.int("size", func(v int) { a.Size(v) })
+		o.int("shard_size", func(v int) { a.ShardSize(v) })
+		o.int64("min_doc_count", func(v int64) { a.MinDocCount(v) })
+		o.int64("shard_min_doc_count", func(v int64) { a.ShardMinDocCount(v) })
+		o.object("background_filter", func(v map[string]interface{}) { a.BackgroundFilterRaw(v) })
+		o.heuristic(func(v SignificanceHeuristic) { a.SignificanceHeuristic(v) })
+		o.str("include", func(v string) { a.Include(v) })
+		o.values("include", func(v ...interface{}) { a.IncludeValues(v...) })
+		o.nested("include", func(p *sourceOptions) {
+			p.int("partition", func(v int) { a.Partition(v) })
+			p.int("num_partitions", func(v int) { a.NumPartitions(v) })
+		})
+		o.str("exclude", func(v string) { a.Exclude(v) })
+		o.values("exclude", func(v ...interface{}) { a.ExcludeValues(v...) })
+		return a
+	},
+	"terms": func(o *sourceOptions) Aggregation {
+		a := NewTermsAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.value("missing", func(v interface{}) { a.Missing(v) })
+		o.int("size", func(v int) { a.Size(v) })
+		o.int("shard_size", func(v int) { a.ShardSize(v) })
+		o.int("required_size", func(v int) { a.RequiredSize(v) })
+		o.int("min_doc_count", func(v int) { a.MinDocCount(v) })
+		o.int("shard_min_doc_count", func(v int) { a.ShardMinDocCount(v) })
+		o.bool("show_term_doc_count_error", func(v bool) { a.ShowTermDocCountError(v) })
+		o.str("collect_mode", func(v string) { a.CollectionMode(v) })
+		o.str("value_type", func(v string) { a.ValueType(v) })
+		o.each("order", func(s *sourceOptions) { a.Order(parseOrder(s)) })
+		o.str("include", func(v string) { a.Include(v) })
+		o.values("include", func(v ...interface{}) { a.IncludeValues(v...) })
+		o.nested("include", func(p *sourceOptions) {
+			var partition, numPartitions int
+			p.int("partition", func(v int) { partition = v })
+			p.int("num_partitions", func(v int) { numPartitions = v })
+			a.IncludePartition(partition, numPartitions)
+		})
+		o.str("exclude", func(v string) { a.Exclude(v) })
+		o.values("exclude", func(v ...interface{}) { a.ExcludeValues(v...) })
+		o.str("execution_hint", func(v string) { a.ExecutionHint(v) })
+		return a
+	},
+	"variable_width_histogram": func(o *sourceOptions) Aggregation {
+		a := NewVariableWidthHistogramAggregation()
+		o.str("field", func(v string) { a.Field(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.int("buckets", func(v int) { a.Buckets(v) })
+		return a
+	},
+
+	// pipelines
+	"avg_bucket": func(o *sourceOptions) Aggregation {
+		a := NewAvgBucketAggregation()
+		o.str("format", func(v string) { a.Format(v) })
+		o.str("gap_policy", func(v string) { a.GapPolicy(v) })
+		o.bucketsPath(func(v ...string) { a.BucketsPath(v...) })
+		return a
+	},
+	"bucket_script": func(o *sourceOptions) Aggregation {
+		a := NewBucketScriptAggregation()
+		o.str("format", func(v string) { a.Format(v) })
+		o.str("gap_policy", func(v string) { a.GapPolicy(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.stringMap("buckets_path", func(v map[string]string) { a.BucketsPathsMap(v) })
+		return a
+	},
+	"bucket_selector": func(o *sourceOptions) Aggregation {
+		a := NewBucketSelectorAggregation()
+		o.str("format", func(v string) { a.Format(v) })
+		o.str("gap_policy", func(v string) { a.GapPolicy(v) })
+		o.script("script", func(v *elastic.Script) { a.Script(v) })
+		o.stringMap("buckets_path", func(v map[string]string) { a.BucketsPathsMap(v) })
+		return a
+	},
+	"bucket_sort": func(o *sourceOptions) Aggregation {
+		a := NewBucketSortAggregation()
+		o.int("from", func(v int) { a.From(v) })
+		o.int("size", func(v int) { a.Size(v) })
+		o.str("gap_policy", func(v string) { a.GapPolicy(v) })
+		o.each("sort", func(s *sourceOptions) { a.SortWithInfo(parseSortInfo(s)) })
+		return a
+	},
+	"cumulative_cardinality": func(o *sourceOptions) Aggregation {
+		a := NewCumulativeCardinalityAggregation()
+		o.str("format", func(v string) { a.Format(v) })
+		o.bucketsPath(func(v ...string) { a.BucketsPath(v...) })
+		return a
+	},
+	"cumulative_sum": func(o *sourceOptions) Aggregation {
+		a := NewCumulativeSumAggregation()
+		o.str("format", func(v string) { a.Format(v) })
+		o.bucketsPath(func(v ...string) { a.BucketsPath(v...) })
+		return a
+	},
+	"derivative": func(o *sourceOptions) Aggregation {
+		a := NewDerivativeAggregation()
+		o.str("format", func(v string) { a.Format(v) })
+		o.str("gap_policy", func(v string) { a.GapPolicy(v) })
+		o.str("unit", func(v string) { a.Unit(v) })
+		o.bucketsPath(func(v ...string) { a.BucketsPath(v...) })
+		return a
+	},
+	"inference": func(o *sourceOptions) Aggregation {
+		a := NewInferenceBucketAggregation()
+		o.str("model_id", func(v string) { a.ModelID(v) })
+		o.object("inference_config", func(v map[string]interface{}) { a.InferenceConfig(v) })
+		o.stringMap("buckets_path", func(v map[string]string) { a.BucketsPathsMap(v) })
+		return a
+	},
+	"max_bucket": func(o *sourceOptions) Aggregation {
+		a := NewMaxBucketAggregation()
+		o.str("format", func(v string) { a.Format(v) })
+		o.str("gap_policy", func(v string) { a.GapPolicy(v) })
+		o.bucketsPath(func(v ...string) { a.BucketsPath(v...) })
+		return a
+	},
+	"min_bucket": func(o *sourceOptions) Aggregation {
+		a := NewMinBucketAggregation()
+		o.str("format", func(v string) { a.Format(v) })
+		o.str("gap_policy", func(v string) { a.GapPolicy(v) })
+		o.bucketsPath(func(v ...string) { a.BucketsPath(v...) })
+		return a
+	},
+	"moving_avg": func(o *sourceOptions) Aggregation {
+		a := NewMovAvgAggregation()
+		o.str("format", func(v string) { a.Format(v) })
+		o.str("gap_policy", func(v string) { a.GapPolicy(v) })
+		o.str("model", func(v string) {
+			model := rawMovAvgModel{name: v}
+			o.object("settings", func(s map[string]interface{}) { model.settings = s })
+			a.Model(model)
+		})
+		o.int("window", func(v int) { a.Window(v) })
+		o.int("predict", func(v int) { a.Predict(v) })
+		o.bool("minimize", func(v bool) { a.Minimize(v) })
+		o.bucketsPath(func(v ...string) { a.BucketsPath(v...) })
+		return a
+	},
+	"moving_percentiles": func(o *sourceOptions) Aggregation {
+		a := NewMovingPercentilesAggregation()
+		o.int("window", func(v int) { a.Window(v) })
+		o.int("shift", func(v int) { a.Shift(v) })
+		o.bucketsPath(func(v ...string) { a.BucketsPath(v...) })
+		return a
+	},
+	"normalize": func(o *sourceOptions) Aggregation {
+		a := NewNormalizeAggregation()
+		o.str("format", func(v string) { a.Format(v) })
+		o.str("method", func(v string) { a.Method(v) })
+		o.bucketsPath(func(v ...string) { a.BucketsPath(v...) })
+		return a
+	},
+	"percentiles_bucket": func(o *sourceOptions) Aggregation {
+		a := NewPercentilesBucketAggregation()
+		o.str("format", func(v string) { a.Format(v) })
+		o.str("gap_policy", func(v string) { a.GapPolicy(v) })
+		o.floats("percents", func(v ...float64) { a.Percents(v...) })
+		o.bucketsPath(func(v ...string) { a.BucketsPath(v...) })
+		return a
+	},
+	"serial_diff": func(o *sourceOptions) Aggregation {
+		a := NewSerialDiffAggregation()
+		o.str("format", func(v string) { a.Format(v) })
+		o.str("gap_policy", func(v string) { a.GapPolicy(v) })
+		o.int("lag", func(v int) { a.Lag(v) })
+		o.bucketsPath(func(v ...string) { a.BucketsPath(v...) })
+		return a
+	},
+	"stats_bucket": func(o *sourceOptions) Aggregation {
+		a := NewStatsBucketAggregation()
+		o.str("format", func(v string) { a.Format(v) })
+		o.str("gap_policy", func(v string) { a.GapPolicy(v) })
+		o.bucketsPath(func(v ...string) { a.BucketsPath(v...) })
+		return a
+	},
+	"sum_bucket": func(o *sourceOptions) Aggregation {
+		a := NewSumBucketAggregation()
+		o.str("format", func(v string) { a.Format(v) })
+		o.str("gap_policy", func(v string) { a.GapPolicy(v) })
+		o.bucketsPath(func(v ...string) { a.BucketsPath(v...) })
+		return a
+	},
+}
+
+// significanceHeuristics are the option keys of the significance heuristics
+var significanceHeuristics = []string{"chi_square", "gnd", "jlh", "mutual_information", "percentage", "script_heuristic"}
+
+// parsePopulation parses a population of the t_test aggregation
+func parsePopulation(p *sourceOptions) (string, elastic.Query) {
+	var field string
+	var filter elastic.Query
+	p.str("field", func(v string) { field = v })
+	p.nested("filter", func(q *sourceOptions) { filter = q.raw() })
+
+	return field, filter
+}
+
+// parseOrder parses the bucket order like {"_count": "desc"}
+func parseOrder(s *sourceOptions) (string, bool) {
+	var field string
+	asc := false
+	if len(s.opts) != 1 {
+		s.fail("order")
+		return field, asc
+	}
+	for key := range s.opts {
+		field = key
+	}
+	s.str(field, func(v string) {
+		switch v {
+		case "asc":
+			asc = true
+		case "desc":
+		default:
+			s.fail(field)
+		}
+	})
+
+	return field, asc
+}
+
+// parseSortInfo parses the sort order like {"date": {"order": "desc"}}
+func parseSortInfo(s *sourceOptions) elastic.SortInfo {
+	var info elastic.SortInfo
+	if len(s.opts) != 1 {
+		s.fail("sort")
+		return info
+	}
+	for key := range s.opts {
+		info.Field = key
+	}
+	s.nested(info.Field, func(o *sourceOptions) {
+		field, asc := parseOrder(o)
+		if field != "order" {
+			o.fail("order")
+		}
+		info.Ascending = asc
+	})
+
+	return info
+}
+
+// parseRange parses a range with optional key and bounds
+func parseRange(r *sourceOptions) (string, interface{}, interface{}) {
+	var key string
+	var from, to interface{}
+	r.str("key", func(v string) { key = v })
+	r.value("from", func(v interface{}) { from = v })
+	r.value("to", func(v interface{}) { to = v })
+
+	return key, from, to
+}
+
+// parseBounds parses the extended or hard bounds of a histogram
+func parseBounds(s *sourceOptions) (interface{}, interface{}) {
+	var min, max interface{}
+	s.value("min", func(v interface{}) { min = v })
+	s.value("max", func(v interface{}) { max = v })
+
+	return min, max
+}
+
+// sourceOptions are the options of an aggregation (or a part of them) being parsed.
+// Every option is taken once, so the ones left unknown make the parsing fail.
+type sourceOptions struct {
+	opts map[string]interface{}
+	err  error
+}
+
+func newSourceOptions(opts map[string]interface{}) *sourceOptions {
+	o := &sourceOptions{opts: make(map[string]interface{}, len(opts))}
+	for key, value := range opts {
+		o.opts[key] = value
+	}
+
+	return o
+}
+
+// done returns the first error of the parsing, an option left unknown is an error as well
+func (o *sourceOptions) done() error {
+	if o.err != nil {
+		return o.err
+	}
+	for key := range o.opts {
+		return fmt.Errorf("unknown option %q", key)
+	}
+
+	return nil
+}
+
+// fail remembers the option which can't be parsed
+func (o *sourceOptions) fail(key string) {
+	if o.err == nil {
+		o.err = fmt.Errorf("option %q can't be parsed", key)
+	}
+}
+
+// take removes the option and returns its value if it's there and isn't null
+func (o *sourceOptions) take(key string) (interface{}, bool) {
+	value, ok := o.opts[key]
+	delete(o.opts, key)
+
+	return value, ok && value != nil
+}
+
+// takeIf takes the option only if accept returns true for its value,
+// so an option of several possible kinds is left for the other takers
+func (o *sourceOptions) takeIf(key string, accept func(value interface{}) bool) (interface{}, bool) {
+	value, ok := o.opts[key]
+	if !ok || value != nil && !accept(value) {
+		return nil, false
+	}
+
+	return o.take(key)
+}
+
+func (o *sourceOptions) str(key string, set func(string)) {
+	if value, ok := o.takeIf(key, isString); ok {
+		set(value.(string))
+	}
+}
+
+func (o *sourceOptions) int(key string, set func(int)) {
+	if value, ok := o.take(key); ok {
+		n, ok := value.(json.Number)
+		i, err := n.Int64()
+		if !ok || err != nil || int64(int(i)) != i {
+			o.fail(key)
+			return
+		}
+		set(int(i))
+	}
+}
+
+func (o *sourceOptions) int64(key string, set func(int64)) {
+	if value, ok := o.take(key); ok {
+		n, ok := value.(json.Number)
+		i, err := n.Int64()
+		if !ok || err != nil {
+			o.fail(key)
+			return
+		}
+		set(i)
+	}
+}
+
+func (o *sourceOptions) float(key string, set func(float64)) {
+	if value, ok := o.take(key); ok {
+		n, ok := value.(json.Number)
+		f, err := n.Float64()
+		if !ok || err != nil {
+			o.fail(key)
+			return
+		}
+		set(f)
+	}
+}
+
+func (o *sourceOptions) bool(key string, set func(bool)) {
+	if value, ok := o.take(key); ok {
+		b, ok := value.(bool)
+		if !ok {
+			o.fail(key)
+			return
+		}
+		set(b)
+	}
+}
+
+// value takes any value, with the numbers turned into int64 or float64
+func (o *sourceOptions) value(key string, set func(interface{})) {
+	if value, ok := o.take(key); ok {
+		set(plainValue(value))
+	}
+}
+
+// values takes an array of any values
+func (o *sourceOptions) values(key string, set func(...interface{})) {
+	if value, ok := o.takeIf(key, isArray); ok {
+		set(plainValue(value).([]interface{})...)
+	}
+}
+
+func (o *sourceOptions) strings(key string, set func(...string)) {
+	if value, ok := o.takeIf(key, isArray); ok {
+		items := value.([]interface{})
+		strs := make([]string, len(items))
+		for i, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				o.fail(key)
+				return
+			}
+			strs[i] = s
+		}
+		set(strs...)
+	}
+}
+
+func (o *sourceOptions) floats(key string, set func(...float64)) {
+	if value, ok := o.takeIf(key, isArray); ok {
+		items := value.([]interface{})
+		floats := make([]float64, len(items))
+		for i, item := range items {
+			n, ok := item.(json.Number)
+			f, err := n.Float64()
+			if !ok || err != nil {
+				o.fail(key)
+				return
+			}
+			floats[i] = f
+		}
+		set(floats...)
+	}
+}
+
+// object takes an object of any values
+func (o *sourceOptions) object(key string, set func(map[string]interface{})) {
+	if value, ok := o.takeIf(key, isObject); ok {
+		set(plainMap(value.(map[string]interface{})))
+	}
+}
+
+func (o *sourceOptions) stringMap(key string, set func(map[string]string)) {
+	if value, ok := o.takeIf(key, isObject); ok {
+		items := value.(map[string]interface{})
+		strs := make(map[string]string, len(items))
+		for name, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				o.fail(key)
+				return
+			}
+			strs[name] = s
+		}
+		set(strs)
+	}
+}
+
+// bucketsPath takes the buckets_path of a pipeline, either a single path or an array of them
+func (o *sourceOptions) bucketsPath(set func(...string)) {
+	o.str("buckets_path", func(v string) { set(v) })
+	o.strings("buckets_path", set)
+}
+
+// nested parses an object option with fn
+func (o *sourceOptions) nested(key string, fn func(s *sourceOptions)) {
+	if value, ok := o.takeIf(key, isObject); ok {
+		o.parseNested(key, value, fn)
+	}
+}
+
+// each parses every object of an array option with fn
+func (o *sourceOptions) each(key string, fn func(s *sourceOptions)) {
+	if value, ok := o.takeIf(key, isArray); ok {
+		for _, item := range value.([]interface{}) {
+			o.parseNested(key, item, fn)
+		}
+	}
+}
+
+// fields parses every object of an object option with fn, in the order of the names
+func (o *sourceOptions) fields(key string, fn func(name string, s *sourceOptions)) {
+	if value, ok := o.takeIf(key, isObject); ok {
+		items := value.(map[string]interface{})
+		for _, name := range sortedKeys(items) {
+			o.parseNested(key, items[name], func(s *sourceOptions) { fn(name, s) })
+		}
+	}
+}
+
+func (o *sourceOptions) parseNested(key string, value interface{}, fn func(s *sourceOptions)) {
+	opts, ok := value.(map[string]interface{})
+	if !ok {
+		o.fail(key)
+		return
+	}
+
+	s := newSourceOptions(opts)
+	fn(s)
+	if s.done() != nil {
+		o.fail(key)
+	}
+}
+
+// raw takes all the options as they are, e.g. to keep a query the package doesn't model
+func (o *sourceOptions) raw() rawSource {
+	raw := rawSource(plainMap(o.opts))
+	o.opts = map[string]interface{}{}
+
+	return raw
+}
+
+func (o *sourceOptions) script(key string, set func(*elastic.Script)) {
+	if value, ok := o.takeIf(key, isString); ok {
+		set(elastic.NewScript(value.(string)))
+		return
+	}
+
+	o.nested(key, func(s *sourceOptions) {
+		var script *elastic.Script
+		s.str("source", func(v string) { script = elastic.NewScriptInline(v) })
+		if script == nil {
+			s.str("id", func(v string) { script = elastic.NewScriptStored(v) })
+		}
+		if script == nil {
+			s.fail("source")
+			return
+		}
+		s.str("lang", func(v string) { script.Lang(v) })
+		s.object("params", func(v map[string]interface{}) { script.Params(v) })
+		set(script)
+	})
+}
+
+// heuristic takes the significance heuristic found by its name among the options
+func (o *sourceOptions) heuristic(set func(SignificanceHeuristic)) {
+	for _, name := range significanceHeuristics {
+		o.object(name, func(v map[string]interface{}) { set(rawHeuristic{name: name, source: v}) })
+	}
+}
+
+func isString(value interface{}) bool {
+	_, ok := value.(string)
+	return ok
+}
+
+func isArray(value interface{}) bool {
+	_, ok := value.([]interface{})
+	return ok
+}
+
+func isObject(value interface{}) bool {
+	_, ok := value.(map[string]interface{})
+	return ok
+}
+
+// plainValue turns the JSON numbers of the value into int64 where possible, float64 otherwise
+func plainValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return plainMap(value)
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, item := range value {
+			result[i] = plainValue(item)
+		}
+		return result
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n
+		}
+		if f, err := value.Float64(); err == nil {
+			return f
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+func plainMap(value map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(value))
+	for key, item := range value {
+		result[key] = plainValue(item)
+	}
+
+	return result
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// rawHeuristic is a significance heuristic restored from its source as is
+type rawHeuristic struct {
+	name   string
+	source map[string]interface{}
+}
+
+func (h rawHeuristic) Name() string {
+	return h.name
+}
+
+func (h rawHeuristic) Source() (interface{}, error) {
+	return h.source, nil
+}
+
+// rawMovAvgModel is a moving average model restored from its source as is
+type rawMovAvgModel struct {
+	name     string
+	settings map[string]interface{}
+}
+
+func (m rawMovAvgModel) Name() string {
+	return m.name
+}
+
+func (m rawMovAvgModel) Settings() map[string]interface{} {
+	return m.settings
+}
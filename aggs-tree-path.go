@@ -0,0 +1,64 @@
+package aggretastic
+
+import "strings"
+
+// Path is the path to a subAgg: the names of the aggregations from the top one down to the subAgg.
+// It can be used instead of the variadic string paths of Select, Inject and Pop.
+type Path []string
+
+// NewPath makes the path out of the aggregation names
+func NewPath(names ...string) Path {
+	return append(Path{}, names...)
+}
+
+// ParsePath parses the path written in Elasticsearch buckets_path syntax,
+// e.g. "sales_per_month>sales". The names are kept as they are, dots included,
+// use ParseBucketsPath for the paths ending with the metric of a multi-value aggregation.
+func ParsePath(s string) Path {
+	if s == "" {
+		return Path{}
+	}
+
+	return strings.Split(s, PathSeparator)
+}
+
+// ParseBucketsPath parses the buckets_path pointing to a metric, e.g. "sales_per_month>stats.avg".
+// The metric (".avg") isn't a part of the path, so it's dropped: the last segment
+// is cut at its last ".", which lets the aggregation names contain dots.
+func ParseBucketsPath(s string) Path {
+	names := ParsePath(s)
+	if last := len(names) - 1; last >= 0 {
+		if i := strings.LastIndex(names[last], "."); i >= 0 {
+			names[last] = names[last][:i]
+		}
+	}
+
+	return names
+}
+
+// String returns the path in Elasticsearch buckets_path syntax
+func (p Path) String() string {
+	return strings.Join(p, PathSeparator)
+}
+
+// pathTarget is either an Aggregation or Aggregations
+type pathTarget interface {
+	Select(path ...string) Aggregation
+	Inject(subAgg Aggregation, path ...string) error
+	Pop(path ...string) Aggregation
+}
+
+// SelectFrom does target.Select() with the path
+func (p Path) SelectFrom(target pathTarget) Aggregation {
+	return target.Select(p...)
+}
+
+// InjectInto does target.Inject() of subAgg with the path
+func (p Path) InjectInto(target pathTarget, subAgg Aggregation) error {
+	return target.Inject(subAgg, p...)
+}
+
+// PopFrom does target.Pop() with the path
+func (p Path) PopFrom(target pathTarget) Aggregation {
+	return target.Pop(p...)
+}
@@ -0,0 +1,71 @@
+package aggretastic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want Path
+	}{
+		{"", Path{}},
+		{"sales", Path{"sales"}},
+		{"sales_per_month>sales", Path{"sales_per_month", "sales"}},
+		{"sales_per_month>stats.avg", Path{"sales_per_month", "stats.avg"}},
+		{"per.month>sales.total", Path{"per.month", "sales.total"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if got := ParsePath(test.path); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+
+	if got := NewPath("sales_per_month", "sales").String(); got != "sales_per_month>sales" {
+		t.Fatalf("expected %q, got %q", "sales_per_month>sales", got)
+	}
+}
+
+func TestParseBucketsPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want Path
+	}{
+		{"", Path{}},
+		{"sales", Path{"sales"}},
+		{"sales_per_month>stats.avg", Path{"sales_per_month", "stats"}},
+		{"per.month>sales.total.value", Path{"per.month", "sales.total"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if got := ParseBucketsPath(test.path); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestPathTargets(t *testing.T) {
+	root := NewTermsAggregation().Field("user").SubAggregation("per_month", NewDateHistogramAggregation().Field("date").Interval("month"))
+	sales := NewSumAggregation().Field("price")
+
+	if err := ParsePath("per_month>sales").InjectInto(root, sales); err != nil {
+		t.Fatal(err)
+	}
+	if ParseBucketsPath("per_month>sales.value").SelectFrom(root) != sales {
+		t.Fatal("expected the injected aggregation selected")
+	}
+
+	aggs := &Aggregations{"users": root}
+	if NewPath("users", "per_month", "sales").PopFrom(aggs) != sales {
+		t.Fatal("expected the injected aggregation popped")
+	}
+	if root.Select("per_month", "sales") != nil {
+		t.Fatal("expected the aggregation removed")
+	}
+}
@@ -0,0 +1,38 @@
+package aggretastic
+
+// Prune removes the empty bucket subAggregations (the ones without any subAggs left) bottom-up,
+// so a bucket holding nothing but empty buckets is removed as well.
+// Metrics, pipelines and the aggregation itself are never removed.
+// The subAggs deeper than MaxAggregationDepth are left as they are.
+// It returns the number of removed subAggs.
+func (a *tree) Prune() int {
+	return pruneSubs(a, a.subAggregations, 1)
+}
+
+// pruneSubs prunes subs found at the depth, marking owner (the aggregation holding them) dirty
+// if any of them is removed. owner may be nil for the aggregations not of this package.
+func pruneSubs(owner dirtyMarker, subs map[string]Aggregation, depth int) int {
+	pruned, removed := 0, false
+	for name, subAgg := range subs {
+		if isNilAgg(subAgg) {
+			continue
+		}
+
+		if depth < MaxAggregationDepth {
+			subOwner, _ := subAgg.(dirtyMarker)
+			pruned += pruneSubs(subOwner, subAgg.GetAllSubs(), depth+1)
+		}
+
+		if IsBucketAggregation(subAgg) && len(subAgg.GetAllSubs()) == 0 {
+			delete(subs, name)
+			pruned++
+			removed = true
+		}
+	}
+
+	if removed && owner != nil {
+		owner.markDirty()
+	}
+
+	return pruned
+}
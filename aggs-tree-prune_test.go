@@ -0,0 +1,51 @@
+package aggretastic
+
+import "testing"
+
+func TestPrune(t *testing.T) {
+	a := NewTermsAggregation().Field("a")
+	a.SubAggregation("e", NewTermsAggregation().Field("e").SubAggregation("e2", NewHistogramAggregation().Field("e2")))
+	a.SubAggregation("k", NewTermsAggregation().Field("k").
+		SubAggregation("m", NewMaxAggregation().Field("m")).
+		SubAggregation("e3", NewFilterAggregation()))
+	a.SubAggregation("d", NewDerivativeAggregation().BucketsPath("k"))
+	a.GetAllSubs()["nil"] = nil
+
+	if n := a.Prune(); n != 3 {
+		t.Fatalf("expected 3 subAggs pruned, got %d", n)
+	}
+	if a.Select("k", "m") == nil || a.Select("d") == nil || a.Select("e") != nil || a.Select("k", "e3") != nil {
+		t.Fatalf("expected the empty buckets pruned only, got %v", a.ListPaths())
+	}
+}
+
+func TestPruneMarksModifiedNodesDirty(t *testing.T) {
+	a := NewTermsAggregation().Field("a")
+	k := NewTermsAggregation().Field("k").
+		SubAggregation("m", NewMaxAggregation().Field("m")).
+		SubAggregation("empty", NewTermsAggregation().Field("empty"))
+	a.SubAggregation("k", k)
+	m := a.Select("k", "m").(*MaxAggregation)
+
+	versionA, versionK, versionM := a.sourceVersion(), k.sourceVersion(), m.sourceVersion()
+	if n := a.Prune(); n != 1 {
+		t.Fatalf("expected 1 subAgg pruned, got %d", n)
+	}
+	if k.sourceVersion() == versionK {
+		t.Fatal("expected the parent of the pruned subAgg to be marked dirty")
+	}
+	if a.sourceVersion() != versionA || m.sourceVersion() != versionM {
+		t.Fatal("expected the aggregations not changed to stay clean")
+	}
+}
+
+func TestPruneIsBoundedByMaxDepth(t *testing.T) {
+	a := NewTermsAggregation().Field("a")
+	b := NewTermsAggregation().Field("b")
+	a.SubAggregation("b", b)
+	b.GetAllSubs()["a"] = a
+
+	if n := a.Prune(); n != 0 {
+		t.Fatalf("expected nothing pruned, got %d", n)
+	}
+}
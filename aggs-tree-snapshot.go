@@ -0,0 +1,157 @@
+package aggretastic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Snapshot serializes the aggregation with all its subAggregations into JSON,
+// which can be kept (on disk, in a cache) and turned back into a tree with RestoreSnapshot.
+// The source is keyed by the name of the aggregation, so the name is restored too.
+func (a *tree) Snapshot() ([]byte, error) {
+	src, err := a.root.Source()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]interface{}{a.name: src})
+}
+
+// RestoreSnapshot rebuilds the tree serialized by Snapshot.
+// The restored tree renders exactly the same source as the original one and all its
+// subAggregations can be selected, popped and injected as usual. Every aggregation of a type
+// modeled by this package is restored as that type (e.g. *TermsAggregation with its setters).
+// The ones which are not (or have options the setters can't reproduce) are restored
+// as a Wrap of their raw source.
+func RestoreSnapshot(data []byte) (Aggregation, error) {
+	var snapshot map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&snapshot); err != nil {
+		return nil, err
+	}
+	if len(snapshot) != 1 {
+		return nil, fmt.Errorf("snapshot must have exactly one aggregation, got %d", len(snapshot))
+	}
+
+	for name, value := range snapshot {
+		src, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("aggregation %q has malformed source", name)
+		}
+		agg, err := restoreSnapshot(name, src)
+		if err != nil {
+			return nil, err
+		}
+		nameAgg(agg, name)
+		return agg, nil
+	}
+
+	return nil, nil
+}
+
+func restoreSnapshot(name string, src map[string]interface{}) (Aggregation, error) {
+	own := make(rawSource, len(src))
+	subs := make(map[string]interface{})
+	for key, value := range src {
+		switch key {
+		case "aggregations", "aggs":
+			aggsMap, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("aggregation %q has malformed subAggregations", name)
+			}
+			for subName, subSrc := range aggsMap {
+				subs[subName] = subSrc
+			}
+		default:
+			own[key] = value
+		}
+	}
+
+	agg, ok := restoreAggregation(own)
+	if !ok || len(subs) > 0 && IsNotInjectable(agg) {
+		agg = Wrap(name, own)
+	}
+	for _, subName := range sortedKeys(subs) {
+		subMap, ok := subs[subName].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("aggregation %q has malformed source", subName)
+		}
+		sub, err := restoreSnapshot(subName, subMap)
+		if err != nil {
+			return nil, err
+		}
+		if err := agg.Inject(sub, subName); err != nil {
+			return nil, err
+		}
+	}
+
+	return agg, nil
+}
+
+// restoreAggregation makes the concrete aggregation out of its own source (without subAggregations).
+// It returns false if the type is unknown or the aggregation doesn't render the same source.
+func restoreAggregation(own rawSource) (Aggregation, bool) {
+	typ := ""
+	for key := range own {
+		if key == "meta" {
+			continue
+		}
+		if typ != "" {
+			return nil, false
+		}
+		typ = key
+	}
+
+	agg, ok := parseAggregation(typ, own[typ])
+	if !ok {
+		return nil, false
+	}
+	if meta, ok := own["meta"]; ok {
+		metaMap, isMap := meta.(map[string]interface{})
+		m, isRestorer := agg.(metaRestorer)
+		if !isMap || !isRestorer {
+			return nil, false
+		}
+		m.setMeta(plainMap(metaMap))
+	}
+
+	// the parsed options are checked against the source once rendered back,
+	// so nothing is lost or changed on the way (e.g. an option set by two keys)
+	src, err := agg.Source()
+	if err != nil {
+		return nil, false
+	}
+	data, err := json.Marshal(src)
+	if err != nil {
+		return nil, false
+	}
+	var rendered map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&rendered); err != nil {
+		return nil, false
+	}
+
+	return agg, reflect.DeepEqual(rendered, map[string]interface{}(own))
+}
+
+// metaRestorer is implemented by the aggregations embedding metaHolder
+type metaRestorer interface {
+	setMeta(meta map[string]interface{})
+}
+
+// rawSource is an aggregation (or a part of it, like a query) restored from its source as is
+type rawSource map[string]interface{}
+
+func (r rawSource) Source() (interface{}, error) {
+	// the caller may add subAggregations to the source, so it gets a copy
+	source := make(map[string]interface{}, len(r))
+	for key, value := range r {
+		source[key] = value
+	}
+
+	return source, nil
+}
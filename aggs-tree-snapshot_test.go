@@ -0,0 +1,176 @@
+package aggretastic
+
+import (
+	"github.com/olivere/elastic"
+	"reflect"
+	"testing"
+)
+
+// snapshotCases are the aggregations of every modeled type with most of their options set
+func snapshotCases() map[string]Aggregation {
+	script := elastic.NewScriptInline("doc['x'].value * params.k").Lang("painless").Param("k", 2)
+	query := elastic.NewTermQuery("user", "kimchy")
+
+	return map[string]Aggregation{
+		// metrics
+		"avg":         NewAvgAggregation().Field("x").Format("0.0").Meta(map[string]interface{}{"tag": "a"}),
+		"boxplot":     NewBoxplotAggregation().Field("x").Missing(0).Compression(200),
+		"cardinality": NewCardinalityAggregation().Field("x").PrecisionThreshold(100).Rehash(true),
+		"ext_stats":   NewExtendedStatsAggregation().Script(script),
+		"geo_bounds":  NewGeoBoundsAggregation().Field("loc").WrapLongitude(true),
+		"centroid":    NewGeoCentroidAggregation().Field("loc"),
+		"matrix":      NewMatrixStatsAggregation().Fields("x", "y").Mode("avg"),
+		"max":         NewMaxAggregation().Script(elastic.NewScript("doc['x'].value")),
+		"mad":         NewMedianAbsoluteDeviationAggregation().Field("x").Compression(100),
+		"min":         NewMinAggregation().Field("x"),
+		"ranks":       NewPercentileRanksAggregation().Field("x").Values(1, 2.5).Estimator("tdigest"),
+		"percentiles": NewPercentilesAggregation().Field("x").Percentiles(50, 99.9),
+		"rate":        NewRateAggregation().Field("x").Unit("day").Mode("sum"),
+		"scripted": NewScriptedMetricAggregation().
+			InitScript(elastic.NewScript("state.n = 0")).
+			MapScript(elastic.NewScript("state.n++")).
+			CombineScript(elastic.NewScriptStored("combine")).
+			ReduceScript(elastic.NewScript("states.size()")).
+			Params(map[string]interface{}{"k": 1}),
+		"stats":        NewStatsAggregation().Field("x").Missing(1.5),
+		"string_stats": NewStringStatsAggregation().Field("s").ShowDistribution(true),
+		"sum":          NewSumAggregation().Field("x"),
+		"ttest":        NewTTestAggregation().A("x", query).B("y", nil).Type("paired"),
+		"top_hits": NewTopHitsAggregation().From(1).Size(3).Explain(true).
+			FetchSourceIncludeExclude([]string{"a"}, []string{"b"}).
+			DocValueFields("d").ScriptField("f", script).Sort("date", false),
+		"top_metrics": NewTopMetricsAggregation().Metrics("x", "y").Sort("date", true).Size(2),
+		"value_count": NewValueCountAggregation().Field("x"),
+
+		// buckets
+		"adjacency":  NewAdjacencyMatrixAggregation().Filters("a", query).Separator("&"),
+		"auto_hist":  NewAutoDateHistogramAggregation().Field("d").Buckets(10).TimeZone("UTC"),
+		"children":   NewChildrenAggregation().Type("answer"),
+		"composite":  NewCompositeAggregation().Sources(NewCompositeAggregationTermsValuesSource("t").Field("x")).Size(5),
+		"date_hist":  NewDateHistogramAggregation().Field("d").Interval("1d").Order("_key", false).ExtendedBoundsOf(&ExtendedBounds{Min: 0, Max: 100}),
+		"date_range": NewDateRangeAggregation().Field("d").AddRangeWithKey("old", nil, "now-1y").Format("yyyy"),
+		"diversified": NewDiversifiedSamplerAggregation().Field("x").ShardSize(10).ExecutionHint("map").
+			SubAggregation("m", NewMaxAggregation().Field("y")),
+		"filter":       NewFilterAggregation().Filter(query),
+		"filters":      NewFiltersAggregation().AddNamedFilter("a", query).AddNamedFilter("b", elastic.NewMatchAllQuery()),
+		"anon_filters": NewFiltersAggregation().Filters(query, elastic.NewMatchAllQuery()),
+		"geo_distance": NewGeoDistanceAggregation().Field("loc").Point("1,2").Unit("km").AddRangeWithKey("near", nil, 10),
+		"geohash":      NewGeoHashGridAggregation().Field("loc").Precision(5).Size(10),
+		"global":       NewGlobalAggregation(),
+		"histogram":    NewHistogramAggregation().Field("x").Interval(10).Order("_count", true).MinDocCount(1),
+		"ip_range":     NewIPRangeAggregation().Field("ip").AddMaskRangeWithKey("net", "10.0.0.0/8").AddRangeWithKey("r", "10.0.0.1", "10.0.0.9"),
+		"missing":      NewMissingAggregation().Field("x"),
+		"multi_terms":  NewMultiTermsAggregation().Terms(MultiTermsField{Field: "a"}, MultiTermsField{Field: "b", Missing: "n/a"}).Size(3),
+		"nested":       NewNestedAggregation().Path("items"),
+		"range":        NewRangeAggregation().Field("x").Keyed(true).AddRangeWithKey("low", nil, 10).AddRangeWithKey("high", 10, nil),
+		"rare_terms":   NewRareTermsAggregation().Field("x").MaxDocCount(2).IncludeValues("a", "b"),
+		"reverse":      NewReverseNestedAggregation().Path("items"),
+		"sampler":      NewSamplerAggregation().ShardSize(100),
+		"sig_terms": NewSignificantTermsAggregation().Field("x").RequiredSize(5).BackgroundFilter(query).
+			SignificanceHeuristic(NewChiSquareSignificanceHeuristic().IncludeNegatives(true)),
+		"sig_text": NewSignificantTextAggregation().Field("s").FilterDuplicateText(true).Include("a.*"),
+		"terms": NewTermsAggregation().Field("x").Size(10).Missing("n/a").OrderByCountDesc().
+			IncludePartition(1, 4).CollectionMode("breadth_first"),
+		"var_hist": NewVariableWidthHistogramAggregation().Field("x").Buckets(3),
+
+		// pipelines
+		"avg_bucket":    NewAvgBucketAggregation().BucketsPath("h>m").GapPolicy("skip"),
+		"bucket_script": NewBucketScriptAggregation().BucketsPathsMap(map[string]string{"a": "x", "b": "y"}).Script(elastic.NewScript("params.a / params.b")),
+		"selector":      NewBucketSelectorAggregation().BucketsPathsMap(map[string]string{"c": "_count"}).Script(elastic.NewScript("params.c > 1")),
+		"bucket_sort":   NewBucketSortAggregation().Sort("x", false).Size(3),
+		"cum_card":      NewCumulativeCardinalityAggregation().BucketsPath("c"),
+		"cum_sum":       NewCumulativeSumAggregation().BucketsPath("s").Format("0"),
+		"derivative":    NewDerivativeAggregation().BucketsPath("s").Unit("1d"),
+		"inference":     NewInferenceBucketAggregation().ModelID("m").BucketsPathsMap(map[string]string{"x": "s"}),
+		"max_bucket":    NewMaxBucketAggregation().BucketsPath("h>m"),
+		"min_bucket":    NewMinBucketAggregation().BucketsPath("h>m"),
+		"mov_avg":       NewMovAvgAggregation().BucketsPath("s").Model(NewEWMAMovAvgModel().Alpha(0.5)).Window(5),
+		"mov_pct":       NewMovingPercentilesAggregation().BucketsPath("p").Window(10).Shift(1),
+		"normalize":     NewNormalizeAggregation().BucketsPath("s").Method("percent_of_sum"),
+		"pct_bucket":    NewPercentilesBucketAggregation().BucketsPath("h>m").Percents(25, 75),
+		"serial_diff":   NewSerialDiffAggregation().BucketsPath("s").Lag(7),
+		"stats_bucket":  NewStatsBucketAggregation().BucketsPath("h>m"),
+		"sum_bucket":    NewSumBucketAggregation().BucketsPath("h>m"),
+	}
+}
+
+func TestRestoreSnapshotKeepsConcreteTypes(t *testing.T) {
+	root := NewTermsAggregation().Field("root")
+	cases := snapshotCases()
+	for name, agg := range cases {
+		root.SubAggregation(name, agg)
+	}
+	NewGlobalAggregation().SubAggregation("top", root)
+
+	data, err := root.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := RestoreSnapshot(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.GetName() != "top" {
+		t.Errorf("expected the root name %q, got %q", "top", restored.GetName())
+	}
+	if _, ok := restored.(*TermsAggregation); !ok {
+		t.Errorf("expected the root to be *TermsAggregation, got %T", restored)
+	}
+	for name, agg := range cases {
+		if got := restored.Select(name); reflect.TypeOf(got) != reflect.TypeOf(agg) {
+			t.Errorf("%s: expected %T, got %T", name, agg, got)
+		}
+	}
+	if _, ok := restored.Select("diversified", "m").(*MaxAggregation); !ok {
+		t.Errorf("expected the subAggregation to be restored as *MaxAggregation")
+	}
+
+	expected, err := CanonicalSource(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := CanonicalSource(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestRestoreSnapshotWrapsUnknownTypes(t *testing.T) {
+	data := []byte(`{"top":{"terms":{"field":"x"},"aggs":{
+		"custom":{"future_agg":{"field":"y"}},
+		"odd":{"avg":{"field":"y","unknown_option":1}}
+	}}}`)
+
+	restored, err := RestoreSnapshot(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := restored.(*TermsAggregation); !ok {
+		t.Errorf("expected *TermsAggregation, got %T", restored)
+	}
+	for _, name := range []string{"custom", "odd"} {
+		if _, ok := restored.Select(name).(*wrapped); !ok {
+			t.Errorf("%s: expected a Wrap, got %T", name, restored.Select(name))
+		}
+	}
+
+	src, err := restored.Select("odd").Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := src.(map[string]interface{})["avg"].(map[string]interface{})["unknown_option"]; !ok {
+		t.Errorf("expected the unknown option to be kept, got %v", src)
+	}
+}
+
+func TestRestoreSnapshotRejectsMalformedData(t *testing.T) {
+	for _, data := range []string{`{}`, `{"a":{},"b":{}}`, `{"a":1}`, `{"a":{"aggs":1}}`} {
+		if _, err := RestoreSnapshot([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", data)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package aggretastic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationErrors collects the errors of all the invalid aggregations of a tree
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// ValidateTree validates root and all its subAggs (depth-first, sorted by name).
+// It returns nil if all of them are valid, otherwise ValidationErrors
+// with the errors prefixed by the path of the invalid subAggs joined with PathSeparator.
+func ValidateTree(root Aggregation) error {
+	if isNilAgg(root) {
+		return nil
+	}
+
+	var errs ValidationErrors
+	if err := root.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	root.Walk(func(path []string, agg Aggregation) bool {
+		if isNilAgg(agg) {
+			return false
+		}
+		if err := agg.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", strings.Join(path, PathSeparator), err))
+		}
+		return true
+	})
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
@@ -0,0 +1,42 @@
+package aggretastic
+
+import "testing"
+
+func TestValidateTree(t *testing.T) {
+	root := NewTermsAggregation().Field("user").
+		SubAggregation("filtered", NewFilterAggregation().SubAggregation("max", NewMaxAggregation())).
+		SubAggregation("change", NewDerivativeAggregation())
+
+	err := ValidateTree(root)
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %d: %v", len(errs), err)
+	}
+
+	want := "change: elastic: DerivativeAggregation requires a buckets path; " +
+		"filtered: elastic: FilterAggregation requires a filter; " +
+		"filtered>max: elastic: MaxAggregation requires a field or a script"
+	if err.Error() != want {
+		t.Fatalf("expected %s, got %s", want, err)
+	}
+
+	if err := ValidateTree(NewTermsAggregation().Field("user")); err != nil {
+		t.Fatalf("expected a valid tree, got %v", err)
+	}
+}
+
+func TestValidateTreeSkipsTypedNil(t *testing.T) {
+	var typedNil *MaxAggregation
+	if err := ValidateTree(typedNil); err != nil {
+		t.Fatalf("expected nil for a typed nil root, got %v", err)
+	}
+
+	root := NewTermsAggregation().Field("user")
+	root.subAggregations["broken"] = typedNil
+	if err := ValidateTree(root); err != nil {
+		t.Fatalf("expected the typed nil subAgg skipped, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package aggretastic
+
+import (
+	"fmt"
+	"github.com/olivere/elastic"
+)
+
+// wrapped adapts an arbitrary elastic.Aggregation to the Aggregation interface.
+// Its own Source() is used as is, subAggregations injected into the tree are added to it.
+type wrapped struct {
+	*tree
+
+	name string
+	agg  elastic.Aggregation
+}
+
+// Wrap makes a tree out of any original elastic.Aggregation, so hand-built aggregations
+// can be mixed into the managed tree. The name is used in error messages only.
+func Wrap(name string, agg elastic.Aggregation) Aggregation {
+	a := &wrapped{name: name, agg: agg}
+	a.tree = nilAggregationTree(a)
+
+	return a
+}
+
+// Unwrap returns the original elastic.Aggregation given to Wrap
+func (a *wrapped) Unwrap() elastic.Aggregation {
+	return a.agg
+}
+
+// AggregationType returns the type key found in the source of the wrapped aggregation
+// (empty if it can't be rendered or there isn't exactly one)
+func (a *wrapped) AggregationType() string {
+	src, err := a.agg.Source()
+	if err != nil {
+		return ""
+	}
+	source, _ := src.(map[string]interface{})
+
+	typ := ""
+	for key := range source {
+		switch key {
+		case "aggregations", "aggs", "meta":
+			continue
+		}
+		if typ != "" {
+			return ""
+		}
+		typ = key
+	}
+
+	return typ
+}
+
+func (a *wrapped) Source() (interface{}, error) {
+	return a.source(0)
+}
+
+func (a *wrapped) source(depth int) (interface{}, error) {
+	src, err := a.agg.Source()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(a.subAggregations) == 0 {
+		return src, nil
+	}
+
+	source, ok := src.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("aggregation %q can't have subAggregations: its source is not an object", a.name)
+	}
+
+	// AggregationBuilder (SubAggregations), merged with the ones of the wrapped aggregation
+	own, _ := source["aggregations"].(map[string]interface{})
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
+	}
+	for name, src := range own {
+		aggsMap := source["aggregations"].(map[string]interface{})
+		if _, ok := aggsMap[name]; !ok {
+			aggsMap[name] = src
+		}
+	}
+
+	return source, nil
+}
@@ -0,0 +1,58 @@
+package aggretastic
+
+import "testing"
+
+func TestWrap(t *testing.T) {
+	original := NewTermsAggregation().Field("user").SubAggregation("own", NewMaxAggregation().Field("x"))
+	agg := Wrap("users", original)
+
+	if err := agg.Inject(NewAvgAggregation().Field("x"), "injected"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := agg.Select("injected").(*AvgAggregation); !ok {
+		t.Fatalf("expected the injected subAggregation selected, got %T", agg.Select("injected"))
+	}
+	if agg.Unwrap() != original {
+		t.Fatal("expected the original aggregation unwrapped")
+	}
+	if agg.AggregationType() != "terms" {
+		t.Fatalf("expected the type %q, got %q", "terms", agg.AggregationType())
+	}
+
+	want := `{"aggregations":{"injected":{"avg":{"field":"x"}},"own":{"max":{"field":"x"}}},"terms":{"field":"user"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	root := NewGlobalAggregation().SubAggregation("users", agg)
+	want = `{"aggregations":{"users":{"aggregations":{"injected":{"avg":{"field":"x"}},"own":{"max":{"field":"x"}}},"terms":{"field":"user"}}},"global":{}}`
+	if root.String() != want {
+		t.Fatalf("expected %s, got %s", want, root)
+	}
+}
+
+func TestWrapRejectsSubAggregationsOfNonObjects(t *testing.T) {
+	agg := Wrap("raw", rawJSON(`{"custom":{}}`))
+	if err := agg.Inject(NewAvgAggregation().Field("x"), "avg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := agg.Source(); err == nil {
+		t.Fatal("expected an error for the subAggregations of a non-object source")
+	}
+}
+
+func TestUnwrapAndExport(t *testing.T) {
+	original := NewAvgAggregation().Field("x")
+	wrapped := Wrap("avg", original)
+	if wrapped.Unwrap() != original {
+		t.Fatal("expected the wrapped aggregation unwrapped")
+	}
+
+	agg := NewTermsAggregation().Field("user")
+	if agg.Unwrap() != nil {
+		t.Fatalf("expected nil unwrapped from an aggregation of the package, got %v", agg.Unwrap())
+	}
+	if agg.Export() != agg {
+		t.Fatal("expected the aggregation itself exported")
+	}
+}
@@ -0,0 +1,210 @@
+package aggretastic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/olivere/elastic"
+)
+
+// shallowDepth makes source() render the aggregation without its subAggregations
+const shallowDepth = -1
+
+// WriteSource writes the JSON of the aggregation source to w.
+// The output is the same as json.Marshal of Source(), but the subAggregations
+// are streamed one by one instead of building the source of the whole tree in memory,
+// which matters for large generated trees. Only the subtrees of a single level
+// (an aggregation with leaf subAggs) are rendered at once.
+func (a *tree) WriteSource(w io.Writer) error {
+	sw := newSourceWriter(w)
+	if err := sw.writeAgg(a.root, a.subAggregations, 0); err != nil {
+		return err
+	}
+	if sw.err != nil {
+		return sw.err
+	}
+
+	return sw.w.Flush()
+}
+
+// sourceWriter writes to w until the first error
+type sourceWriter struct {
+	w   *bufio.Writer
+	err error
+
+	// buf and enc are reused to encode the values
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+func newSourceWriter(w io.Writer) *sourceWriter {
+	sw := &sourceWriter{w: bufio.NewWriter(w)}
+	sw.enc = json.NewEncoder(&sw.buf)
+
+	return sw
+}
+
+func (sw *sourceWriter) write(p []byte) {
+	if sw.err == nil {
+		_, sw.err = sw.w.Write(p)
+	}
+}
+
+func (sw *sourceWriter) writeString(s string) {
+	if sw.err == nil {
+		_, sw.err = sw.w.WriteString(s)
+	}
+}
+
+func (sw *sourceWriter) writeJSON(v interface{}) error {
+	sw.buf.Reset()
+	if err := sw.enc.Encode(v); err != nil {
+		return err
+	}
+	// Encode terminates the value with a newline
+	sw.write(bytes.TrimSuffix(sw.buf.Bytes(), []byte("\n")))
+
+	return sw.err
+}
+
+// writeKey writes the object key, the usual aggregation names are written as is
+func (sw *sourceWriter) writeKey(key string) error {
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.') {
+			return sw.writeJSON(key)
+		}
+	}
+	sw.writeString(`"`)
+	sw.writeString(key)
+	sw.writeString(`"`)
+
+	return sw.err
+}
+
+func (sw *sourceWriter) writeAgg(agg elastic.Aggregation, subs map[string]Aggregation, depth int) error {
+	// the aggregations having leaf subAggs only are small enough to be rendered at once,
+	// which is much cheaper than encoding every leaf on its own
+	if len(subs) > 0 && depth < MaxAggregationDepth && onlyLeaves(subs) {
+		src, err := agg.Source()
+		if err != nil {
+			return err
+		}
+		return sw.writeJSON(src)
+	}
+
+	var src interface{}
+	var err error
+	if s, ok := agg.(depthSourcer); ok {
+		src, err = s.source(shallowDepth)
+	} else {
+		src, err = agg.Source()
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(subs) == 0 {
+		return sw.writeJSON(src)
+	}
+	if depth >= MaxAggregationDepth {
+		return ErrMaxDepthExceeded
+	}
+
+	source, ok := src.(map[string]interface{})
+	if !ok {
+		// the aggregation renders something else than an object, let it deal with the subs itself
+		return sw.writeJSON(src)
+	}
+
+	// subAggregations the aggregation renders itself (e.g. the wrapped ones) are kept
+	// unless there are injected subAggs with the same names
+	own, _ := source["aggregations"].(map[string]interface{})
+
+	keys := make([]string, 0, len(source)+1)
+	for key := range source {
+		if key != "aggregations" {
+			keys = append(keys, key)
+		}
+	}
+	keys = append(keys, "aggregations")
+	sort.Strings(keys)
+
+	sw.writeString("{")
+	for i, key := range keys {
+		if i > 0 {
+			sw.writeString(",")
+		}
+		if err := sw.writeKey(key); err != nil {
+			return err
+		}
+		sw.writeString(":")
+
+		if key != "aggregations" {
+			if err := sw.writeJSON(source[key]); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := sw.writeSubs(own, subs, depth); err != nil {
+			return err
+		}
+	}
+	sw.writeString("}")
+
+	return sw.err
+}
+
+func (sw *sourceWriter) writeSubs(own map[string]interface{}, subs map[string]Aggregation, depth int) error {
+	names := make([]string, 0, len(own)+len(subs))
+	for name, sub := range subs {
+		if !isNilAgg(sub) {
+			names = append(names, name)
+		}
+	}
+	for name := range own {
+		if sub, ok := subs[name]; !ok || isNilAgg(sub) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	sw.writeString("{")
+	for i, name := range names {
+		if i > 0 {
+			sw.writeString(",")
+		}
+		if err := sw.writeKey(name); err != nil {
+			return err
+		}
+		sw.writeString(":")
+
+		sub, ok := subs[name]
+		if !ok || isNilAgg(sub) {
+			if err := sw.writeJSON(own[name]); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := sw.writeAgg(sub, sub.GetAllSubs(), depth+1); err != nil {
+			return wrapSubError(name, err)
+		}
+	}
+	sw.writeString("}")
+
+	return sw.err
+}
+
+// onlyLeaves reports whether none of subs has subAggregations of its own
+func onlyLeaves(subs map[string]Aggregation) bool {
+	for _, sub := range subs {
+		if !isNilAgg(sub) && len(sub.GetAllSubs()) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
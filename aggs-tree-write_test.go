@@ -0,0 +1,78 @@
+package aggretastic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"testing"
+)
+
+// newWideTree makes a tree of a few thousands aggregations
+func newWideTree() *TermsAggregation {
+	root := NewTermsAggregation().Field("x")
+	for i := 0; i < 300; i++ {
+		h := NewHistogramAggregation().Field("h").Interval(1)
+		for j := 0; j < 10; j++ {
+			h.SubAggregation(fmt.Sprint("m", j), NewMaxAggregation().Field("y"))
+		}
+		root.SubAggregation(fmt.Sprint("h", i), h)
+	}
+
+	return root
+}
+
+func TestWriteSource(t *testing.T) {
+	root := newWideTree()
+	root.SubAggregation("wrapped", Wrap("wrapped", NewMaxAggregation().Field("q")))
+	root.SubAggregation("pipeline", NewMaxBucketAggregation().BucketsPath("h0>m0"))
+
+	var buf bytes.Buffer
+	if err := root.WriteSource(&buf); err != nil {
+		t.Fatal(err)
+	}
+	src, err := root.Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(want) {
+		t.Fatal("expected WriteSource to write the same JSON as Source")
+	}
+}
+
+func TestWriteSourceFailsWithSource(t *testing.T) {
+	root := NewTermsAggregation().Field("x").SubAggregation("matrix", NewMatrixStatsAggregation())
+	if err := root.WriteSource(ioutil.Discard); err == nil {
+		t.Fatal("expected the error of the subAggregation")
+	}
+}
+
+func BenchmarkWriteSource(b *testing.B) {
+	root := newWideTree()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := root.WriteSource(ioutil.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSourceMarshal(b *testing.B) {
+	root := newWideTree()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		src, err := root.Source()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := json.Marshal(src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
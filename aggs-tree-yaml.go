@@ -0,0 +1,103 @@
+//go:build yaml
+// +build yaml
+
+package aggretastic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+)
+
+// The YAML support needs gopkg.in/yaml.v2, so it's built with the "yaml" tag only:
+// go build -tags yaml
+// The package doesn't require yaml.v2 otherwise (glide keeps it as a test import),
+// so the builds with the tag need it in their own dependencies.
+
+// ToYAML serializes the aggregation with all its subAggregations into YAML,
+// e.g. to keep it as a template which is easy to read and edit by hand.
+// It's the same as Snapshot serializes into JSON: the source keyed by the name of the aggregation.
+func ToYAML(agg Aggregation) ([]byte, error) {
+	if isNilAgg(agg) {
+		return nil, ErrNilAggregation
+	}
+
+	src, err := agg.Source()
+	if err != nil {
+		return nil, err
+	}
+
+	// the source is normalized through JSON, so YAML gets plain maps, slices and numbers
+	data, err := json.Marshal(map[string]interface{}{agg.GetName(): src})
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	// the numbers are turned into integers where possible, so they aren't written as floats
+	return yaml.Marshal(plainValue(value))
+}
+
+// FromYAML rebuilds the tree serialized by ToYAML (or written by hand in the same form).
+// Like with RestoreSnapshot, the aggregations are restored as their concrete types and the root
+// gets its name back.
+func FromYAML(data []byte) (Aggregation, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	value, err := fromYAMLValue(value)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := value.(map[string]interface{}); !ok {
+		return nil, errors.New("aggregation YAML must be an object")
+	}
+
+	src, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return RestoreSnapshot(src)
+}
+
+// fromYAMLValue turns the maps decoded by yaml.v2 (keyed by interface{}) into the ones JSON can encode
+func fromYAMLValue(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("aggregation YAML has a non-string key %v", key)
+			}
+			converted, err := fromYAMLValue(item)
+			if err != nil {
+				return nil, err
+			}
+			result[name] = converted
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, item := range value {
+			converted, err := fromYAMLValue(item)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = converted
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,52 @@
+//go:build yaml
+// +build yaml
+
+package aggretastic
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestYAMLRoundTrip(t *testing.T) {
+	root := NewTermsAggregation().Field("user").Size(10).AddMeta("team", "ops")
+	root.SubAggregation("by_day", NewDateHistogramAggregation().Field("ts").Interval("1d").ExtendedBoundsMin(int64(1577836800000)).
+		SubAggregation("avg", NewAvgAggregation().Field("x")))
+	root.SubAggregation("ranges", NewRangeAggregation().Field("p").AddRange(nil, 10.5))
+	NewGlobalAggregation().SubAggregation("users", root)
+
+	data, err := ToYAML(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"users:", "min: 1577836800000", "to: 10.5"} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("expected %q in\n%s", expected, data)
+		}
+	}
+
+	restored, err := FromYAML(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.String() != restored.String() {
+		t.Errorf("expected\n%s\ngot\n%s", root.String(), restored.String())
+	}
+	if restored.GetName() != "users" {
+		t.Errorf("expected the root name %q, got %q", "users", restored.GetName())
+	}
+	if _, ok := restored.(*TermsAggregation); !ok {
+		t.Errorf("expected *TermsAggregation, got %T", restored)
+	}
+	if _, ok := restored.Select("by_day", "avg").(*AvgAggregation); !ok {
+		t.Errorf("expected *AvgAggregation, got %T", restored.Select("by_day", "avg"))
+	}
+}
+
+func TestFromYAMLRejectsMalformedData(t *testing.T) {
+	for _, data := range []string{"- a\n", "top:\n  terms:\n    1: x\n", "a: {}\nb: {}\n"} {
+		if _, err := FromYAML([]byte(data)); err == nil {
+			t.Errorf("%q: expected an error", data)
+		}
+	}
+}
@@ -3,14 +3,28 @@ package aggretastic
 import (
 	"fmt"
 	"github.com/olivere/elastic"
+	"reflect"
+	"sort"
 )
 
 var (
 	ErrNoPath             = fmt.Errorf("no path")
 	ErrPathNotSelectable  = fmt.Errorf("path is not selectable")
 	ErrAggIsNotInjectable = fmt.Errorf("agg is not injectable")
+	ErrPathAlreadyExists  = fmt.Errorf("path already exists")
+	ErrMaxDepthExceeded   = fmt.Errorf("max aggregation depth exceeded")
+	ErrCycleDetected      = fmt.Errorf("aggregation can't be injected into its own subtree")
+	ErrAggNotReachable    = fmt.Errorf("aggregation is not reachable")
+	ErrAggNotCloneable    = fmt.Errorf("aggregation can't be cloned")
+	ErrNilAggregation     = fmt.Errorf("nil aggregation can't be injected")
+	ErrMergeConflict      = fmt.Errorf("merge would replace a subtree with a leaf")
 )
 
+// MaxAggregationDepth limits the depth of subAggregations rendered by Source().
+// Deeper (or cyclic) trees make Source() return ErrMaxDepthExceeded,
+// the traversals (Walk, Dump, Clone etc.) don't go deeper either.
+var MaxAggregationDepth = 100
+
 // Aggregation is a tree-ish version of original elastic.Aggregation
 // Besides just attaching subAggregations it can get any of children subAggregations
 // and add another subAggregation to it
@@ -19,6 +33,14 @@ type Aggregation interface {
 	// is used to support call of `.Source()` method from aggregations' code
 	elastic.Aggregation
 
+	// AggregationType returns the Elasticsearch type of the aggregation,
+	// the key it's rendered with by Source(), e.g. "terms" or "sum_bucket"
+	AggregationType() string
+
+	// GetName returns the name the aggregation was injected with into its parent
+	// (empty if it isn't injected anywhere)
+	GetName() string
+
 	// GetAllSubs returns the map of this aggregation's subAggregations
 	GetAllSubs() map[string]Aggregation
 
@@ -28,23 +50,85 @@ type Aggregation interface {
 	// InjectX sets new subAgg into the map of subAggregations only if it NOT exists already
 	InjectX(subAgg Aggregation, path ...string) error
 
+	// InjectStrict sets new subAgg into the map of subAggregations or returns ErrPathAlreadyExists if it exists already
+	InjectStrict(subAgg Aggregation, path ...string) error
+
 	// Select returns any subAgg by it's path
 	Select(path ...string) Aggregation
 
 	// Pop returns a subAgg by it's path and remove it from tree
 	Pop(path ...string) Aggregation
 
-	// Export returns the same object in original Agg interface
+	// Walk calls fn for every subAgg in the tree (depth-first, sorted by name)
+	// Returning false from fn skips the subAggs of the current one
+	Walk(fn func(path []string, agg Aggregation) bool)
+
+	// Validate reports the missing required configuration of the aggregation itself
+	// (its subAggs aren't validated, see ValidateTree)
+	Validate() error
+
+	// Export returns the same object in original Agg interface.
+	// It's the aggregation itself (with all its subAggregations), not the aggregation it wraps.
 	Export() elastic.Aggregation
+
+	// Unwrap returns the original elastic.Aggregation the aggregation was made of with Wrap,
+	// or nil for the aggregations of this package
+	Unwrap() elastic.Aggregation
+
+	// String returns the canonical JSON source of the aggregation (see CanonicalSource)
+	// or an <error: ...> marker if it can't be rendered
+	String() string
+}
+
+// depthSourcer is implemented by the tree aggregations
+// to render their source knowing how deep in the tree they are
+type depthSourcer interface {
+	source(depth int) (interface{}, error)
+}
+
+// sourceAt renders the source of agg located at the given depth of the tree
+func sourceAt(agg Aggregation, depth int) (interface{}, error) {
+	if s, ok := agg.(depthSourcer); ok {
+		return s.source(depth)
+	}
+
+	return agg.Source()
+}
+
+// namer is implemented by the aggregations to remember the name they're injected with
+type namer interface {
+	setName(name string)
+}
+
+// nameAgg tells agg the name it's injected with
+func nameAgg(agg Aggregation, name string) {
+	if n, ok := agg.(namer); ok {
+		n.setName(name)
+	}
 }
 
 func IsNilTree(t Aggregation) bool {
 	return t == nil || t.Export() == nil
 }
 
+// isNilAgg reports whether agg is nil, including the nil pointers of the concrete aggregations
+// (calling any method of which would panic)
+func isNilAgg(agg Aggregation) bool {
+	if agg == nil {
+		return true
+	}
+	v := reflect.ValueOf(agg)
+
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
 type tree struct {
 	root            elastic.Aggregation
+	name            string
 	subAggregations map[string]Aggregation
+
+	version uint64
+	cache   *sourceCache
 }
 
 func nilAggregationTree(root elastic.Aggregation) *tree {
@@ -54,14 +138,89 @@ func nilAggregationTree(root elastic.Aggregation) *tree {
 	}
 }
 
+// GetName returns the name the aggregation was injected with.
+// The name is set by Inject (and the rest of the inject methods or SubAggregation)
+// and is the last one used, as the same aggregation may be injected more than once.
+func (a *tree) GetName() string {
+	return a.name
+}
+
+func (a *tree) setName(name string) {
+	a.name = name
+}
+
+// setSub sets subAgg with the given name into the map of subAggregations.
+// It returns ErrNilAggregation for a nil subAgg and ErrCycleDetected if this tree is in the subtree
+// of subAgg. The fluent SubAggregation() setters ignore the error, so SubAggregation(name, nil)
+// and SubAggregation(name, parent) change nothing and don't break Source() or the traversals later.
+func (a *tree) setSub(name string, subAggregation Aggregation) error {
+	if isNilAgg(subAggregation) {
+		return ErrNilAggregation
+	}
+	if a.reachableFrom(subAggregation) {
+		return ErrCycleDetected
+	}
+	nameAgg(subAggregation, name)
+	a.subAggregations[name] = subAggregation
+	a.markDirty()
+
+	return nil
+}
+
+// renderSubAggregations adds the sources of subAggregations (if any) to the aggregation source.
+// Every tree aggregation renders its subAggregations here, so the output is deterministic:
+// the subAggs are rendered in the order of their names (the first failing one is always the same)
+// and encoding/json writes the keys of the resulting maps sorted.
+// Use CanonicalSource to compare sources with values having their own MarshalJSON.
+func (a *tree) renderSubAggregations(source map[string]interface{}, depth int) error {
+	if len(a.subAggregations) == 0 || depth == shallowDepth {
+		return nil
+	}
+	if depth >= MaxAggregationDepth {
+		return ErrMaxDepthExceeded
+	}
+
+	names := sortedNames(a.subAggregations)
+
+	aggsMap := make(map[string]interface{})
+	source["aggregations"] = aggsMap
+	for _, name := range names {
+		subAgg := a.subAggregations[name]
+		if isNilAgg(subAgg) {
+			// can only be put there through GetAllSubs()
+			continue
+		}
+		src, err := sourceAt(subAgg, depth+1)
+		if err != nil {
+			return wrapSubError(name, err)
+		}
+		aggsMap[name] = src
+	}
+
+	return nil
+}
+
+// wrapSubError adds the name of the subAgg failed to render to err.
+// Every level adds its name, so the error tells the whole path to the failed subAgg.
+// ErrMaxDepthExceeded is returned as is: its path (most likely a cycle) is of no use.
+func wrapSubError(name string, err error) error {
+	if err == ErrMaxDepthExceeded {
+		return err
+	}
+
+	return fmt.Errorf("aggregation %q: %w", name, err)
+}
+
 func (a *tree) Inject(subAggregation Aggregation, path ...string) error {
 	if len(path) == 0 {
 		return ErrNoPath
 	}
+	if isNilAgg(subAggregation) {
+		return ErrNilAggregation
+	}
 
 	if len(path) == 1 {
-		a.subAggregations[path[0]] = subAggregation
-		return nil
+		return a.setSub(path[0], subAggregation)
 	}
 
 	// deeper inject
@@ -73,10 +232,44 @@ func (a *tree) Inject(subAggregation Aggregation, path ...string) error {
 	return cursor.Inject(subAggregation, path[len(path)-1])
 }
 
+// reachableFrom reports whether this tree is agg itself or is somewhere in its subtree,
+// so injecting agg here would create a cycle
+func (a *tree) reachableFrom(agg Aggregation) bool {
+	return isReachable(a.root, agg)
+}
+
+// isReachable reports whether target is agg itself or is somewhere in its subtree
+func isReachable(target elastic.Aggregation, agg Aggregation) bool {
+	visited := make(map[elastic.Aggregation]bool)
+
+	var reach func(agg Aggregation) bool
+	reach = func(agg Aggregation) bool {
+		if isNilAgg(agg) || visited[agg] {
+			return false
+		}
+		if elastic.Aggregation(agg) == target {
+			return true
+		}
+		visited[agg] = true
+
+		for _, sub := range agg.GetAllSubs() {
+			if reach(sub) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return reach(agg)
+}
+
 func (a *tree) InjectX(subAggregation Aggregation, path ...string) error {
 	if len(path) == 0 {
 		return ErrNoPath
 	}
+	if isNilAgg(subAggregation) {
+		return ErrNilAggregation
+	}
 
 	if alreadyInjected := a.Select(path...); IsNilTree(alreadyInjected) {
 		return a.Inject(subAggregation, path...)
@@ -85,10 +278,177 @@ func (a *tree) InjectX(subAggregation Aggregation, path ...string) error {
 	return nil
 }
 
+func (a *tree) InjectStrict(subAggregation Aggregation, path ...string) error {
+	if len(path) == 0 {
+		return ErrNoPath
+	}
+	if isNilAgg(subAggregation) {
+		return ErrNilAggregation
+	}
+
+	if len(path) == 1 {
+		if _, ok := a.subAggregations[path[0]]; ok {
+			return ErrPathAlreadyExists
+		}
+		return a.setSub(path[0], subAggregation)
+	}
+
+	// deeper inject
+	cursor := a.Select(path[:len(path)-1]...)
+	if IsNilTree(cursor) {
+		return ErrPathNotSelectable
+	}
+
+	return cursor.InjectStrict(subAggregation, path[len(path)-1])
+}
+
+// AddSubAggregation sets new subAgg with the given name or returns ErrPathAlreadyExists if the name is taken.
+// It's a safe alternative to the fluent SubAggregation() setters of aggregations.
+func (a *tree) AddSubAggregation(name string, subAggregation Aggregation) error {
+	return a.InjectStrict(subAggregation, name)
+}
+
+// InjectReplace does the same as Inject but returns the subAgg it replaced (nil if there was nothing)
+func (a *tree) InjectReplace(subAggregation Aggregation, path ...string) (Aggregation, error) {
+	old := a.Select(path...)
+	if err := a.Inject(subAggregation, path...); err != nil {
+		return nil, err
+	}
+
+	return old, nil
+}
+
+// SelectOrCreate returns the subAgg found by path or, if there is none,
+// injects the one made by factory and returns it.
+// All the parents on the path must exist, otherwise ErrPathNotSelectable is returned.
+func (a *tree) SelectOrCreate(factory func() Aggregation, path ...string) (Aggregation, error) {
+	if len(path) == 0 {
+		return nil, ErrNoPath
+	}
+
+	if existing := a.Select(path...); !IsNilTree(existing) {
+		return existing, nil
+	}
+
+	subAggregation := factory()
+	if err := a.Inject(subAggregation, path...); err != nil {
+		return nil, err
+	}
+
+	return subAggregation, nil
+}
+
+// InjectDeep injects subAgg by path creating the missing parents on the way with factory,
+// which gets the name of the parent to make. The parents made by factory must be bucket aggregations
+// and the existing ones must be able to hold subAggs, otherwise ErrAggIsNotInjectable is returned.
+// Nothing is changed if an error is returned.
+func (a *tree) InjectDeep(factory func(name string) Aggregation, subAggregation Aggregation, path ...string) error {
+	if len(path) == 0 {
+		return ErrNoPath
+	}
+	if isNilAgg(subAggregation) {
+		return ErrNilAggregation
+	}
+
+	// the deepest of the parents which exist already
+	parent, ok := a.root.(Aggregation)
+	if !ok {
+		return ErrAggIsNotInjectable
+	}
+	depth := 0
+	for ; depth < len(path)-1 && !IsNotInjectable(parent); depth++ {
+		next := parent.Select(path[depth])
+		if IsNilTree(next) {
+			break
+		}
+		parent = next
+	}
+	if IsNotInjectable(parent) {
+		return ErrAggIsNotInjectable
+	}
+
+	// the missing parents are chained apart from the tree, so it stays as is on error
+	var top, bottom Aggregation
+	for _, name := range path[depth : len(path)-1] {
+		container := factory(name)
+		if IsNilTree(container) || !IsBucketAggregation(container) {
+			return ErrAggIsNotInjectable
+		}
+		if bottom == nil {
+			top = container
+		} else if err := bottom.Inject(container, name); err != nil {
+			return err
+		}
+		bottom = container
+	}
+
+	name := path[len(path)-1]
+	if top == nil {
+		return parent.Inject(subAggregation, name)
+	}
+	if err := bottom.Inject(subAggregation, name); err != nil {
+		return err
+	}
+
+	return parent.Inject(top, path[depth])
+}
+
+// InjectMany sets all the subs into the map of subAggregations of the agg found by parentPath
+// (or of this agg if parentPath is empty). Either all the subs are injected or none of them:
+// every sub is checked first and the first error is returned, the names of the subs must not be taken
+// (ErrPathAlreadyExists) and the parent must not be in their subtrees (ErrCycleDetected).
+// The subs are injected in the order of their names.
+func (a *tree) InjectMany(subs map[string]Aggregation, parentPath ...string) error {
+	parent, ok := a.root.(Aggregation)
+	if !ok {
+		return ErrAggIsNotInjectable
+	}
+	if len(parentPath) > 0 {
+		parent = a.Select(parentPath...)
+	}
+	if IsNilTree(parent) {
+		return ErrPathNotSelectable
+	}
+	if IsNotInjectable(parent) {
+		return ErrAggIsNotInjectable
+	}
+
+	names := sortedNames(subs)
+	existing := parent.GetAllSubs()
+	for _, name := range names {
+		subAgg := subs[name]
+		if name == "" {
+			return ErrNoPath
+		}
+		if isNilAgg(subAgg) {
+			return ErrNilAggregation
+		}
+		if _, ok := existing[name]; ok {
+			return ErrPathAlreadyExists
+		}
+		if isReachable(parent, subAgg) {
+			return ErrCycleDetected
+		}
+	}
+
+	for _, name := range names {
+		if err := parent.Inject(subs[name], name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (a *tree) GetAllSubs() map[string]Aggregation {
 	return a.subAggregations
 }
 
+// SubAggregationNames returns the sorted names of the direct subAggs
+func (a *tree) SubAggregationNames() []string {
+	return sortedNames(a.subAggregations)
+}
+
 func (a *tree) Select(path ...string) Aggregation {
 	if len(path) == 0 {
 		return nil
@@ -106,6 +466,18 @@ func (a *tree) Select(path ...string) Aggregation {
 	return subAgg.Select(path[1:]...)
 }
 
+// ParentOf returns the parent of the subAgg found by path together with the path of the parent.
+// It returns nil if path is shorter than 2 (the parent is the aggregation itself) or doesn't resolve.
+func (a *tree) ParentOf(path ...string) (Aggregation, []string) {
+	if len(path) <= 1 || IsNilTree(a.Select(path...)) {
+		return nil, nil
+	}
+
+	parentPath := append([]string{}, path[:len(path)-1]...)
+
+	return a.Select(parentPath...), parentPath
+}
+
 func (a *tree) Pop(path ...string) Aggregation {
 	if len(path) == 0 {
 		return nil
@@ -118,20 +490,132 @@ func (a *tree) Pop(path ...string) Aggregation {
 
 	if len(path) == 1 {
 		delete(a.subAggregations, path[0])
+		a.markDirty()
 		return subAgg
 	}
 
 	return subAgg.Pop(path[1:]...)
 }
 
+func (a *tree) Walk(fn func(path []string, agg Aggregation) bool) {
+	walkSubs(a.subAggregations, nil, fn)
+}
+
+// ForEachSub calls fn for every direct subAgg (sorted by name) and stops at the first error
+// returned by fn, which is returned then. fn may change the tree, e.g. Pop or Inject subAggs:
+// the names are taken before the first call and the ones removed on the way are skipped.
+func (a *tree) ForEachSub(fn func(name string, sub Aggregation) error) error {
+	names := sortedNames(a.subAggregations)
+
+	for _, name := range names {
+		subAgg, ok := a.subAggregations[name]
+		if !ok {
+			continue
+		}
+		if err := fn(name, subAgg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sortedNames returns the names of subs in sorted order
+func sortedNames(subs map[string]Aggregation) []string {
+	names := make([]string, 0, len(subs))
+	for name := range subs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// walkSubs calls fn for every subAgg of subs and their subAggs down to MaxAggregationDepth,
+// the deeper (e.g. cyclic) subAggs aren't visited like they aren't rendered by Source()
+func walkSubs(subs map[string]Aggregation, prefix []string, fn func(path []string, agg Aggregation) bool) {
+	names := sortedNames(subs)
+
+	for _, name := range names {
+		subAgg := subs[name]
+		path := append(append(make([]string, 0, len(prefix)+1), prefix...), name)
+		if !fn(path, subAgg) || isNilAgg(subAgg) || len(path) >= MaxAggregationDepth {
+			continue
+		}
+		walkSubs(subAgg.GetAllSubs(), path, fn)
+	}
+}
+
+// deeperThanMax reports whether agg has subAggs deeper than MaxAggregationDepth,
+// so Source() fails on it with ErrMaxDepthExceeded
+func deeperThanMax(agg Aggregation) bool {
+	deep := false
+	agg.Walk(func(path []string, subAgg Aggregation) bool {
+		if len(path) >= MaxAggregationDepth && !isNilAgg(subAgg) && len(subAgg.GetAllSubs()) > 0 {
+			deep = true
+		}
+		return !deep
+	})
+
+	return deep
+}
+
 func (a *tree) Export() elastic.Aggregation {
 	return a.root
 }
 
+func (a *tree) Validate() error {
+	// nothing is required unless the concrete aggregation says so
+	return nil
+}
+
+func (a *tree) Unwrap() elastic.Aggregation {
+	return nil
+}
+
+func (a *tree) String() string {
+	return sourceString(a.root)
+}
+
+// Render returns the source of agg wrapped with its name, i.e. { name: { ... } }
+// It's ready to be embedded into the "aggregations" part of a manually assembled request.
+func Render(name string, agg Aggregation) (map[string]interface{}, error) {
+	src, err := agg.Source()
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{name: src}, nil
+}
+
 // Shorthand type for collection of Aggregations
 type Aggregations map[string]Aggregation
 
-// Export does export() on the map of aggregations
+// NewAggregations returns an empty map of aggregations to be filled with Add
+func NewAggregations() Aggregations {
+	return make(Aggregations)
+}
+
+// Add puts agg into the map under name and returns the map, so the calls can be chained:
+// NewAggregations().Add("by_day", dateHistogram).Add("totals", sum)
+// A nil map is made first, so the result must be kept like the one of append.
+// Nil aggregations are ignored.
+func (a Aggregations) Add(name string, agg Aggregation) Aggregations {
+	if a == nil {
+		a = NewAggregations()
+	}
+	if isNilAgg(agg) {
+		return a
+	}
+
+	nameAgg(agg, name)
+	a[name] = agg
+
+	return a
+}
+
+// Export does export() on the map of aggregations.
+// Nil aggregations are skipped.
 func (a *Aggregations) Export() map[string]elastic.Aggregation {
 	result := make(map[string]elastic.Aggregation)
 
@@ -140,12 +624,35 @@ func (a *Aggregations) Export() map[string]elastic.Aggregation {
 	}
 
 	for k, v := range *a {
+		if isNilAgg(v) {
+			continue
+		}
 		result[k] = v.Export()
 	}
 
 	return result
 }
 
+// ExportAll does Export() on a plain map of aggregations, like Aggregations.Export does
+func ExportAll(aggs map[string]Aggregation) map[string]elastic.Aggregation {
+	a := Aggregations(aggs)
+	return a.Export()
+}
+
+// ApplyTo adds the aggregations to the search request by their names and returns the service.
+// Nil aggregations are skipped.
+func ApplyTo(service *elastic.SearchService, aggs map[string]Aggregation) *elastic.SearchService {
+	for _, name := range sortedNames(aggs) {
+		agg := aggs[name]
+		if isNilAgg(agg) {
+			continue
+		}
+		service = service.Aggregation(name, agg.Export())
+	}
+
+	return service
+}
+
 // Select selects an aggregation from the map (going deep forwarding the agg.Select() method)
 func (a *Aggregations) Select(path ...string) Aggregation {
 	if len(path) == 0 {
@@ -192,10 +699,14 @@ func (a *Aggregations) Inject(subAgg Aggregation, path ...string) error {
 	if len(path) == 0 {
 		return ErrNoPath
 	}
+	if isNilAgg(subAgg) {
+		return ErrNilAggregation
+	}
 
 	name := path[0]
 
 	if len(path) == 1 {
+		nameAgg(subAgg, name)
 		(*a)[name] = subAgg
 		return nil
 	}
@@ -208,6 +719,20 @@ func (a *Aggregations) Inject(subAgg Aggregation, path ...string) error {
 	return (*a)[name].Inject(subAgg, path...)
 }
 
+// InjectReplace does the same as Inject but returns the agg it replaced (nil if there was nothing)
+func (a *Aggregations) InjectReplace(subAgg Aggregation, path ...string) (Aggregation, error) {
+	if a == nil {
+		return nil, ErrAggIsNotInjectable
+	}
+
+	old := a.Select(path...)
+	if err := a.Inject(subAgg, path...); err != nil {
+		return nil, err
+	}
+
+	return old, nil
+}
+
 func (a *Aggregations) InjectX(subAgg Aggregation, path ...string) error {
 	if a == nil {
 		return ErrAggIsNotInjectable
@@ -216,11 +741,15 @@ func (a *Aggregations) InjectX(subAgg Aggregation, path ...string) error {
 	if len(path) == 0 {
 		return ErrNoPath
 	}
+	if isNilAgg(subAgg) {
+		return ErrNilAggregation
+	}
 
 	name := path[0]
 
 	if len(path) == 1 {
 		if _, ok := (*a)[name]; !ok {
+			nameAgg(subAgg, name)
 			(*a)[name] = subAgg
 		}
 
@@ -234,3 +763,54 @@ func (a *Aggregations) InjectX(subAgg Aggregation, path ...string) error {
 
 	return (*a)[name].InjectX(subAgg, path...)
 }
+
+func (a *Aggregations) InjectStrict(subAgg Aggregation, path ...string) error {
+	if a == nil {
+		return ErrAggIsNotInjectable
+	}
+
+	if len(path) == 0 {
+		return ErrNoPath
+	}
+	if isNilAgg(subAgg) {
+		return ErrNilAggregation
+	}
+
+	name := path[0]
+
+	if len(path) == 1 {
+		if _, ok := (*a)[name]; ok {
+			return ErrPathAlreadyExists
+		}
+		nameAgg(subAgg, name)
+		(*a)[name] = subAgg
+		return nil
+	}
+
+	path = path[1:]
+	if _, ok := (*a)[name]; !ok {
+		return ErrAggIsNotInjectable
+	}
+
+	return (*a)[name].InjectStrict(subAgg, path...)
+}
+
+// Walk calls fn for every aggregation of the map and all their subAggs (depth-first, sorted by name).
+// The paths start with the name of the top-level aggregation.
+// Returning false from fn skips the subAggs of the current one
+func (a *Aggregations) Walk(fn func(path []string, agg Aggregation) bool) {
+	if a == nil {
+		return
+	}
+
+	walkSubs(*a, nil, fn)
+}
+
+// SubAggregationNames returns the sorted names of the aggregations in the map
+func (a *Aggregations) SubAggregationNames() []string {
+	if a == nil {
+		return []string{}
+	}
+
+	return sortedNames(*a)
+}
@@ -0,0 +1,690 @@
+package aggretastic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/olivere/elastic"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestInjectDetectsCycles(t *testing.T) {
+	a := NewTermsAggregation().Field("a")
+	b := NewTermsAggregation().Field("b")
+	c := NewTermsAggregation().Field("c")
+	if err := a.Inject(b, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Inject(c, "b", "c"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		inject func() error
+	}{
+		{"under its own descendant", func() error { return a.Inject(a, "b", "c", "a") }},
+		{"ancestor under descendant", func() error { return c.Inject(b, "x") }},
+		{"under itself", func() error { return a.Inject(a, "self") }},
+		{"strict", func() error { return a.InjectStrict(a, "b", "c", "strict") }},
+		{"if missing", func() error { return a.InjectX(a, "self") }},
+		{"add", func() error { return c.AddSubAggregation("add", a) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.inject(); err != ErrCycleDetected {
+				t.Fatalf("expected ErrCycleDetected, got %v", err)
+			}
+			if _, err := a.Source(); err != nil {
+				t.Fatalf("expected the tree to stay renderable, got %v", err)
+			}
+		})
+	}
+
+	if err := a.Inject(NewMaxAggregation().Field("x"), "b", "c", "m"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubAggregationIgnoresCycles(t *testing.T) {
+	a := NewTermsAggregation().Field("a")
+	b := NewHistogramAggregation().Field("b").Interval(1)
+	a.SubAggregation("b", b)
+
+	a.SubAggregation("self", a)
+	b.SubAggregation("loop", a)
+
+	if want := `{"aggregations":{"b":{"histogram":{"field":"b","interval":1}}},"terms":{"field":"a"}}`; a.String() != want {
+		t.Fatalf("expected the cyclic subAggs to be ignored, got %s", a)
+	}
+}
+
+func TestTraversalsAreBoundedByMaxDepth(t *testing.T) {
+	a := NewTermsAggregation().Field("a")
+	b := NewTermsAggregation().Field("b")
+	a.SubAggregation("b", b)
+	// the map of subAggs is open, so a cycle can still be made through it
+	b.GetAllSubs()["a"] = a
+
+	if _, err := a.Source(); err != ErrMaxDepthExceeded {
+		t.Fatalf("expected ErrMaxDepthExceeded, got %v", err)
+	}
+
+	visited := 0
+	a.Walk(func(path []string, agg Aggregation) bool {
+		visited++
+		return true
+	})
+	if visited != MaxAggregationDepth {
+		t.Fatalf("expected the walk to stop at depth %d, visited %d", MaxAggregationDepth, visited)
+	}
+	if a.Count() != MaxAggregationDepth || a.Depth() != MaxAggregationDepth || len(a.ListPaths()) != MaxAggregationDepth {
+		t.Fatalf("expected Count, Depth and ListPaths to stop at %d, got %d, %d, %d", MaxAggregationDepth, a.Count(), a.Depth(), len(a.ListPaths()))
+	}
+	if err := a.Dump(&bytes.Buffer{}); err != ErrMaxDepthExceeded {
+		t.Fatalf("expected Dump to return ErrMaxDepthExceeded, got %v", err)
+	}
+	if Clone(a) != nil {
+		t.Fatal("expected the cyclic tree not to be cloned")
+	}
+}
+
+func TestInjectManyIsAtomic(t *testing.T) {
+	root := NewTermsAggregation().Field("root")
+	child := NewTermsAggregation().Field("child")
+	if err := root.Inject(child, "child"); err != nil {
+		t.Fatal(err)
+	}
+	want := root.String()
+
+	tests := []struct {
+		name       string
+		subs       map[string]Aggregation
+		parentPath []string
+		err        error
+	}{
+		{"empty name", map[string]Aggregation{"a": NewMaxAggregation().Field("a"), "": NewMaxAggregation()}, nil, ErrNoPath},
+		{"nil sub", map[string]Aggregation{"a": NewMaxAggregation().Field("a"), "b": nil}, nil, ErrNilAggregation},
+		{"taken name", map[string]Aggregation{"a": NewMaxAggregation().Field("a"), "child": NewMaxAggregation()}, nil, ErrPathAlreadyExists},
+		{"cycle at the root", map[string]Aggregation{"a": NewMaxAggregation().Field("a"), "loop": root}, nil, ErrCycleDetected},
+		{"cycle at the path", map[string]Aggregation{"a": NewMaxAggregation().Field("a"), "loop": root}, []string{"child"}, ErrCycleDetected},
+		{"missing path", map[string]Aggregation{"a": NewMaxAggregation().Field("a")}, []string{"missing"}, ErrPathNotSelectable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := root.InjectMany(test.subs, test.parentPath...); err != test.err {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+			if root.String() != want {
+				t.Fatalf("expected the tree to stay unchanged, got %s", root)
+			}
+		})
+	}
+
+	if err := child.InjectMany(map[string]Aggregation{"loop": root}); err != ErrCycleDetected {
+		t.Fatalf("expected ErrCycleDetected injecting the root under its child, got %v", err)
+	}
+
+	err := root.InjectMany(map[string]Aggregation{
+		"max": NewMaxAggregation().Field("x"),
+		"min": NewMinAggregation().Field("x"),
+	}, "child")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Select("child", "max") == nil || root.Select("child", "min") == nil {
+		t.Fatalf("expected both subs injected, got %s", root)
+	}
+}
+
+func TestInjectStrictDetectsOverwrites(t *testing.T) {
+	root := NewTermsAggregation().Field("a").
+		SubAggregation("a", NewTermsAggregation().Field("b").SubAggregation("b", NewAvgAggregation().Field("x")))
+
+	tests := []struct {
+		name string
+		path []string
+		want error
+	}{
+		{"shallow", []string{"a"}, ErrPathAlreadyExists},
+		{"deep", []string{"a", "b"}, ErrPathAlreadyExists},
+		{"free", []string{"a", "c"}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := root.Select(test.path...)
+			err := root.InjectStrict(NewSumAggregation().Field("y"), test.path...)
+			if err != test.want {
+				t.Fatalf("expected %v, got %v", test.want, err)
+			}
+			if err != nil && root.Select(test.path...) != before {
+				t.Fatal("expected the existing aggregation kept")
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	agg := NewTermsAggregation().Field("user").SubAggregation("avg", NewAvgAggregation().Field("age"))
+
+	rendered, err := Render("users", agg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"users":{"aggregations":{"avg":{"avg":{"field":"age"}}},"terms":{"field":"user"}}}`
+	if string(data) != want {
+		t.Fatalf("expected %s, got %s", want, data)
+	}
+}
+
+func TestForEachSubStopsAtFirstError(t *testing.T) {
+	agg := NewTermsAggregation().Field("user").
+		SubAggregation("c", NewAvgAggregation().Field("x")).
+		SubAggregation("a", NewAvgAggregation().Field("x")).
+		SubAggregation("b", NewAvgAggregation().Field("x"))
+
+	stop := errors.New("stop")
+	var seen []string
+	err := agg.ForEachSub(func(name string, sub Aggregation) error {
+		seen = append(seen, name)
+		if name == "b" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Fatalf("expected %v, got %v", stop, err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(seen, want) {
+		t.Fatalf("expected %v, got %v", want, seen)
+	}
+}
+
+func TestForEachSubSkipsRemovedSubs(t *testing.T) {
+	agg := NewTermsAggregation().Field("user").
+		SubAggregation("a", NewAvgAggregation().Field("x")).
+		SubAggregation("b", NewAvgAggregation().Field("x")).
+		SubAggregation("c", NewAvgAggregation().Field("x"))
+
+	var seen []string
+	err := agg.ForEachSub(func(name string, sub Aggregation) error {
+		seen = append(seen, name)
+		agg.Pop("b")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "c"}; !reflect.DeepEqual(seen, want) {
+		t.Fatalf("expected %v, got %v", want, seen)
+	}
+}
+
+func TestSubAggregationNames(t *testing.T) {
+	agg := NewTermsAggregation().Field("user").
+		SubAggregation("c", NewAvgAggregation().Field("x")).
+		SubAggregation("a", NewTermsAggregation().Field("y").SubAggregation("deep", NewAvgAggregation().Field("x"))).
+		SubAggregation("b", NewAvgAggregation().Field("x"))
+
+	if want, got := []string{"a", "b", "c"}, agg.SubAggregationNames(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := NewAvgAggregation().SubAggregationNames(); got == nil || len(got) != 0 {
+		t.Fatalf("expected an empty list, got %#v", got)
+	}
+
+	aggs := Aggregations{"y": agg, "x": NewAvgAggregation().Field("x")}
+	if want, got := []string{"x", "y"}, aggs.SubAggregationNames(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestInjectRejectsNilAggregations(t *testing.T) {
+	root := NewTermsAggregation().Field("user")
+	aggs := Aggregations{}
+	var typedNil *AvgAggregation
+
+	tests := []struct {
+		name   string
+		inject func() error
+	}{
+		{"inject", func() error { return root.Inject(nil, "x") }},
+		{"typed nil", func() error { return root.Inject(typedNil, "x") }},
+		{"strict", func() error { return root.InjectStrict(nil, "x") }},
+		{"many", func() error { return root.InjectMany(map[string]Aggregation{"x": nil}) }},
+		{"select or create", func() error {
+			_, err := root.SelectOrCreate(func() Aggregation { return nil }, "x")
+			return err
+		}},
+		{"aggregations", func() error { return aggs.Inject(nil, "x") }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.inject(); err != ErrNilAggregation {
+				t.Fatalf("expected %v, got %v", ErrNilAggregation, err)
+			}
+			if len(root.GetAllSubs()) != 0 || len(aggs) != 0 {
+				t.Fatal("expected nothing injected")
+			}
+		})
+	}
+}
+
+func TestSourceSkipsNilSubAggregations(t *testing.T) {
+	agg := NewTermsAggregation().Field("user").SubAggregation("x", nil)
+	agg.GetAllSubs()["y"] = nil
+	agg.GetAllSubs()["avg"] = NewAvgAggregation().Field("age")
+
+	want := `{"aggregations":{"avg":{"avg":{"field":"age"}}},"terms":{"field":"user"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	var buf bytes.Buffer
+	if err := agg.WriteSource(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want {
+		t.Fatalf("expected %s, got %s", want, buf.String())
+	}
+}
+
+func TestAggregationTypeMatchesSource(t *testing.T) {
+	cases := snapshotCases()
+	cases["wrapped"] = Wrap("avg", NewAvgAggregation().Field("x"))
+
+	for name, agg := range cases {
+		t.Run(name, func(t *testing.T) {
+			src, err := agg.Source()
+			if err != nil {
+				t.Fatal(err)
+			}
+			source, ok := src.(map[string]interface{})
+			if !ok {
+				t.Fatalf("expected an object, got %T", src)
+			}
+			if _, ok := source[agg.AggregationType()]; !ok {
+				t.Fatalf("expected the %q key in %s", agg.AggregationType(), agg)
+			}
+		})
+	}
+}
+
+func TestFindByAggregationType(t *testing.T) {
+	root := NewTermsAggregation().Field("user").
+		SubAggregation("total", NewSumAggregation().Field("x")).
+		SubAggregation("by_day", NewTermsAggregation().Field("day").SubAggregation("day_total", NewSumAggregation().Field("x")))
+
+	tests := []struct {
+		typeName string
+		want     []string
+	}{
+		{"sum", []string{"by_day>day_total", "total"}},
+		{"SumAggregation", []string{"by_day>day_total", "total"}},
+		{"terms", []string{"by_day"}},
+		{"avg", []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.typeName, func(t *testing.T) {
+			found := FindByType(root, test.typeName)
+			got := make([]string, 0, len(found))
+			for path := range found {
+				got = append(got, path)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+// failingSource is a source failing with err
+type failingSource struct {
+	err error
+}
+
+func (f failingSource) Source() (interface{}, error) {
+	return nil, f.err
+}
+
+func TestSourceErrorsNameTheSubAggregation(t *testing.T) {
+	failure := errors.New("bad script")
+	root := NewTermsAggregation().Field("user").
+		SubAggregation("ok", NewAvgAggregation().Field("x")).
+		SubAggregation("outer", NewTermsAggregation().Field("day").
+			SubAggregation("inner", Wrap("inner", failingSource{failure})))
+
+	want := `aggregation "outer": aggregation "inner": bad script`
+	_, err := root.Source()
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected %s, got %v", want, err)
+	}
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the error to wrap %v", failure)
+	}
+
+	var buf bytes.Buffer
+	err = root.WriteSource(&buf)
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected %s, got %v", want, err)
+	}
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the error to wrap %v", failure)
+	}
+}
+
+func TestNewAggregationsAdd(t *testing.T) {
+	byDay := NewDateHistogramAggregation().Field("date").Interval("1d")
+	totals := NewSumAggregation().Field("price")
+	aggs := NewAggregations().Add("by_day", byDay).Add("totals", totals).Add("none", nil)
+
+	if want, got := []string{"by_day", "totals"}, aggs.SubAggregationNames(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if byDay.GetName() != "by_day" {
+		t.Fatalf("expected the name %q, got %q", "by_day", byDay.GetName())
+	}
+
+	exported := aggs.Export()
+	want := map[string]string{
+		"by_day": `{"date_histogram":{"field":"date","interval":"1d"}}`,
+		"totals": `{"sum":{"field":"price"}}`,
+	}
+	if len(exported) != len(want) {
+		t.Fatalf("expected %d aggregations, got %d", len(want), len(exported))
+	}
+	for name, agg := range want {
+		src, err := exported[name].Source()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != agg {
+			t.Fatalf("%s: expected %s, got %s", name, agg, data)
+		}
+	}
+
+	var empty Aggregations
+	if empty = empty.Add("totals", totals); len(empty) != 1 {
+		t.Fatalf("expected the nil map to be made, got %v", empty)
+	}
+}
+
+func TestApplyTo(t *testing.T) {
+	aggs := map[string]Aggregation{
+		"users":  NewTermsAggregation().Field("user"),
+		"totals": NewSumAggregation().Field("price"),
+		"none":   nil,
+	}
+
+	if exported := ExportAll(aggs); len(exported) != 2 {
+		t.Fatalf("expected 2 exported aggregations, got %d", len(exported))
+	}
+
+	searchSource := elastic.NewSearchSource()
+	ApplyTo(elastic.NewSearchService(nil).SearchSource(searchSource), aggs)
+	src, err := searchSource.Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(src.(map[string]interface{})["aggregations"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"totals":{"sum":{"field":"price"}},"users":{"terms":{"field":"user"}}}`
+	if string(data) != want {
+		t.Fatalf("expected %s, got %s", want, data)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	root := NewTermsAggregation().Field("user").
+		SubAggregation("b", NewTermsAggregation().Field("b").SubAggregation("x", NewAvgAggregation().Field("x"))).
+		SubAggregation("a", NewTermsAggregation().Field("a").
+			SubAggregation("y", NewTermsAggregation().Field("y").SubAggregation("z", NewAvgAggregation().Field("z"))))
+
+	var seen []string
+	root.Walk(func(path []string, agg Aggregation) bool {
+		seen = append(seen, strings.Join(path, PathSeparator))
+		return strings.Join(path, PathSeparator) != "a>y"
+	})
+
+	if want := []string{"a", "a>y", "b", "b>x"}; !reflect.DeepEqual(seen, want) {
+		t.Fatalf("expected %v, got %v", want, seen)
+	}
+}
+
+func TestInjectReplace(t *testing.T) {
+	root := NewTermsAggregation().Field("user")
+	first := NewAvgAggregation().Field("x")
+
+	old, err := root.InjectReplace(first, "metric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old != nil {
+		t.Fatalf("expected nothing replaced, got %s", old)
+	}
+
+	old, err = root.InjectReplace(NewSumAggregation().Field("x"), "metric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old != first {
+		t.Fatalf("expected the replaced aggregation returned, got %v", old)
+	}
+	if _, ok := root.Select("metric").(*SumAggregation); !ok {
+		t.Fatalf("expected the new aggregation injected, got %T", root.Select("metric"))
+	}
+}
+
+func TestAddSubAggregationRejectsTakenNames(t *testing.T) {
+	root := NewTermsAggregation().Field("user")
+	first := NewAvgAggregation().Field("x")
+
+	if err := root.AddSubAggregation("metric", first); err != nil {
+		t.Fatal(err)
+	}
+	if err := root.AddSubAggregation("metric", NewSumAggregation().Field("x")); err != ErrPathAlreadyExists {
+		t.Fatalf("expected %v, got %v", ErrPathAlreadyExists, err)
+	}
+	if root.Select("metric") != first {
+		t.Fatal("expected the first aggregation kept")
+	}
+}
+
+func TestSourceIsBoundedByMaxAggregationDepth(t *testing.T) {
+	defer func(depth int) { MaxAggregationDepth = depth }(MaxAggregationDepth)
+	MaxAggregationDepth = 3
+
+	chain := func(depth int) Aggregation {
+		root := NewTermsAggregation().Field("level")
+		cursor := root
+		for i := 0; i < depth; i++ {
+			sub := NewTermsAggregation().Field("level")
+			cursor.SubAggregation("sub", sub)
+			cursor = sub
+		}
+		return root
+	}
+
+	if _, err := chain(3).Source(); err != nil {
+		t.Fatalf("expected the tree of the max depth rendered, got %v", err)
+	}
+	if _, err := chain(4).Source(); err != ErrMaxDepthExceeded {
+		t.Fatalf("expected %v, got %v", ErrMaxDepthExceeded, err)
+	}
+}
+
+func TestGetName(t *testing.T) {
+	root := NewTermsAggregation().Field("user")
+	max := NewMaxAggregation().Field("x")
+	derivative := NewDerivativeAggregation().BucketsPath("max")
+	root.SubAggregation("max", max)
+	if err := root.Inject(derivative, "change"); err != nil {
+		t.Fatal(err)
+	}
+
+	if max.GetName() != "max" || derivative.GetName() != "change" {
+		t.Fatalf("expected the injected names, got %q and %q", max.GetName(), derivative.GetName())
+	}
+	if root.GetName() != "" {
+		t.Fatalf("expected no name of the root, got %q", root.GetName())
+	}
+
+	aggs := Aggregations{}
+	if err := aggs.Inject(root, "users"); err != nil {
+		t.Fatal(err)
+	}
+	clone := Clone(root)
+	if root.GetName() != "users" {
+		t.Fatalf("expected the name %q, got %q", "users", root.GetName())
+	}
+	if clone.GetName() != "" {
+		t.Fatalf("expected the clone not to be named, got %q", clone.GetName())
+	}
+	if clone.Select("max").GetName() != "max" {
+		t.Fatalf("expected the names of the cloned subAggregations kept, got %q", clone.Select("max").GetName())
+	}
+}
+
+func TestSourceRendersSubAggregationsSorted(t *testing.T) {
+	agg := NewTermsAggregation().Field("user").
+		SubAggregation("z", NewMaxAggregation().Field("x")).
+		SubAggregation("a", NewMinAggregation().Field("x")).
+		SubAggregation("m", NewAvgAggregation().Field("x"))
+
+	want := `{"aggregations":{"a":{"min":{"field":"x"}},"m":{"avg":{"field":"x"}},"z":{"max":{"field":"x"}}},"terms":{"field":"user"}}`
+	for i := 0; i < 10; i++ {
+		if got := marshalSource(t, agg); got != want {
+			t.Fatalf("expected %s, got %s", want, got)
+		}
+	}
+}
+
+func TestParentOf(t *testing.T) {
+	root := NewGlobalAggregation()
+	byDay := NewHistogramAggregation().Field("day").Interval(1)
+	root.SubAggregation("by_day", byDay)
+	byDay.SubAggregation("max", NewMaxAggregation().Field("x"))
+
+	parent, path := root.ParentOf("by_day", "max")
+	if parent != byDay {
+		t.Fatalf("expected the parent %s, got %v", byDay, parent)
+	}
+	if want := []string{"by_day"}; !reflect.DeepEqual(path, want) {
+		t.Fatalf("expected %v, got %v", want, path)
+	}
+
+	for _, path := range [][]string{{"by_day"}, {"by_day", "min"}, {}} {
+		if parent, _ := root.ParentOf(path...); parent != nil {
+			t.Errorf("%v: expected no parent, got %v", path, parent)
+		}
+	}
+}
+
+func TestAggregationsWalk(t *testing.T) {
+	aggs := Aggregations{
+		"b": NewTermsAggregation().Field("b").SubAggregation("x", NewAvgAggregation().Field("x")),
+		"a": NewTermsAggregation().Field("a").
+			SubAggregation("y", NewTermsAggregation().Field("y").SubAggregation("z", NewAvgAggregation().Field("z"))),
+	}
+
+	var seen []string
+	aggs.Walk(func(path []string, agg Aggregation) bool {
+		seen = append(seen, strings.Join(path, PathSeparator))
+		return strings.Join(path, PathSeparator) != "a>y"
+	})
+	if want := []string{"a", "a>y", "b", "b>x"}; !reflect.DeepEqual(seen, want) {
+		t.Fatalf("expected %v, got %v", want, seen)
+	}
+
+	var empty *Aggregations
+	empty.Walk(func([]string, Aggregation) bool {
+		t.Fatal("expected no calls for nil Aggregations")
+		return true
+	})
+}
+
+func TestInjectDeep(t *testing.T) {
+	root := NewTermsAggregation().Field("user").SubAggregation("a", NewTermsAggregation().Field("a"))
+	terms := func(name string) Aggregation { return NewTermsAggregation().Field(name) }
+
+	if err := root.InjectDeep(terms, NewAvgAggregation().Field("x"), "a", "b", "c", "avg"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "a>b", "a>b>c", "a>b>c>avg"}; !reflect.DeepEqual(root.ListPaths(), want) {
+		t.Fatalf("expected %v, got %v", want, root.ListPaths())
+	}
+	if name := root.Select("a", "b", "c").GetName(); name != "c" {
+		t.Fatalf("expected the created parent named %q, got %q", "c", name)
+	}
+
+	root.SubAggregation("hits", NewTopHitsAggregation())
+	metric := func(name string) Aggregation { return NewAvgAggregation().Field(name) }
+
+	tests := []struct {
+		name    string
+		factory func(name string) Aggregation
+		subAgg  Aggregation
+		path    []string
+		want    error
+	}{
+		{"metric factory", metric, NewAvgAggregation().Field("x"), []string{"x", "y", "z"}, ErrAggIsNotInjectable},
+		{"not injectable parent", terms, NewAvgAggregation().Field("x"), []string{"hits", "y", "z"}, ErrAggIsNotInjectable},
+		{"cycle", terms, root, []string{"n", "m"}, ErrCycleDetected},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := root.InjectDeep(test.factory, test.subAgg, test.path...); err != test.want {
+				t.Fatalf("expected %v, got %v", test.want, err)
+			}
+			if want := []string{"a", "a>b", "a>b>c", "a>b>c>avg", "hits"}; !reflect.DeepEqual(root.ListPaths(), want) {
+				t.Fatalf("expected the tree unchanged, got %v", root.ListPaths())
+			}
+		})
+	}
+}
+
+func TestSelectOrCreate(t *testing.T) {
+	root := NewTermsAggregation().Field("user")
+	created := 0
+	factory := func() Aggregation {
+		created++
+		return NewTermsAggregation().Field("day")
+	}
+
+	first, err := root.SelectOrCreate(factory, "by_day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := root.SelectOrCreate(factory, "by_day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second || created != 1 {
+		t.Fatalf("expected the created aggregation selected the second time, created %d", created)
+	}
+
+	if _, err := root.SelectOrCreate(factory, "missing", "by_day"); err != ErrPathNotSelectable {
+		t.Fatalf("expected %v, got %v", ErrPathNotSelectable, err)
+	}
+}
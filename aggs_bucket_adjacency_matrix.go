@@ -1,6 +1,9 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // AdjacencyMatrixAggregation returning a form of adjacency matrix.
 // The request provides a collection of named filter expressions,
@@ -11,9 +14,10 @@ import "github.com/olivere/elastic"
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-adjacency-matrix-aggregation.html
 type AdjacencyMatrixAggregation struct {
 	*tree
+	metaHolder
 
-	filters map[string]elastic.Query
-	meta    map[string]interface{}
+	filters   map[string]elastic.Query
+	separator string
 }
 
 // NewAdjacencyMatrixAggregation initializes a new AdjacencyMatrixAggregation.
@@ -27,23 +31,57 @@ func NewAdjacencyMatrixAggregation() *AdjacencyMatrixAggregation {
 // Filters adds the filter
 func (a *AdjacencyMatrixAggregation) Filters(name string, filter elastic.Query) *AdjacencyMatrixAggregation {
 	a.filters[name] = filter
+	a.markDirty()
+	return a
+}
+
+// Separator sets the string used to join the filter names of an
+// intersection bucket key. Elasticsearch uses "&" by default.
+func (a *AdjacencyMatrixAggregation) Separator(separator string) *AdjacencyMatrixAggregation {
+	a.separator = separator
+	a.markDirty()
 	return a
 }
 
 // SubAggregation adds a sub-aggregation to this aggregation.
 func (a *AdjacencyMatrixAggregation) SubAggregation(name string, subAggregation Aggregation) *AdjacencyMatrixAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *AdjacencyMatrixAggregation) Meta(metaData map[string]interface{}) *AdjacencyMatrixAggregation {
 	a.meta = metaData
+	a.markDirty()
 	return a
 }
 
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *AdjacencyMatrixAggregation) AddMeta(key string, value interface{}) *AdjacencyMatrixAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *AdjacencyMatrixAggregation) AggregationType() string {
+	return "adjacency_matrix"
+}
+
+func (a *AdjacencyMatrixAggregation) Validate() error {
+	if len(a.filters) == 0 {
+		return errors.New("elastic: AdjacencyMatrixAggregation requires at least one filter")
+	}
+
+	return nil
+}
+
 // Source returns the a JSON-serializable interface.
 func (a *AdjacencyMatrixAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *AdjacencyMatrixAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//  "aggs" : {
@@ -73,23 +111,17 @@ func (a *AdjacencyMatrixAggregation) Source() (interface{}, error) {
 	}
 	adjacencyMatrix["filters"] = dict
 
+	if a.separator != "" {
+		adjacencyMatrix["separator"] = a.separator
+	}
+
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -0,0 +1,24 @@
+package aggretastic
+
+import (
+	"github.com/olivere/elastic"
+	"testing"
+)
+
+func TestAdjacencyMatrixAggregationSeparator(t *testing.T) {
+	agg := NewAdjacencyMatrixAggregation().
+		Filters("grpB", elastic.NewTermQuery("accounts", "c")).
+		Filters("grpA", elastic.NewTermQuery("accounts", "a")).
+		SubAggregation("docs", NewValueCountAggregation().Field("id"))
+
+	want := `{"adjacency_matrix":{"filters":{"grpA":{"term":{"accounts":"a"}},"grpB":{"term":{"accounts":"c"}}}},"aggregations":{"docs":{"value_count":{"field":"id"}}}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	agg.Separator("|")
+	want = `{"adjacency_matrix":{"filters":{"grpA":{"term":{"accounts":"a"}},"grpB":{"term":{"accounts":"c"}}},"separator":"|"},"aggregations":{"docs":{"value_count":{"field":"id"}}}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
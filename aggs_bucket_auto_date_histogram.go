@@ -0,0 +1,197 @@
+package aggretastic
+
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
+
+// AutoDateHistogramAggregation is a multi-bucket aggregation similar to the
+// date histogram except instead of providing an interval to use as the width
+// of each bucket, a target number of buckets is provided indicating the number
+// of buckets needed and the interval of the buckets is automatically chosen
+// to best achieve that target.
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/6.5/search-aggregations-bucket-autodatehistogram-aggregation.html
+type AutoDateHistogramAggregation struct {
+	*tree
+	metaHolder
+
+	field   string
+	script  *elastic.Script
+	missing interface{}
+
+	buckets         *int
+	minimumInterval string
+	timeZone        string
+	format          string
+}
+
+// NewAutoDateHistogramAggregation creates a new AutoDateHistogramAggregation.
+func NewAutoDateHistogramAggregation() *AutoDateHistogramAggregation {
+	a := &AutoDateHistogramAggregation{}
+	a.tree = nilAggregationTree(a)
+
+	return a
+}
+
+// Field on which the aggregation is processed.
+func (a *AutoDateHistogramAggregation) Field(field string) *AutoDateHistogramAggregation {
+	a.field = field
+	a.markDirty()
+	return a
+}
+
+// GetField returns the field the aggregation works on.
+func (a *AutoDateHistogramAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *AutoDateHistogramAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
+func (a *AutoDateHistogramAggregation) Script(script *elastic.Script) *AutoDateHistogramAggregation {
+	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *AutoDateHistogramAggregation) StoredScript(id string, params map[string]interface{}) *AutoDateHistogramAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
+	return a
+}
+
+// Missing configures the value to use when documents miss a value.
+func (a *AutoDateHistogramAggregation) Missing(missing interface{}) *AutoDateHistogramAggregation {
+	a.missing = missing
+	a.markDirty()
+	return a
+}
+
+func (a *AutoDateHistogramAggregation) SubAggregation(name string, subAggregation Aggregation) *AutoDateHistogramAggregation {
+	a.setSub(name, subAggregation)
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *AutoDateHistogramAggregation) Meta(metaData map[string]interface{}) *AutoDateHistogramAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *AutoDateHistogramAggregation) AddMeta(key string, value interface{}) *AutoDateHistogramAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// Buckets sets the target number of buckets. Elasticsearch picks the
+// interval that best achieves it. Defaults to 10.
+func (a *AutoDateHistogramAggregation) Buckets(buckets int) *AutoDateHistogramAggregation {
+	a.buckets = &buckets
+	a.markDirty()
+	return a
+}
+
+// MinimumInterval sets the smallest interval Elasticsearch may choose.
+// Allowed values are: "year", "month", "day", "hour", "minute", "second".
+func (a *AutoDateHistogramAggregation) MinimumInterval(interval string) *AutoDateHistogramAggregation {
+	a.minimumInterval = interval
+	a.markDirty()
+	return a
+}
+
+// TimeZone sets the timezone in which to translate dates before computing buckets.
+func (a *AutoDateHistogramAggregation) TimeZone(timeZone string) *AutoDateHistogramAggregation {
+	a.timeZone = timeZone
+	a.markDirty()
+	return a
+}
+
+// Format sets the format to use for dates.
+func (a *AutoDateHistogramAggregation) Format(format string) *AutoDateHistogramAggregation {
+	a.format = format
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *AutoDateHistogramAggregation) AggregationType() string {
+	return "auto_date_histogram"
+}
+
+func (a *AutoDateHistogramAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: AutoDateHistogramAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
+func (a *AutoDateHistogramAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *AutoDateHistogramAggregation) source(depth int) (interface{}, error) {
+	// Example:
+	// {
+	//     "aggs" : {
+	//         "sales_over_time" : {
+	//             "auto_date_histogram" : {
+	//                 "field" : "date",
+	//                 "buckets" : 10
+	//             }
+	//         }
+	//     }
+	// }
+	//
+	// This method returns only the { "auto_date_histogram" : { ... } } part.
+
+	source := make(map[string]interface{})
+	opts := make(map[string]interface{})
+	source["auto_date_histogram"] = opts
+
+	// ValuesSourceAggregationBuilder
+	if a.field != "" {
+		opts["field"] = a.field
+	}
+	if a.script != nil {
+		src, err := a.script.Source()
+		if err != nil {
+			return nil, err
+		}
+		opts["script"] = src
+	}
+	if a.missing != nil {
+		opts["missing"] = a.missing
+	}
+
+	if a.buckets != nil {
+		opts["buckets"] = *a.buckets
+	}
+	if a.minimumInterval != "" {
+		opts["minimum_interval"] = a.minimumInterval
+	}
+	if a.timeZone != "" {
+		opts["time_zone"] = a.timeZone
+	}
+	if a.format != "" {
+		opts["format"] = a.format
+	}
+
+	// AggregationBuilder (SubAggregations)
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
@@ -0,0 +1,32 @@
+package aggretastic
+
+import "testing"
+
+func TestAutoDateHistogramAggregation(t *testing.T) {
+	tests := []struct {
+		interval string
+		want     string
+	}{
+		{"minute", `{"aggregations":{"max":{"max":{"field":"price"}}},"auto_date_histogram":{"buckets":10,"field":"date","minimum_interval":"minute"}}`},
+		{"hour", `{"aggregations":{"max":{"max":{"field":"price"}}},"auto_date_histogram":{"buckets":10,"field":"date","minimum_interval":"hour"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.interval, func(t *testing.T) {
+			agg := NewAutoDateHistogramAggregation().Field("date").Buckets(10).MinimumInterval(test.interval).
+				SubAggregation("max", NewMaxAggregation().Field("price"))
+			if agg.String() != test.want {
+				t.Fatalf("expected %s, got %s", test.want, agg)
+			}
+		})
+	}
+}
+
+func TestAutoDateHistogramAggregationOmitsUnsetBuckets(t *testing.T) {
+	agg := NewAutoDateHistogramAggregation().Field("date").TimeZone("UTC").Format("yyyy-MM-dd").Missing("2020-01-01")
+
+	want := `{"auto_date_histogram":{"field":"date","format":"yyyy-MM-dd","missing":"2020-01-01","time_zone":"UTC"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
@@ -0,0 +1,81 @@
+package aggretastic
+
+// ExtendedBounds forces the histogram aggregations to return (possibly empty) buckets
+// from Min to Max even if there are no documents there. Either of them may be nil.
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-histogram-aggregation.html#search-aggregations-bucket-histogram-aggregation-extended-bounds
+type ExtendedBounds struct {
+	Min interface{}
+	Max interface{}
+}
+
+// Source returns serializable JSON of the bounds, nil members are omitted.
+func (b *ExtendedBounds) Source() (interface{}, error) {
+	return boundsSource(b.Min, b.Max), nil
+}
+
+// withMin returns a copy of the bounds (b may be nil) with the given Min.
+// Setters of the aggregations never change the bounds in place,
+// as the bounds are shared by the clones of the aggregation.
+func (b *ExtendedBounds) withMin(min interface{}) *ExtendedBounds {
+	c := ExtendedBounds{}
+	if b != nil {
+		c = *b
+	}
+	c.Min = min
+	return &c
+}
+
+// withMax returns a copy of the bounds (b may be nil) with the given Max
+func (b *ExtendedBounds) withMax(max interface{}) *ExtendedBounds {
+	c := ExtendedBounds{}
+	if b != nil {
+		c = *b
+	}
+	c.Max = max
+	return &c
+}
+
+// HardBounds limits the range of buckets of the histogram aggregations to Min - Max.
+// Either of them may be nil.
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/7.10/search-aggregations-bucket-histogram-aggregation.html#search-aggregations-bucket-histogram-aggregation-hard-bounds
+type HardBounds struct {
+	Min interface{}
+	Max interface{}
+}
+
+// Source returns serializable JSON of the bounds, nil members are omitted.
+func (b *HardBounds) Source() (interface{}, error) {
+	return boundsSource(b.Min, b.Max), nil
+}
+
+// withMin returns a copy of the bounds (b may be nil) with the given Min
+func (b *HardBounds) withMin(min interface{}) *HardBounds {
+	c := HardBounds{}
+	if b != nil {
+		c = *b
+	}
+	c.Min = min
+	return &c
+}
+
+// withMax returns a copy of the bounds (b may be nil) with the given Max
+func (b *HardBounds) withMax(max interface{}) *HardBounds {
+	c := HardBounds{}
+	if b != nil {
+		c = *b
+	}
+	c.Max = max
+	return &c
+}
+
+func boundsSource(min, max interface{}) map[string]interface{} {
+	source := make(map[string]interface{})
+	if min != nil {
+		source["min"] = min
+	}
+	if max != nil {
+		source["max"] = max
+	}
+
+	return source
+}
@@ -0,0 +1,38 @@
+package aggretastic
+
+import "testing"
+
+func TestBoundsWithMinOnly(t *testing.T) {
+	tests := []struct {
+		name string
+		agg  Aggregation
+		want string
+	}{
+		{"histogram extended", NewHistogramAggregation().Field("p").Interval(1).ExtendedBoundsMin(5),
+			`{"histogram":{"extended_bounds":{"min":5},"field":"p","interval":1}}`},
+		{"histogram hard", NewHistogramAggregation().Field("p").Interval(1).HardBoundsOf(&HardBounds{Min: 5}),
+			`{"histogram":{"field":"p","hard_bounds":{"min":5},"interval":1}}`},
+		{"date histogram extended", NewDateHistogramAggregation().Field("d").Interval("1d").ExtendedBoundsOf(&ExtendedBounds{Min: "now-7d/d"}),
+			`{"date_histogram":{"extended_bounds":{"min":"now-7d/d"},"field":"d","interval":"1d","min_doc_count":0}}`},
+		{"date histogram hard", NewDateHistogramAggregation().Field("d").Interval("1d").HardBoundsMax("now"),
+			`{"date_histogram":{"field":"d","hard_bounds":{"max":"now"},"interval":"1d"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.agg.String() != test.want {
+				t.Fatalf("expected %s, got %s", test.want, test.agg)
+			}
+		})
+	}
+}
+
+func TestBoundsAreNotSharedWithClones(t *testing.T) {
+	agg := NewHistogramAggregation().Field("p").Interval(1).ExtendedBoundsMin(5)
+	Clone(agg).(*HistogramAggregation).ExtendedBoundsMax(9)
+
+	want := `{"histogram":{"extended_bounds":{"min":5},"field":"p","interval":1}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
@@ -1,14 +1,16 @@
 package aggretastic
 
+import "errors"
+
 // ChildrenAggregation is a special single bucket aggregation that enables
 // aggregating from buckets on parent document types to buckets on child documents.
 // It is available from 1.4.0.Beta1 upwards.
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-children-aggregation.html
 type ChildrenAggregation struct {
 	*tree
+	metaHolder
 
-	typ  string
-	meta map[string]interface{}
+	typ string
 }
 
 func NewChildrenAggregation() *ChildrenAggregation {
@@ -20,21 +22,47 @@ func NewChildrenAggregation() *ChildrenAggregation {
 
 func (a *ChildrenAggregation) Type(typ string) *ChildrenAggregation {
 	a.typ = typ
+	a.markDirty()
 	return a
 }
 
 func (a *ChildrenAggregation) SubAggregation(name string, subAggregation Aggregation) *ChildrenAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *ChildrenAggregation) Meta(metaData map[string]interface{}) *ChildrenAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *ChildrenAggregation) AddMeta(key string, value interface{}) *ChildrenAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *ChildrenAggregation) AggregationType() string {
+	return "children"
+}
+
+func (a *ChildrenAggregation) Validate() error {
+	if a.typ == "" {
+		return errors.New("elastic: ChildrenAggregation requires a type")
+	}
+
+	return nil
+}
+
 func (a *ChildrenAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *ChildrenAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -53,22 +81,12 @@ func (a *ChildrenAggregation) Source() (interface{}, error) {
 	opts["type"] = a.typ
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -1,6 +1,10 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"fmt"
+	"github.com/olivere/elastic"
+)
 
 // CompositeAggregation is a multi-bucket values source based aggregation
 // that can be used to calculate unique composite values from source documents.
@@ -9,11 +13,11 @@ import "github.com/olivere/elastic"
 // for details.
 type CompositeAggregation struct {
 	*tree
+	metaHolder
 
 	after   map[string]interface{}
 	size    *int
 	sources []CompositeAggregationValuesSource
-	meta    map[string]interface{}
 }
 
 // NewCompositeAggregation creates a new CompositeAggregation.
@@ -28,6 +32,7 @@ func NewCompositeAggregation() *CompositeAggregation {
 // Defaults to 10 as of Elasticsearch 6.1.
 func (a *CompositeAggregation) Size(size int) *CompositeAggregation {
 	a.size = &size
+	a.markDirty()
 	return a
 }
 
@@ -35,6 +40,7 @@ func (a *CompositeAggregation) Size(size int) *CompositeAggregation {
 // request should "aggregate after".
 func (a *CompositeAggregation) AggregateAfter(after map[string]interface{}) *CompositeAggregation {
 	a.after = after
+	a.markDirty()
 	return a
 }
 
@@ -42,23 +48,49 @@ func (a *CompositeAggregation) AggregateAfter(after map[string]interface{}) *Com
 // use in the aggregation.
 func (a *CompositeAggregation) Sources(sources ...CompositeAggregationValuesSource) *CompositeAggregation {
 	a.sources = append(a.sources, sources...)
+	a.markDirty()
 	return a
 }
 
 // SubAggregations of this aggregation.
 func (a *CompositeAggregation) SubAggregation(name string, subAggregation Aggregation) *CompositeAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *CompositeAggregation) Meta(metaData map[string]interface{}) *CompositeAggregation {
 	a.meta = metaData
+	a.markDirty()
 	return a
 }
 
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *CompositeAggregation) AddMeta(key string, value interface{}) *CompositeAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *CompositeAggregation) AggregationType() string {
+	return "composite"
+}
+
+func (a *CompositeAggregation) Validate() error {
+	if len(a.sources) == 0 {
+		return errors.New("elastic: CompositeAggregation requires at least one source")
+	}
+
+	return nil
+}
+
 // Source returns the serializable JSON for this aggregation.
 func (a *CompositeAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *CompositeAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	// {
 	//     "aggs" : {
@@ -101,22 +133,12 @@ func (a *CompositeAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -141,12 +163,13 @@ type CompositeAggregationValuesSource interface {
 // See https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-composite-aggregation.html#_terms
 // for details.
 type CompositeAggregationTermsValuesSource struct {
-	name      string
-	field     string
-	script    *elastic.Script
-	valueType string
-	missing   interface{}
-	order     string
+	name          string
+	field         string
+	script        *elastic.Script
+	valueType     string
+	missing       interface{}
+	order         string
+	missingBucket bool
 }
 
 // NewCompositeAggregationTermsValuesSource creates and initializes
@@ -169,6 +192,13 @@ func (a *CompositeAggregationTermsValuesSource) Script(script *elastic.Script) *
 	return a
 }
 
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *CompositeAggregationTermsValuesSource) StoredScript(id string, params map[string]interface{}) *CompositeAggregationTermsValuesSource {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	return a
+}
+
 // ValueType specifies the type of values produced by this source,
 // e.g. "string" or "date".
 func (a *CompositeAggregationTermsValuesSource) ValueType(valueType string) *CompositeAggregationTermsValuesSource {
@@ -202,6 +232,13 @@ func (a *CompositeAggregationTermsValuesSource) Missing(missing interface{}) *Co
 	return a
 }
 
+// MissingBucket includes the documents without a value for the source
+// as a bucket with the null key.
+func (a *CompositeAggregationTermsValuesSource) MissingBucket(missingBucket bool) *CompositeAggregationTermsValuesSource {
+	a.missingBucket = missingBucket
+	return a
+}
+
 // Source returns the serializable JSON for this values source.
 func (a *CompositeAggregationTermsValuesSource) Source() (interface{}, error) {
 	source := make(map[string]interface{})
@@ -229,6 +266,11 @@ func (a *CompositeAggregationTermsValuesSource) Source() (interface{}, error) {
 		values["missing"] = a.missing
 	}
 
+	// missing_bucket
+	if a.missingBucket {
+		values["missing_bucket"] = true
+	}
+
 	// value_type
 	if a.valueType != "" {
 		values["value_type"] = a.valueType
@@ -251,13 +293,14 @@ func (a *CompositeAggregationTermsValuesSource) Source() (interface{}, error) {
 // See https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-composite-aggregation.html#_histogram
 // for details.
 type CompositeAggregationHistogramValuesSource struct {
-	name      string
-	field     string
-	script    *elastic.Script
-	valueType string
-	missing   interface{}
-	order     string
-	interval  float64
+	name          string
+	field         string
+	script        *elastic.Script
+	valueType     string
+	missing       interface{}
+	order         string
+	missingBucket bool
+	interval      float64
 }
 
 // NewCompositeAggregationHistogramValuesSource creates and initializes
@@ -281,6 +324,13 @@ func (a *CompositeAggregationHistogramValuesSource) Script(script *elastic.Scrip
 	return a
 }
 
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *CompositeAggregationHistogramValuesSource) StoredScript(id string, params map[string]interface{}) *CompositeAggregationHistogramValuesSource {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	return a
+}
+
 // ValueType specifies the type of values produced by this source,
 // e.g. "string" or "date".
 func (a *CompositeAggregationHistogramValuesSource) ValueType(valueType string) *CompositeAggregationHistogramValuesSource {
@@ -295,6 +345,13 @@ func (a *CompositeAggregationHistogramValuesSource) Missing(missing interface{})
 	return a
 }
 
+// MissingBucket includes the documents without a value for the source
+// as a bucket with the null key.
+func (a *CompositeAggregationHistogramValuesSource) MissingBucket(missingBucket bool) *CompositeAggregationHistogramValuesSource {
+	a.missingBucket = missingBucket
+	return a
+}
+
 // Order specifies the order in the values produced by this source.
 // It can be either "asc" or "desc".
 func (a *CompositeAggregationHistogramValuesSource) Order(order string) *CompositeAggregationHistogramValuesSource {
@@ -347,6 +404,11 @@ func (a *CompositeAggregationHistogramValuesSource) Source() (interface{}, error
 		values["missing"] = a.missing
 	}
 
+	// missing_bucket
+	if a.missingBucket {
+		values["missing_bucket"] = true
+	}
+
 	// value_type
 	if a.valueType != "" {
 		values["value_type"] = a.valueType
@@ -372,14 +434,15 @@ func (a *CompositeAggregationHistogramValuesSource) Source() (interface{}, error
 // See https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-composite-aggregation.html#_date_histogram
 // for details.
 type CompositeAggregationDateHistogramValuesSource struct {
-	name      string
-	field     string
-	script    *elastic.Script
-	valueType string
-	missing   interface{}
-	order     string
-	interval  interface{}
-	timeZone  string
+	name          string
+	field         string
+	script        *elastic.Script
+	valueType     string
+	missing       interface{}
+	order         string
+	missingBucket bool
+	interval      interface{}
+	timeZone      string
 }
 
 // NewCompositeAggregationDateHistogramValuesSource creates and initializes
@@ -403,6 +466,13 @@ func (a *CompositeAggregationDateHistogramValuesSource) Script(script *elastic.S
 	return a
 }
 
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *CompositeAggregationDateHistogramValuesSource) StoredScript(id string, params map[string]interface{}) *CompositeAggregationDateHistogramValuesSource {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	return a
+}
+
 // ValueType specifies the type of values produced by this source,
 // e.g. "string" or "date".
 func (a *CompositeAggregationDateHistogramValuesSource) ValueType(valueType string) *CompositeAggregationDateHistogramValuesSource {
@@ -417,6 +487,13 @@ func (a *CompositeAggregationDateHistogramValuesSource) Missing(missing interfac
 	return a
 }
 
+// MissingBucket includes the documents without a value for the source
+// as a bucket with the null key.
+func (a *CompositeAggregationDateHistogramValuesSource) MissingBucket(missingBucket bool) *CompositeAggregationDateHistogramValuesSource {
+	a.missingBucket = missingBucket
+	return a
+}
+
 // Order specifies the order in the values produced by this source.
 // It can be either "asc" or "desc".
 func (a *CompositeAggregationDateHistogramValuesSource) Order(order string) *CompositeAggregationDateHistogramValuesSource {
@@ -475,6 +552,11 @@ func (a *CompositeAggregationDateHistogramValuesSource) Source() (interface{}, e
 		values["missing"] = a.missing
 	}
 
+	// missing_bucket
+	if a.missingBucket {
+		values["missing_bucket"] = true
+	}
+
 	// value_type
 	if a.valueType != "" {
 		values["value_type"] = a.valueType
@@ -495,3 +577,98 @@ func (a *CompositeAggregationDateHistogramValuesSource) Source() (interface{}, e
 
 	return source, nil
 }
+
+// -- CompositeAggregationGeoTileGridValuesSource --
+
+// CompositeAggregationGeoTileGridValuesSource is a source for the CompositeAggregation that handles geotile grid cells
+// it works very similar to a geotile grid aggregation with slightly different syntax
+//
+// See https://www.elastic.co/guide/en/elasticsearch/reference/7.x/search-aggregations-bucket-composite-aggregation.html#_geotile_grid
+// for details.
+type CompositeAggregationGeoTileGridValuesSource struct {
+	name          string
+	field         string
+	precision     *int
+	order         string
+	missingBucket bool
+}
+
+// NewCompositeAggregationGeoTileGridValuesSource creates and initializes
+// a new CompositeAggregationGeoTileGridValuesSource.
+func NewCompositeAggregationGeoTileGridValuesSource(name string) *CompositeAggregationGeoTileGridValuesSource {
+	return &CompositeAggregationGeoTileGridValuesSource{
+		name: name,
+	}
+}
+
+// Field to use for this source, it must be a geo_point field.
+func (a *CompositeAggregationGeoTileGridValuesSource) Field(field string) *CompositeAggregationGeoTileGridValuesSource {
+	a.field = field
+	return a
+}
+
+// Precision specifies the zoom level of the tiles, from 0 to 29 (Elasticsearch defaults to 7).
+func (a *CompositeAggregationGeoTileGridValuesSource) Precision(precision int) *CompositeAggregationGeoTileGridValuesSource {
+	a.precision = &precision
+	return a
+}
+
+// MissingBucket includes the documents without a value for the source
+// as a bucket with the null key.
+func (a *CompositeAggregationGeoTileGridValuesSource) MissingBucket(missingBucket bool) *CompositeAggregationGeoTileGridValuesSource {
+	a.missingBucket = missingBucket
+	return a
+}
+
+// Order specifies the order in the values produced by this source.
+// It can be either "asc" or "desc".
+func (a *CompositeAggregationGeoTileGridValuesSource) Order(order string) *CompositeAggregationGeoTileGridValuesSource {
+	a.order = order
+	return a
+}
+
+// Asc ensures the order of the values produced is ascending.
+func (a *CompositeAggregationGeoTileGridValuesSource) Asc() *CompositeAggregationGeoTileGridValuesSource {
+	a.order = "asc"
+	return a
+}
+
+// Desc ensures the order of the values produced is descending.
+func (a *CompositeAggregationGeoTileGridValuesSource) Desc() *CompositeAggregationGeoTileGridValuesSource {
+	a.order = "desc"
+	return a
+}
+
+// Source returns the serializable JSON for this values source.
+func (a *CompositeAggregationGeoTileGridValuesSource) Source() (interface{}, error) {
+	source := make(map[string]interface{})
+	name := make(map[string]interface{})
+	source[a.name] = name
+	values := make(map[string]interface{})
+	name["geotile_grid"] = values
+
+	// field
+	if a.field != "" {
+		values["field"] = a.field
+	}
+
+	// missing_bucket
+	if a.missingBucket {
+		values["missing_bucket"] = true
+	}
+
+	// order
+	if a.order != "" {
+		values["order"] = a.order
+	}
+
+	// GeoTileGrid-related properties
+	if a.precision != nil {
+		if *a.precision < 0 || *a.precision > 29 {
+			return nil, fmt.Errorf("elastic: geotile grid precision %d is out of range [0, 29]", *a.precision)
+		}
+		values["precision"] = *a.precision
+	}
+
+	return source, nil
+}
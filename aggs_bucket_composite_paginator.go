@@ -0,0 +1,73 @@
+package aggretastic
+
+import "github.com/olivere/elastic"
+
+// CompositePaginator pages through all the buckets of a CompositeAggregation.
+// After every page the after_key of the response is set as AggregateAfter of the aggregation,
+// so the next search continues where the previous one stopped:
+//
+//	p := NewCompositePaginator(agg, fetch)
+//	for p.Next() {
+//		for _, bucket := range p.Page().Buckets {
+//			...
+//		}
+//	}
+//	if err := p.Err(); err != nil {
+//		...
+//	}
+type CompositePaginator struct {
+	agg   *CompositeAggregation
+	fetch func(agg *CompositeAggregation) (*elastic.AggregationBucketCompositeItems, error)
+
+	page *elastic.AggregationBucketCompositeItems
+	err  error
+	done bool
+}
+
+// NewCompositePaginator creates a paginator over agg. fetch runs the search with agg
+// and returns the decoded composite aggregation of the response.
+func NewCompositePaginator(
+	agg *CompositeAggregation,
+	fetch func(agg *CompositeAggregation) (*elastic.AggregationBucketCompositeItems, error),
+) *CompositePaginator {
+	return &CompositePaginator{agg: agg, fetch: fetch}
+}
+
+// Next fetches the next page and reports whether there is one.
+// The pagination stops on an error, on a page without after_key
+// or on a page with fewer buckets than the size of the aggregation.
+func (p *CompositePaginator) Next() bool {
+	if p.done {
+		return false
+	}
+
+	page, err := p.fetch(p.agg)
+	if err != nil {
+		p.err = err
+		p.done = true
+		return false
+	}
+	if page == nil || len(page.Buckets) == 0 {
+		p.done = true
+		return false
+	}
+
+	p.page = page
+	if len(page.AfterKey) == 0 || (p.agg.size != nil && len(page.Buckets) < *p.agg.size) {
+		p.done = true
+	} else {
+		p.agg.AggregateAfter(page.AfterKey)
+	}
+
+	return true
+}
+
+// Page returns the page fetched by the last call of Next
+func (p *CompositePaginator) Page() *elastic.AggregationBucketCompositeItems {
+	return p.page
+}
+
+// Err returns the error which stopped the pagination (if any)
+func (p *CompositePaginator) Err() error {
+	return p.err
+}
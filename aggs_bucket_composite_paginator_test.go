@@ -0,0 +1,96 @@
+package aggretastic
+
+import (
+	"errors"
+	"github.com/olivere/elastic"
+	"reflect"
+	"testing"
+)
+
+// compositePages returns the pages of size buckets with the after_key of their number,
+// the last one having the given number of buckets
+func compositePages(count, size, last int) []*elastic.AggregationBucketCompositeItems {
+	pages := make([]*elastic.AggregationBucketCompositeItems, count)
+	for i := range pages {
+		n := size
+		if i == count-1 {
+			n = last
+		}
+		pages[i] = &elastic.AggregationBucketCompositeItems{
+			Buckets:  make([]*elastic.AggregationBucketCompositeItem, n),
+			AfterKey: map[string]interface{}{"page": i},
+		}
+	}
+
+	return pages
+}
+
+func TestCompositePaginator(t *testing.T) {
+	agg := NewCompositeAggregation().Sources(NewCompositeAggregationTermsValuesSource("user").Field("user")).Size(2)
+	pages := compositePages(3, 2, 1)
+
+	var afters []interface{}
+	p := NewCompositePaginator(agg, func(agg *CompositeAggregation) (*elastic.AggregationBucketCompositeItems, error) {
+		afters = append(afters, agg.after["page"])
+		return pages[len(afters)-1], nil
+	})
+
+	fetched := 0
+	for p.Next() {
+		if p.Page() != pages[fetched] {
+			t.Fatalf("expected the page %d", fetched)
+		}
+		fetched++
+	}
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if fetched != 3 {
+		t.Fatalf("expected 3 pages, got %d", fetched)
+	}
+	if want := []interface{}{nil, 0, 1}; !reflect.DeepEqual(afters, want) {
+		t.Fatalf("expected the after keys %v, got %v", want, afters)
+	}
+}
+
+func TestCompositePaginatorStops(t *testing.T) {
+	failure := errors.New("search failed")
+
+	tests := []struct {
+		name  string
+		pages []*elastic.AggregationBucketCompositeItems
+		err   error
+		want  int
+	}{
+		{"empty page", append(compositePages(1, 2, 2), &elastic.AggregationBucketCompositeItems{}), nil, 1},
+		{"no after key", []*elastic.AggregationBucketCompositeItems{{Buckets: make([]*elastic.AggregationBucketCompositeItem, 2)}}, nil, 1},
+		{"error", compositePages(1, 2, 2), failure, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			p := NewCompositePaginator(NewCompositeAggregation().Size(2), func(agg *CompositeAggregation) (*elastic.AggregationBucketCompositeItems, error) {
+				calls++
+				if calls > len(test.pages) {
+					return nil, test.err
+				}
+				return test.pages[calls-1], nil
+			})
+
+			fetched := 0
+			for p.Next() {
+				fetched++
+			}
+			if fetched != test.want {
+				t.Fatalf("expected %d pages, got %d", test.want, fetched)
+			}
+			if p.Err() != test.err {
+				t.Fatalf("expected %v, got %v", test.err, p.Err())
+			}
+			if p.Next() {
+				t.Fatal("expected the paginator to stay stopped")
+			}
+		})
+	}
+}
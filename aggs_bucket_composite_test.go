@@ -0,0 +1,45 @@
+package aggretastic
+
+import "testing"
+
+func TestCompositeValuesSourceMissingBucketAndOrder(t *testing.T) {
+	agg := NewCompositeAggregation().Size(100).Sources(
+		NewCompositeAggregationTermsValuesSource("product").Field("product").MissingBucket(true).Order("desc"),
+		NewCompositeAggregationHistogramValuesSource("price", 5).Field("price").MissingBucket(true).Order("asc"),
+		NewCompositeAggregationDateHistogramValuesSource("date", "1d").Field("timestamp").Order("desc"),
+	)
+
+	want := `{"composite":{"size":100,"sources":[` +
+		`{"product":{"terms":{"field":"product","missing_bucket":true,"order":"desc"}}},` +
+		`{"price":{"histogram":{"field":"price","interval":5,"missing_bucket":true,"order":"asc"}}},` +
+		`{"date":{"date_histogram":{"field":"timestamp","interval":"1d","order":"desc"}}}]}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestCompositeGeoTileGridValuesSource(t *testing.T) {
+	agg := NewCompositeAggregation().Sources(
+		NewCompositeAggregationGeoTileGridValuesSource("tile").Field("location").Precision(8),
+		NewCompositeAggregationTermsValuesSource("kind").Field("kind"),
+	)
+	want := `{"composite":{"sources":[{"tile":{"geotile_grid":{"field":"location","precision":8}}},{"kind":{"terms":{"field":"kind"}}}]}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	agg = NewCompositeAggregation().Sources(
+		NewCompositeAggregationTermsValuesSource("kind").Field("kind"),
+		NewCompositeAggregationGeoTileGridValuesSource("tile").Field("location").Precision(0).Desc(),
+	)
+	want = `{"composite":{"sources":[{"kind":{"terms":{"field":"kind"}}},{"tile":{"geotile_grid":{"field":"location","order":"desc","precision":0}}}]}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestCompositeGeoTileGridValuesSourcePrecision(t *testing.T) {
+	if _, err := NewCompositeAggregationGeoTileGridValuesSource("tile").Field("location").Precision(30).Source(); err == nil {
+		t.Fatal("expected an error for the precision out of range")
+	}
+}
@@ -7,3 +7,13 @@ type BucketCountThresholds struct {
 	RequiredSize     *int
 	ShardSize        *int
 }
+
+// clone returns a copy of t (nil for nil), the setters of the aggregations change it in place
+func (t *BucketCountThresholds) clone() *BucketCountThresholds {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+	return &c
+}
@@ -1,27 +1,30 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // DateHistogramAggregation is a multi-bucket aggregation similar to the
 // histogram except it can only be applied on date values.
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-datehistogram-aggregation.html
 type DateHistogramAggregation struct {
 	*tree
+	metaHolder
 
 	field   string
 	script  *elastic.Script
 	missing interface{}
-	meta    map[string]interface{}
 
-	interval          string
-	order             string
-	orderAsc          bool
-	minDocCount       *int64
-	extendedBoundsMin interface{}
-	extendedBoundsMax interface{}
-	timeZone          string
-	format            string
-	offset            string
+	interval       string
+	order          string
+	orderAsc       bool
+	minDocCount    *int64
+	extendedBounds *ExtendedBounds
+	hardBounds     *HardBounds
+	timeZone       string
+	format         string
+	offset         string
 }
 
 // NewDateHistogramAggregation creates a new DateHistogramAggregation.
@@ -35,28 +38,58 @@ func NewDateHistogramAggregation() *DateHistogramAggregation {
 // Field on which the aggregation is processed.
 func (a *DateHistogramAggregation) Field(field string) *DateHistogramAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *DateHistogramAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *DateHistogramAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *DateHistogramAggregation) Script(script *elastic.Script) *DateHistogramAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *DateHistogramAggregation) StoredScript(id string, params map[string]interface{}) *DateHistogramAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 // Missing configures the value to use when documents miss a value.
 func (a *DateHistogramAggregation) Missing(missing interface{}) *DateHistogramAggregation {
 	a.missing = missing
+	a.markDirty()
 	return a
 }
 
 func (a *DateHistogramAggregation) SubAggregation(name string, subAggregation Aggregation) *DateHistogramAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *DateHistogramAggregation) Meta(metaData map[string]interface{}) *DateHistogramAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *DateHistogramAggregation) AddMeta(key string, value interface{}) *DateHistogramAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
@@ -66,6 +99,7 @@ func (a *DateHistogramAggregation) Meta(metaData map[string]interface{}) *DateHi
 // (up to "w" for weeks).
 func (a *DateHistogramAggregation) Interval(interval string) *DateHistogramAggregation {
 	a.interval = interval
+	a.markDirty()
 	return a
 }
 
@@ -75,6 +109,7 @@ func (a *DateHistogramAggregation) Interval(interval string) *DateHistogramAggre
 func (a *DateHistogramAggregation) Order(order string, asc bool) *DateHistogramAggregation {
 	a.order = order
 	a.orderAsc = asc
+	a.markDirty()
 	return a
 }
 
@@ -82,6 +117,7 @@ func (a *DateHistogramAggregation) OrderByCount(asc bool) *DateHistogramAggregat
 	// "order" : { "_count" : "asc" }
 	a.order = "_count"
 	a.orderAsc = asc
+	a.markDirty()
 	return a
 }
 
@@ -97,6 +133,7 @@ func (a *DateHistogramAggregation) OrderByKey(asc bool) *DateHistogramAggregatio
 	// "order" : { "_key" : "asc" }
 	a.order = "_key"
 	a.orderAsc = asc
+	a.markDirty()
 	return a
 }
 
@@ -126,6 +163,7 @@ func (a *DateHistogramAggregation) OrderByAggregation(aggName string, asc bool)
 	// }
 	a.order = aggName
 	a.orderAsc = asc
+	a.markDirty()
 	return a
 }
 
@@ -147,6 +185,7 @@ func (a *DateHistogramAggregation) OrderByAggregationAndMetric(aggName, metric s
 	// }
 	a.order = aggName + "." + metric
 	a.orderAsc = asc
+	a.markDirty()
 	return a
 }
 
@@ -154,49 +193,110 @@ func (a *DateHistogramAggregation) OrderByAggregationAndMetric(aggName, metric s
 // Buckets with less documents than this min value will not be returned.
 func (a *DateHistogramAggregation) MinDocCount(minDocCount int64) *DateHistogramAggregation {
 	a.minDocCount = &minDocCount
+	a.markDirty()
 	return a
 }
 
 // TimeZone sets the timezone in which to translate dates before computing buckets.
 func (a *DateHistogramAggregation) TimeZone(timeZone string) *DateHistogramAggregation {
 	a.timeZone = timeZone
+	a.markDirty()
 	return a
 }
 
 // Format sets the format to use for dates.
 func (a *DateHistogramAggregation) Format(format string) *DateHistogramAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 // Offset sets the offset of time intervals in the histogram, e.g. "+6h".
 func (a *DateHistogramAggregation) Offset(offset string) *DateHistogramAggregation {
 	a.offset = offset
+	a.markDirty()
 	return a
 }
 
-// ExtendedBounds accepts int, int64, string, or time.Time values.
+// ExtendedBounds accepts int, int64, string, or time.Time values: epoch millis,
+// dates in the Format of the aggregation (e.g. ISO 8601) or date math like "now-7d/d".
 // In case the lower value in the histogram would be greater than min or the
 // upper value would be less than max, empty buckets will be generated.
+// Unless MinDocCount is set, min_doc_count 0 is rendered with the bounds, so the empty buckets are returned.
 func (a *DateHistogramAggregation) ExtendedBounds(min, max interface{}) *DateHistogramAggregation {
-	a.extendedBoundsMin = min
-	a.extendedBoundsMax = max
+	a.extendedBounds = &ExtendedBounds{Min: min, Max: max}
+	a.markDirty()
 	return a
 }
 
 // ExtendedBoundsMin accepts int, int64, string, or time.Time values.
 func (a *DateHistogramAggregation) ExtendedBoundsMin(min interface{}) *DateHistogramAggregation {
-	a.extendedBoundsMin = min
+	a.extendedBounds = a.extendedBounds.withMin(min)
+	a.markDirty()
 	return a
 }
 
 // ExtendedBoundsMax accepts int, int64, string, or time.Time values.
 func (a *DateHistogramAggregation) ExtendedBoundsMax(max interface{}) *DateHistogramAggregation {
-	a.extendedBoundsMax = max
+	a.extendedBounds = a.extendedBounds.withMax(max)
+	a.markDirty()
 	return a
 }
 
+// ExtendedBoundsOf sets the extended bounds, a nil bounds removes them.
+func (a *DateHistogramAggregation) ExtendedBoundsOf(bounds *ExtendedBounds) *DateHistogramAggregation {
+	a.extendedBounds = bounds
+	a.markDirty()
+	return a
+}
+
+// HardBounds limits the buckets to the range from min to max.
+// It accepts int, int64, string, or time.Time values.
+func (a *DateHistogramAggregation) HardBounds(min, max interface{}) *DateHistogramAggregation {
+	a.hardBounds = &HardBounds{Min: min, Max: max}
+	a.markDirty()
+	return a
+}
+
+// HardBoundsMin accepts int, int64, string, or time.Time values.
+func (a *DateHistogramAggregation) HardBoundsMin(min interface{}) *DateHistogramAggregation {
+	a.hardBounds = a.hardBounds.withMin(min)
+	a.markDirty()
+	return a
+}
+
+// HardBoundsMax accepts int, int64, string, or time.Time values.
+func (a *DateHistogramAggregation) HardBoundsMax(max interface{}) *DateHistogramAggregation {
+	a.hardBounds = a.hardBounds.withMax(max)
+	a.markDirty()
+	return a
+}
+
+// HardBoundsOf sets the hard bounds, a nil bounds removes them.
+func (a *DateHistogramAggregation) HardBoundsOf(bounds *HardBounds) *DateHistogramAggregation {
+	a.hardBounds = bounds
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *DateHistogramAggregation) AggregationType() string {
+	return "date_histogram"
+}
+
+func (a *DateHistogramAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: DateHistogramAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
 func (a *DateHistogramAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *DateHistogramAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	// {
 	//     "aggs" : {
@@ -233,6 +333,9 @@ func (a *DateHistogramAggregation) Source() (interface{}, error) {
 	opts["interval"] = a.interval
 	if a.minDocCount != nil {
 		opts["min_doc_count"] = *a.minDocCount
+	} else if a.extendedBounds != nil {
+		// the empty buckets filling the bounds aren't returned otherwise
+		opts["min_doc_count"] = int64(0)
 	}
 	if a.order != "" {
 		o := make(map[string]interface{})
@@ -252,34 +355,28 @@ func (a *DateHistogramAggregation) Source() (interface{}, error) {
 	if a.format != "" {
 		opts["format"] = a.format
 	}
-	if a.extendedBoundsMin != nil || a.extendedBoundsMax != nil {
-		bounds := make(map[string]interface{})
-		if a.extendedBoundsMin != nil {
-			bounds["min"] = a.extendedBoundsMin
+	if a.extendedBounds != nil {
+		src, err := a.extendedBounds.Source()
+		if err != nil {
+			return nil, err
 		}
-		if a.extendedBoundsMax != nil {
-			bounds["max"] = a.extendedBoundsMax
+		opts["extended_bounds"] = src
+	}
+	if a.hardBounds != nil {
+		src, err := a.hardBounds.Source()
+		if err != nil {
+			return nil, err
 		}
-		opts["extended_bounds"] = bounds
+		opts["hard_bounds"] = src
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -0,0 +1,30 @@
+package aggretastic
+
+import "testing"
+
+func TestDateHistogramAggregationFillsGaps(t *testing.T) {
+	tests := []struct {
+		name string
+		agg  *DateHistogramAggregation
+		want string
+	}{
+		{"iso bounds", NewDateHistogramAggregation().Field("ts").Interval("1d").ExtendedBounds("2020-01-01T00:00:00Z", "2020-01-31T00:00:00Z"),
+			`{"date_histogram":{"extended_bounds":{"max":"2020-01-31T00:00:00Z","min":"2020-01-01T00:00:00Z"},"field":"ts","interval":"1d","min_doc_count":0}}`},
+		{"date math min", NewDateHistogramAggregation().Field("ts").Interval("1d").ExtendedBoundsMin("now-7d/d"),
+			`{"date_histogram":{"extended_bounds":{"min":"now-7d/d"},"field":"ts","interval":"1d","min_doc_count":0}}`},
+		{"epoch millis max", NewDateHistogramAggregation().Field("ts").Interval("1d").ExtendedBoundsMax(int64(1577836800000)),
+			`{"date_histogram":{"extended_bounds":{"max":1577836800000},"field":"ts","interval":"1d","min_doc_count":0}}`},
+		{"explicit min doc count", NewDateHistogramAggregation().Field("ts").Interval("1d").ExtendedBoundsMin("now-7d/d").MinDocCount(1),
+			`{"date_histogram":{"extended_bounds":{"min":"now-7d/d"},"field":"ts","interval":"1d","min_doc_count":1}}`},
+		{"no bounds", NewDateHistogramAggregation().Field("ts").Interval("1d"),
+			`{"date_histogram":{"field":"ts","interval":"1d"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.agg.String() != test.want {
+				t.Fatalf("expected %s, got %s", test.want, test.agg)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package aggretastic
 
 import (
+	"errors"
 	"github.com/olivere/elastic"
 	"time"
 )
@@ -15,10 +16,10 @@ import (
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-daterange-aggregation.html
 type DateRangeAggregation struct {
 	*tree
+	metaHolder
 
 	field    string
 	script   *elastic.Script
-	meta     map[string]interface{}
 	keyed    *bool
 	unmapped *bool
 	timeZone string
@@ -41,106 +42,168 @@ func NewDateRangeAggregation() *DateRangeAggregation {
 
 func (a *DateRangeAggregation) Field(field string) *DateRangeAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *DateRangeAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *DateRangeAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *DateRangeAggregation) Script(script *elastic.Script) *DateRangeAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *DateRangeAggregation) StoredScript(id string, params map[string]interface{}) *DateRangeAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) SubAggregation(name string, subAggregation Aggregation) *DateRangeAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *DateRangeAggregation) Meta(metaData map[string]interface{}) *DateRangeAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *DateRangeAggregation) AddMeta(key string, value interface{}) *DateRangeAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) Keyed(keyed bool) *DateRangeAggregation {
 	a.keyed = &keyed
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) Unmapped(unmapped bool) *DateRangeAggregation {
 	a.unmapped = &unmapped
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) TimeZone(timeZone string) *DateRangeAggregation {
 	a.timeZone = timeZone
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) Format(format string) *DateRangeAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) AddRange(from, to interface{}) *DateRangeAggregation {
 	a.entries = append(a.entries, DateRangeAggregationEntry{From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) AddRangeWithKey(key string, from, to interface{}) *DateRangeAggregation {
 	a.entries = append(a.entries, DateRangeAggregationEntry{Key: key, From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) AddUnboundedTo(from interface{}) *DateRangeAggregation {
 	a.entries = append(a.entries, DateRangeAggregationEntry{From: from, To: nil})
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) AddUnboundedToWithKey(key string, from interface{}) *DateRangeAggregation {
 	a.entries = append(a.entries, DateRangeAggregationEntry{Key: key, From: from, To: nil})
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) AddUnboundedFrom(to interface{}) *DateRangeAggregation {
 	a.entries = append(a.entries, DateRangeAggregationEntry{From: nil, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) AddUnboundedFromWithKey(key string, to interface{}) *DateRangeAggregation {
 	a.entries = append(a.entries, DateRangeAggregationEntry{Key: key, From: nil, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) Lt(to interface{}) *DateRangeAggregation {
 	a.entries = append(a.entries, DateRangeAggregationEntry{From: nil, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) LtWithKey(key string, to interface{}) *DateRangeAggregation {
 	a.entries = append(a.entries, DateRangeAggregationEntry{Key: key, From: nil, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) Between(from, to interface{}) *DateRangeAggregation {
 	a.entries = append(a.entries, DateRangeAggregationEntry{From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) BetweenWithKey(key string, from, to interface{}) *DateRangeAggregation {
 	a.entries = append(a.entries, DateRangeAggregationEntry{Key: key, From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) Gt(from interface{}) *DateRangeAggregation {
 	a.entries = append(a.entries, DateRangeAggregationEntry{From: from, To: nil})
+	a.markDirty()
 	return a
 }
 
 func (a *DateRangeAggregation) GtWithKey(key string, from interface{}) *DateRangeAggregation {
 	a.entries = append(a.entries, DateRangeAggregationEntry{Key: key, From: from, To: nil})
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *DateRangeAggregation) AggregationType() string {
+	return "date_range"
+}
+
+func (a *DateRangeAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: DateRangeAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
 func (a *DateRangeAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *DateRangeAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	// {
 	//     "aggs" : {
@@ -197,33 +260,22 @@ func (a *DateRangeAggregation) Source() (interface{}, error) {
 		}
 		if ent.From != nil {
 			switch from := ent.From.(type) {
-			case int, int16, int32, int64, float32, float64:
-				r["from"] = from
-			case *int, *int16, *int32, *int64, *float32, *float64:
-				r["from"] = from
 			case time.Time:
 				r["from"] = from.Format(time.RFC3339)
 			case *time.Time:
 				r["from"] = from.Format(time.RFC3339)
-			case string:
-				r["from"] = from
-			case *string:
+			default:
+				// numbers and date math expressions like "now-1M/M" are passed as is
 				r["from"] = from
 			}
 		}
 		if ent.To != nil {
 			switch to := ent.To.(type) {
-			case int, int16, int32, int64, float32, float64:
-				r["to"] = to
-			case *int, *int16, *int32, *int64, *float32, *float64:
-				r["to"] = to
 			case time.Time:
 				r["to"] = to.Format(time.RFC3339)
 			case *time.Time:
 				r["to"] = to.Format(time.RFC3339)
-			case string:
-				r["to"] = to
-			case *string:
+			default:
 				r["to"] = to
 			}
 		}
@@ -232,22 +284,12 @@ func (a *DateRangeAggregation) Source() (interface{}, error) {
 	opts["ranges"] = ranges
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
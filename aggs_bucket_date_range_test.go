@@ -0,0 +1,26 @@
+package aggretastic
+
+import "testing"
+
+func TestDateRangeAggregation(t *testing.T) {
+	agg := NewDateRangeAggregation().Field("created").Format("yyyy-MM").TimeZone("UTC").
+		AddUnboundedFrom("now-1M/M").
+		AddRangeWithKey("last_month", "now-1M/M", "now/M").
+		AddUnboundedTo("now/M").
+		SubAggregation("avg", NewAvgAggregation().Field("price"))
+
+	want := `{"aggregations":{"avg":{"avg":{"field":"price"}}},"date_range":{"field":"created","format":"yyyy-MM",` +
+		`"ranges":[{"to":"now-1M/M"},{"from":"now-1M/M","key":"last_month","to":"now/M"},{"from":"now/M"}],"time_zone":"UTC"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestDateRangeAggregationKeyed(t *testing.T) {
+	agg := NewDateRangeAggregation().Field("created").Keyed(true).AddRange("2020-01-01", nil)
+
+	want := `{"date_range":{"field":"created","keyed":true,"ranges":[{"from":"2020-01-01"}]}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
@@ -1,6 +1,9 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // DiversifiedSamplerAggregation Like the ‘sampler` aggregation this is a filtering aggregation used to limit any
 // sub aggregations’ processing to a sample of the top-scoring documents. The diversified_sampler aggregation adds
@@ -9,64 +12,127 @@ import "github.com/olivere/elastic"
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-diversified-sampler-aggregation.html
 type DiversifiedSamplerAggregation struct {
 	*tree
+	metaHolder
 
-	meta            map[string]interface{}
 	field           string
 	script          *elastic.Script
-	shardSize       int
-	maxDocsPerValue int
+	shardSize       *int
+	maxDocsPerValue *int
 	executionHint   string
+
+	// executionHintErr is set by TypedExecutionHint for the unknown hints
+	executionHintErr error
 }
 
 func NewDiversifiedSamplerAggregation() *DiversifiedSamplerAggregation {
-	a := &DiversifiedSamplerAggregation{
-		shardSize:       -1,
-		maxDocsPerValue: -1,
-	}
+	a := &DiversifiedSamplerAggregation{}
 	a.tree = nilAggregationTree(a)
 
 	return a
 }
 
 func (a *DiversifiedSamplerAggregation) SubAggregation(name string, subAggregation Aggregation) *DiversifiedSamplerAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *DiversifiedSamplerAggregation) Meta(metaData map[string]interface{}) *DiversifiedSamplerAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *DiversifiedSamplerAggregation) AddMeta(key string, value interface{}) *DiversifiedSamplerAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 // Field on which the aggregation is processed.
 func (a *DiversifiedSamplerAggregation) Field(field string) *DiversifiedSamplerAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *DiversifiedSamplerAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *DiversifiedSamplerAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *DiversifiedSamplerAggregation) Script(script *elastic.Script) *DiversifiedSamplerAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *DiversifiedSamplerAggregation) StoredScript(id string, params map[string]interface{}) *DiversifiedSamplerAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 // ShardSize sets the maximum number of docs returned from each shard.
 func (a *DiversifiedSamplerAggregation) ShardSize(shardSize int) *DiversifiedSamplerAggregation {
-	a.shardSize = shardSize
+	a.shardSize = &shardSize
+	a.markDirty()
 	return a
 }
 
 func (a *DiversifiedSamplerAggregation) MaxDocsPerValue(maxDocsPerValue int) *DiversifiedSamplerAggregation {
-	a.maxDocsPerValue = maxDocsPerValue
+	a.maxDocsPerValue = &maxDocsPerValue
+	a.markDirty()
 	return a
 }
 
+// ExecutionHint sets the hint as it is, see TypedExecutionHint for the known ones.
 func (a *DiversifiedSamplerAggregation) ExecutionHint(hint string) *DiversifiedSamplerAggregation {
 	a.executionHint = hint
+	a.executionHintErr = nil
+	a.markDirty()
 	return a
 }
 
+// TypedExecutionHint sets ExecutionHintMap, ExecutionHintGlobalOrdinals or ExecutionHintBytesHash,
+// any other hint makes Validate return an error.
+func (a *DiversifiedSamplerAggregation) TypedExecutionHint(hint ExecutionHint) *DiversifiedSamplerAggregation {
+	a.executionHint = string(hint)
+	a.executionHintErr = checkExecutionHint(hint, samplerExecutionHints)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *DiversifiedSamplerAggregation) AggregationType() string {
+	return "diversified_sampler"
+}
+
+func (a *DiversifiedSamplerAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: DiversifiedSamplerAggregation requires a field or a script")
+	}
+	if a.executionHintErr != nil {
+		return a.executionHintErr
+	}
+
+	return nil
+}
+
 func (a *DiversifiedSamplerAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *DiversifiedSamplerAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	// {
 	//     "aggs": {
@@ -95,33 +161,23 @@ func (a *DiversifiedSamplerAggregation) Source() (interface{}, error) {
 		}
 		opts["script"] = src
 	}
-	if a.shardSize >= 0 {
-		opts["shard_size"] = a.shardSize
+	if a.shardSize != nil {
+		opts["shard_size"] = *a.shardSize
 	}
-	if a.maxDocsPerValue >= 0 {
-		opts["max_docs_per_value"] = a.maxDocsPerValue
+	if a.maxDocsPerValue != nil {
+		opts["max_docs_per_value"] = *a.maxDocsPerValue
 	}
 	if a.executionHint != "" {
 		opts["execution_hint"] = a.executionHint
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -1,6 +1,9 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // FilterAggregation defines a single bucket of all the documents
 // in the current document set context that match a specified filter.
@@ -9,9 +12,10 @@ import "github.com/olivere/elastic"
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-filter-aggregation.html
 type FilterAggregation struct {
 	*tree
+	metaHolder
 
-	filter elastic.Query
-	meta   map[string]interface{}
+	filter    elastic.Query
+	filterRaw map[string]interface{}
 }
 
 func NewFilterAggregation() *FilterAggregation {
@@ -22,22 +26,56 @@ func NewFilterAggregation() *FilterAggregation {
 }
 
 func (a *FilterAggregation) SubAggregation(name string, subAggregation Aggregation) *FilterAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *FilterAggregation) Meta(metaData map[string]interface{}) *FilterAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *FilterAggregation) AddMeta(key string, value interface{}) *FilterAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 func (a *FilterAggregation) Filter(filter elastic.Query) *FilterAggregation {
 	a.filter = filter
+	a.markDirty()
+	return a
+}
+
+// FilterRaw sets an already built query body to be used as the filter as is.
+// It takes precedence over the query set with Filter.
+func (a *FilterAggregation) FilterRaw(raw map[string]interface{}) *FilterAggregation {
+	a.filterRaw = raw
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *FilterAggregation) AggregationType() string {
+	return "filter"
+}
+
+func (a *FilterAggregation) Validate() error {
+	if a.filter == nil && a.filterRaw == nil {
+		return errors.New("elastic: FilterAggregation requires a filter")
+	}
+
+	return nil
+}
+
 func (a *FilterAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *FilterAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -48,30 +86,27 @@ func (a *FilterAggregation) Source() (interface{}, error) {
 	//	}
 	// This method returns only the { "filter" : {} } part.
 
-	src, err := a.filter.Source()
-	if err != nil {
-		return nil, err
-	}
 	source := make(map[string]interface{})
-	source["filter"] = src
+	switch {
+	case a.filterRaw != nil:
+		source["filter"] = a.filterRaw
+	case a.filter != nil:
+		src, err := a.filter.Source()
+		if err != nil {
+			return nil, err
+		}
+		source["filter"] = src
+	default:
+		return nil, errors.New("elastic: FilterAggregation requires a filter")
+	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -0,0 +1,32 @@
+package aggretastic
+
+import (
+	"github.com/olivere/elastic"
+	"testing"
+)
+
+func TestFilterAggregationFilterRaw(t *testing.T) {
+	raw := map[string]interface{}{"term": map[string]interface{}{"type": "t-shirt"}}
+
+	tests := []struct {
+		name string
+		agg  *FilterAggregation
+		want string
+	}{
+		{"raw", NewFilterAggregation().FilterRaw(raw), `{"filter":{"term":{"type":"t-shirt"}}}`},
+		{"query", NewFilterAggregation().Filter(elastic.NewTermQuery("type", "hat")), `{"filter":{"term":{"type":"hat"}}}`},
+		{"raw wins", NewFilterAggregation().Filter(elastic.NewTermQuery("type", "hat")).FilterRaw(raw), `{"filter":{"term":{"type":"t-shirt"}}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.agg.String() != test.want {
+				t.Fatalf("expected %s, got %s", test.want, test.agg)
+			}
+		})
+	}
+
+	if _, err := NewFilterAggregation().Source(); err == nil {
+		t.Fatal("expected an error without a filter")
+	}
+}
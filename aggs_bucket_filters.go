@@ -1,6 +1,8 @@
 package aggretastic
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"github.com/olivere/elastic"
 )
@@ -17,17 +19,16 @@ import (
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-filters-aggregation.html
 type FiltersAggregation struct {
 	*tree
+	metaHolder
 
 	unnamedFilters []elastic.Query
-	namedFilters   map[string]elastic.Query
-	meta           map[string]interface{}
+	namedFilters   NamedFilters
 }
 
 // NewFiltersAggregation initializes a new FiltersAggregation.
 func NewFiltersAggregation() *FiltersAggregation {
 	a := &FiltersAggregation{
 		unnamedFilters: make([]elastic.Query, 0),
-		namedFilters:   make(map[string]elastic.Query),
 	}
 	a.tree = nilAggregationTree(a)
 
@@ -38,6 +39,7 @@ func NewFiltersAggregation() *FiltersAggregation {
 // either use named or unnamed filters, but not both.
 func (a *FiltersAggregation) Filter(filter elastic.Query) *FiltersAggregation {
 	a.unnamedFilters = append(a.unnamedFilters, filter)
+	a.markDirty()
 	return a
 }
 
@@ -47,32 +49,67 @@ func (a *FiltersAggregation) Filters(filters ...elastic.Query) *FiltersAggregati
 	if len(filters) > 0 {
 		a.unnamedFilters = append(a.unnamedFilters, filters...)
 	}
+	a.markDirty()
 	return a
 }
 
 // FilterWithName adds a filter with a specific name. Notice that you can
 // either use named or unnamed filters, but not both.
+// It's the same as AddNamedFilter.
 func (a *FiltersAggregation) FilterWithName(name string, filter elastic.Query) *FiltersAggregation {
-	a.namedFilters[name] = filter
+	return a.AddNamedFilter(name, filter)
+}
+
+// AddNamedFilter adds a filter with a specific name. The named filters are rendered
+// in the order they were added, adding a filter with the same name again replaces
+// the query keeping its position. Notice that you can either use named or unnamed filters, but not both.
+func (a *FiltersAggregation) AddNamedFilter(name string, filter elastic.Query) *FiltersAggregation {
+	a.namedFilters = a.namedFilters.with(name, filter)
+	a.markDirty()
 	return a
 }
 
 // SubAggregation adds a sub-aggregation to this aggregation.
 func (a *FiltersAggregation) SubAggregation(name string, subAggregation Aggregation) *FiltersAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *FiltersAggregation) Meta(metaData map[string]interface{}) *FiltersAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *FiltersAggregation) AddMeta(key string, value interface{}) *FiltersAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *FiltersAggregation) AggregationType() string {
+	return "filters"
+}
+
+func (a *FiltersAggregation) Validate() error {
+	if len(a.unnamedFilters) == 0 && len(a.namedFilters) == 0 {
+		return errors.New("elastic: FiltersAggregation requires at least one filter")
+	}
+
+	return nil
+}
+
 // Source returns the a JSON-serializable interface.
 // If the aggregation is invalid, an error is returned. This may e.g. happen
 // if you mixed named and unnamed filters.
 func (a *FiltersAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *FiltersAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//  "aggs" : {
@@ -107,34 +144,90 @@ func (a *FiltersAggregation) Source() (interface{}, error) {
 		}
 		filters["filters"] = arr
 	} else {
-		dict := make(map[string]interface{})
-		for key, filter := range a.namedFilters {
-			src, err := filter.Source()
-			if err != nil {
-				return nil, err
-			}
-			dict[key] = src
+		src, err := a.namedFilters.Source()
+		if err != nil {
+			return nil, err
 		}
-		filters["filters"] = dict
+		filters["filters"] = src
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
+	a.renderMeta(source)
+
+	return source, nil
+}
+
+// NamedFilter is a filter of FiltersAggregation with the name of its bucket
+type NamedFilter struct {
+	Name  string
+	Query elastic.Query
+}
+
+// NamedFilters is the ordered list of named filters, unlike a map it's rendered
+// in the order of the filters, so the buckets come in the same order as well.
+type NamedFilters []NamedFilter
+
+// with returns the filters with the query of name replaced or added to the end
+func (f NamedFilters) with(name string, query elastic.Query) NamedFilters {
+	for i := range f {
+		if f[i].Name == name {
+			f[i].Query = query
+			return f
+		}
+	}
+
+	return append(f, NamedFilter{Name: name, Query: query})
+}
+
+// Source returns the JSON-serializable object of the filters keyed by their names
+func (f NamedFilters) Source() (interface{}, error) {
+	source := orderedObject{
+		keys:   make([]string, len(f)),
+		values: make([]interface{}, len(f)),
+	}
+	for i, filter := range f {
+		src, err := filter.Query.Source()
+		if err != nil {
+			return nil, err
+		}
+		source.keys[i] = filter.Name
+		source.values[i] = src
 	}
 
 	return source, nil
 }
+
+// orderedObject is a JSON object keeping its keys in the given order
+type orderedObject struct {
+	keys   []string
+	values []interface{}
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
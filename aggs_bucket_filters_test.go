@@ -0,0 +1,51 @@
+package aggretastic
+
+import (
+	"encoding/json"
+	"github.com/olivere/elastic"
+	"testing"
+)
+
+func TestNamedFiltersKeepOrder(t *testing.T) {
+	agg := NewFiltersAggregation().
+		AddNamedFilter("warnings", elastic.NewTermQuery("body", "warning")).
+		FilterWithName("errors", elastic.NewTermQuery("body", "error")).
+		AddNamedFilter("alpha", elastic.NewMatchAllQuery())
+
+	want := `{"filters":{"filters":{"warnings":{"term":{"body":"warning"}},"errors":{"term":{"body":"error"}},"alpha":{"match_all":{}}}}}`
+	if got := marshalSource(t, agg); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	// adding a filter with the same name replaces it in place
+	agg.AddNamedFilter("warnings", elastic.NewTermQuery("body", "warn"))
+	want = `{"filters":{"filters":{"warnings":{"term":{"body":"warn"}},"errors":{"term":{"body":"error"}},"alpha":{"match_all":{}}}}}`
+	if got := marshalSource(t, agg); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestNamedFiltersOfCloneAreIndependent(t *testing.T) {
+	agg := NewFiltersAggregation().
+		AddNamedFilter("a", elastic.NewTermQuery("x", 1)).
+		AddNamedFilter("b", elastic.NewTermQuery("x", 2))
+	want := marshalSource(t, agg)
+
+	Clone(agg).(*FiltersAggregation).AddNamedFilter("a", elastic.NewTermQuery("x", 3))
+	if got := marshalSource(t, agg); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func marshalSource(t *testing.T, agg Aggregation) string {
+	src, err := agg.Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
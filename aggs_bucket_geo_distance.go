@@ -1,5 +1,7 @@
 package aggretastic
 
+import "errors"
+
 // GeoDistanceAggregation is a multi-bucket aggregation that works on geo_point fields
 // and conceptually works very similar to the range aggregation.
 // The user can define a point of origin and a set of distance range buckets.
@@ -10,13 +12,14 @@ package aggretastic
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-geodistance-aggregation.html
 type GeoDistanceAggregation struct {
 	*tree
+	metaHolder
 
 	field        string
 	unit         string
 	distanceType string
 	point        string
 	ranges       []geoDistAggRange
-	meta         map[string]interface{}
+	keyed        *bool
 }
 
 type geoDistAggRange struct {
@@ -36,75 +39,137 @@ func NewGeoDistanceAggregation() *GeoDistanceAggregation {
 
 func (a *GeoDistanceAggregation) Field(field string) *GeoDistanceAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *GeoDistanceAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *GeoDistanceAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *GeoDistanceAggregation) Unit(unit string) *GeoDistanceAggregation {
 	a.unit = unit
+	a.markDirty()
 	return a
 }
 
 func (a *GeoDistanceAggregation) DistanceType(distanceType string) *GeoDistanceAggregation {
 	a.distanceType = distanceType
+	a.markDirty()
 	return a
 }
 
+// Point sets the origin as "lat,lon" (or a geohash).
 func (a *GeoDistanceAggregation) Point(latLon string) *GeoDistanceAggregation {
 	a.point = latLon
+	a.markDirty()
+	return a
+}
+
+func (a *GeoDistanceAggregation) Keyed(keyed bool) *GeoDistanceAggregation {
+	a.keyed = &keyed
+	a.markDirty()
 	return a
 }
 
 func (a *GeoDistanceAggregation) SubAggregation(name string, subAggregation Aggregation) *GeoDistanceAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *GeoDistanceAggregation) Meta(metaData map[string]interface{}) *GeoDistanceAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *GeoDistanceAggregation) AddMeta(key string, value interface{}) *GeoDistanceAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
+
 func (a *GeoDistanceAggregation) AddRange(from, to interface{}) *GeoDistanceAggregation {
 	a.ranges = append(a.ranges, geoDistAggRange{From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *GeoDistanceAggregation) AddRangeWithKey(key string, from, to interface{}) *GeoDistanceAggregation {
 	a.ranges = append(a.ranges, geoDistAggRange{Key: key, From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *GeoDistanceAggregation) AddUnboundedTo(from float64) *GeoDistanceAggregation {
 	a.ranges = append(a.ranges, geoDistAggRange{From: from, To: nil})
+	a.markDirty()
 	return a
 }
 
 func (a *GeoDistanceAggregation) AddUnboundedToWithKey(key string, from float64) *GeoDistanceAggregation {
 	a.ranges = append(a.ranges, geoDistAggRange{Key: key, From: from, To: nil})
+	a.markDirty()
 	return a
 }
 
 func (a *GeoDistanceAggregation) AddUnboundedFrom(to float64) *GeoDistanceAggregation {
 	a.ranges = append(a.ranges, geoDistAggRange{From: nil, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *GeoDistanceAggregation) AddUnboundedFromWithKey(key string, to float64) *GeoDistanceAggregation {
 	a.ranges = append(a.ranges, geoDistAggRange{Key: key, From: nil, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *GeoDistanceAggregation) Between(from, to interface{}) *GeoDistanceAggregation {
 	a.ranges = append(a.ranges, geoDistAggRange{From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *GeoDistanceAggregation) BetweenWithKey(key string, from, to interface{}) *GeoDistanceAggregation {
 	a.ranges = append(a.ranges, geoDistAggRange{Key: key, From: from, To: to})
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *GeoDistanceAggregation) AggregationType() string {
+	return "geo_distance"
+}
+
+func (a *GeoDistanceAggregation) Validate() error {
+	if a.field == "" {
+		return errors.New("elastic: GeoDistanceAggregation requires a field")
+	}
+	if a.point == "" {
+		return errors.New("elastic: GeoDistanceAggregation requires an origin point")
+	}
+	if err := ValidateGeoPoint(a.point); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (a *GeoDistanceAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *GeoDistanceAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	// {
 	//    "aggs" : {
@@ -138,6 +203,9 @@ func (a *GeoDistanceAggregation) Source() (interface{}, error) {
 		opts["distance_type"] = a.distanceType
 	}
 	if a.point != "" {
+		if err := ValidateGeoPoint(a.point); err != nil {
+			return nil, err
+		}
 		opts["origin"] = a.point
 	}
 
@@ -175,23 +243,17 @@ func (a *GeoDistanceAggregation) Source() (interface{}, error) {
 	}
 	opts["ranges"] = ranges
 
+	if a.keyed != nil {
+		opts["keyed"] = *a.keyed
+	}
+
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
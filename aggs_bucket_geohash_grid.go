@@ -1,20 +1,19 @@
 package aggretastic
 
+import "errors"
+
 type GeoHashGridAggregation struct {
 	*tree
+	metaHolder
 
 	field     string
 	precision interface{}
-	size      int
-	shardSize int
-	meta      map[string]interface{}
+	size      *int
+	shardSize *int
 }
 
 func NewGeoHashGridAggregation() *GeoHashGridAggregation {
-	a := &GeoHashGridAggregation{
-		size:      -1,
-		shardSize: -1,
-	}
+	a := &GeoHashGridAggregation{}
 	a.tree = nilAggregationTree(a)
 
 	return a
@@ -22,38 +21,78 @@ func NewGeoHashGridAggregation() *GeoHashGridAggregation {
 
 func (a *GeoHashGridAggregation) Field(field string) *GeoHashGridAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *GeoHashGridAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *GeoHashGridAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 // Precision accepts the level as int value between 1 and 12 or Distance Units like "2km", "5mi" as described at
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/common-options.html#distance-units and
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-geohashgrid-aggregation.html
 func (a *GeoHashGridAggregation) Precision(precision interface{}) *GeoHashGridAggregation {
 	a.precision = precision
+	a.markDirty()
 	return a
 }
 
 func (a *GeoHashGridAggregation) Size(size int) *GeoHashGridAggregation {
-	a.size = size
+	a.size = &size
+	a.markDirty()
 	return a
 }
 
 func (a *GeoHashGridAggregation) ShardSize(shardSize int) *GeoHashGridAggregation {
-	a.shardSize = shardSize
+	a.shardSize = &shardSize
+	a.markDirty()
 	return a
 }
 
 func (a *GeoHashGridAggregation) SubAggregation(name string, subAggregation Aggregation) *GeoHashGridAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 func (a *GeoHashGridAggregation) Meta(metaData map[string]interface{}) *GeoHashGridAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *GeoHashGridAggregation) AddMeta(key string, value interface{}) *GeoHashGridAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *GeoHashGridAggregation) AggregationType() string {
+	return "geohash_grid"
+}
+
+func (a *GeoHashGridAggregation) Validate() error {
+	if a.field == "" {
+		return errors.New("elastic: GeoHashGridAggregation requires a field")
+	}
+
+	return nil
+}
+
 func (a *GeoHashGridAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *GeoHashGridAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	// {
 	//     "aggs": {
@@ -78,30 +117,20 @@ func (a *GeoHashGridAggregation) Source() (interface{}, error) {
 		opts["precision"] = a.precision
 	}
 
-	if a.size != -1 {
-		opts["size"] = a.size
+	if a.size != nil {
+		opts["size"] = *a.size
 	}
 
-	if a.shardSize != -1 {
-		opts["shard_size"] = a.shardSize
+	if a.shardSize != nil {
+		opts["shard_size"] = *a.shardSize
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -7,8 +7,7 @@ package aggretastic
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-global-aggregation.html
 type GlobalAggregation struct {
 	*tree
-
-	meta map[string]interface{}
+	metaHolder
 }
 
 func NewGlobalAggregation() *GlobalAggregation {
@@ -19,17 +18,34 @@ func NewGlobalAggregation() *GlobalAggregation {
 }
 
 func (a *GlobalAggregation) SubAggregation(name string, subAggregation Aggregation) *GlobalAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *GlobalAggregation) Meta(metaData map[string]interface{}) *GlobalAggregation {
 	a.meta = metaData
+	a.markDirty()
 	return a
 }
 
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *GlobalAggregation) AddMeta(key string, value interface{}) *GlobalAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *GlobalAggregation) AggregationType() string {
+	return "global"
+}
+
 func (a *GlobalAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *GlobalAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -48,22 +64,12 @@ func (a *GlobalAggregation) Source() (interface{}, error) {
 	source["global"] = opts
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -1,6 +1,9 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // HistogramAggregation is a multi-bucket values source based aggregation
 // that can be applied on numeric values extracted from the documents.
@@ -9,19 +12,19 @@ import "github.com/olivere/elastic"
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-histogram-aggregation.html
 type HistogramAggregation struct {
 	*tree
+	metaHolder
 
 	field   string
 	script  *elastic.Script
 	missing interface{}
-	meta    map[string]interface{}
 
-	interval    float64
-	order       string
-	orderAsc    bool
-	minDocCount *int64
-	minBounds   *float64
-	maxBounds   *float64
-	offset      *float64
+	interval       float64
+	order          []TermsOrder
+	minDocCount    *int64
+	extendedBounds *ExtendedBounds
+	hardBounds     *HardBounds
+	offset         *float64
+	keyed          *bool
 }
 
 func NewHistogramAggregation() *HistogramAggregation {
@@ -33,50 +36,81 @@ func NewHistogramAggregation() *HistogramAggregation {
 
 func (a *HistogramAggregation) Field(field string) *HistogramAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *HistogramAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *HistogramAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *HistogramAggregation) Script(script *elastic.Script) *HistogramAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *HistogramAggregation) StoredScript(id string, params map[string]interface{}) *HistogramAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 // Missing configures the value to use when documents miss a value.
 func (a *HistogramAggregation) Missing(missing interface{}) *HistogramAggregation {
 	a.missing = missing
+	a.markDirty()
 	return a
 }
 
 func (a *HistogramAggregation) SubAggregation(name string, subAggregation Aggregation) *HistogramAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *HistogramAggregation) Meta(metaData map[string]interface{}) *HistogramAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *HistogramAggregation) AddMeta(key string, value interface{}) *HistogramAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 // Interval for this builder, must be greater than 0.
 func (a *HistogramAggregation) Interval(interval float64) *HistogramAggregation {
 	a.interval = interval
+	a.markDirty()
 	return a
 }
 
 // Order specifies the sort order. Valid values for order are:
 // "_key", "_count", a sub-aggregation name, or a sub-aggregation name
-// with a metric.
+// with a metric. Every call adds an order, the later ones break the ties of the earlier ones.
 func (a *HistogramAggregation) Order(order string, asc bool) *HistogramAggregation {
-	a.order = order
-	a.orderAsc = asc
+	a.order = append(a.order, TermsOrder{Field: order, Ascending: asc})
+	a.markDirty()
 	return a
 }
 
 func (a *HistogramAggregation) OrderByCount(asc bool) *HistogramAggregation {
 	// "order" : { "_count" : "asc" }
-	a.order = "_count"
-	a.orderAsc = asc
+	a.order = append(a.order, TermsOrder{Field: "_count", Ascending: asc})
+	a.markDirty()
 	return a
 }
 
@@ -90,8 +124,8 @@ func (a *HistogramAggregation) OrderByCountDesc() *HistogramAggregation {
 
 func (a *HistogramAggregation) OrderByKey(asc bool) *HistogramAggregation {
 	// "order" : { "_key" : "asc" }
-	a.order = "_key"
-	a.orderAsc = asc
+	a.order = append(a.order, TermsOrder{Field: "_key", Ascending: asc})
+	a.markDirty()
 	return a
 }
 
@@ -119,8 +153,8 @@ func (a *HistogramAggregation) OrderByAggregation(aggName string, asc bool) *His
 	//         }
 	//     }
 	// }
-	a.order = aggName
-	a.orderAsc = asc
+	a.order = append(a.order, TermsOrder{Field: aggName, Ascending: asc})
+	a.markDirty()
 	return a
 }
 
@@ -140,49 +174,113 @@ func (a *HistogramAggregation) OrderByAggregationAndMetric(aggName, metric strin
 	//         }
 	//     }
 	// }
-	a.order = aggName + "." + metric
-	a.orderAsc = asc
+	a.order = append(a.order, TermsOrder{Field: aggName + "." + metric, Ascending: asc})
+	a.markDirty()
 	return a
 }
 
+// MinDocCount sets the minimum number of documents a bucket needs to be returned.
+// 0 is rendered as well, together with ExtendedBounds it fills the whole range with (empty) buckets.
 func (a *HistogramAggregation) MinDocCount(minDocCount int64) *HistogramAggregation {
 	a.minDocCount = &minDocCount
+	a.markDirty()
 	return a
 }
 
 func (a *HistogramAggregation) ExtendedBounds(min, max float64) *HistogramAggregation {
-	a.minBounds = &min
-	a.maxBounds = &max
+	a.extendedBounds = &ExtendedBounds{Min: min, Max: max}
+	a.markDirty()
 	return a
 }
 
 func (a *HistogramAggregation) ExtendedBoundsMin(min float64) *HistogramAggregation {
-	a.minBounds = &min
+	a.extendedBounds = a.extendedBounds.withMin(min)
+	a.markDirty()
 	return a
 }
 
 func (a *HistogramAggregation) MinBounds(min float64) *HistogramAggregation {
-	a.minBounds = &min
-	return a
+	return a.ExtendedBoundsMin(min)
 }
 
 func (a *HistogramAggregation) ExtendedBoundsMax(max float64) *HistogramAggregation {
-	a.maxBounds = &max
+	a.extendedBounds = a.extendedBounds.withMax(max)
+	a.markDirty()
 	return a
 }
 
 func (a *HistogramAggregation) MaxBounds(max float64) *HistogramAggregation {
-	a.maxBounds = &max
+	return a.ExtendedBoundsMax(max)
+}
+
+// ExtendedBoundsOf sets the extended bounds, a nil bounds removes them.
+func (a *HistogramAggregation) ExtendedBoundsOf(bounds *ExtendedBounds) *HistogramAggregation {
+	a.extendedBounds = bounds
+	a.markDirty()
+	return a
+}
+
+// HardBounds limits the buckets to the range from min to max.
+func (a *HistogramAggregation) HardBounds(min, max float64) *HistogramAggregation {
+	a.hardBounds = &HardBounds{Min: min, Max: max}
+	a.markDirty()
+	return a
+}
+
+func (a *HistogramAggregation) HardBoundsMin(min float64) *HistogramAggregation {
+	a.hardBounds = a.hardBounds.withMin(min)
+	a.markDirty()
+	return a
+}
+
+func (a *HistogramAggregation) HardBoundsMax(max float64) *HistogramAggregation {
+	a.hardBounds = a.hardBounds.withMax(max)
+	a.markDirty()
+	return a
+}
+
+// HardBoundsOf sets the hard bounds, a nil bounds removes them.
+func (a *HistogramAggregation) HardBoundsOf(bounds *HardBounds) *HistogramAggregation {
+	a.hardBounds = bounds
+	a.markDirty()
+	return a
+}
+
+// Keyed returns the buckets as a hash keyed by the bucket key instead of an array.
+func (a *HistogramAggregation) Keyed(keyed bool) *HistogramAggregation {
+	a.keyed = &keyed
+	a.markDirty()
 	return a
 }
 
 // Offset into the histogram
 func (a *HistogramAggregation) Offset(offset float64) *HistogramAggregation {
 	a.offset = &offset
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *HistogramAggregation) AggregationType() string {
+	return "histogram"
+}
+
+func (a *HistogramAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: HistogramAggregation requires a field or a script")
+	}
+	if err := validateOrderPaths(a, "HistogramAggregation", a.order); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (a *HistogramAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *HistogramAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	// {
 	//     "aggs" : {
@@ -217,14 +315,22 @@ func (a *HistogramAggregation) Source() (interface{}, error) {
 	}
 
 	opts["interval"] = a.interval
-	if a.order != "" {
-		o := make(map[string]interface{})
-		if a.orderAsc {
-			o[a.order] = "asc"
-		} else {
-			o[a.order] = "desc"
+	if len(a.order) == 1 {
+		src, err := a.order[0].Source()
+		if err != nil {
+			return nil, err
 		}
-		opts["order"] = o
+		opts["order"] = src
+	} else if len(a.order) > 1 {
+		var orderSlice []interface{}
+		for _, order := range a.order {
+			src, err := order.Source()
+			if err != nil {
+				return nil, err
+			}
+			orderSlice = append(orderSlice, src)
+		}
+		opts["order"] = orderSlice
 	}
 	if a.offset != nil {
 		opts["offset"] = *a.offset
@@ -232,34 +338,31 @@ func (a *HistogramAggregation) Source() (interface{}, error) {
 	if a.minDocCount != nil {
 		opts["min_doc_count"] = *a.minDocCount
 	}
-	if a.minBounds != nil || a.maxBounds != nil {
-		bounds := make(map[string]interface{})
-		if a.minBounds != nil {
-			bounds["min"] = a.minBounds
+	if a.extendedBounds != nil {
+		src, err := a.extendedBounds.Source()
+		if err != nil {
+			return nil, err
 		}
-		if a.maxBounds != nil {
-			bounds["max"] = a.maxBounds
+		opts["extended_bounds"] = src
+	}
+	if a.hardBounds != nil {
+		src, err := a.hardBounds.Source()
+		if err != nil {
+			return nil, err
 		}
-		opts["extended_bounds"] = bounds
+		opts["hard_bounds"] = src
+	}
+	if a.keyed != nil {
+		opts["keyed"] = *a.keyed
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -0,0 +1,50 @@
+package aggretastic
+
+import "testing"
+
+func TestHistogramAggregationKeepsZeroMinDocCount(t *testing.T) {
+	agg := NewHistogramAggregation().Field("price").Interval(10).MinDocCount(0).ExtendedBounds(0, 100).Keyed(true)
+
+	want := `{"histogram":{"extended_bounds":{"max":100,"min":0},"field":"price","interval":10,"keyed":true,"min_doc_count":0}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestHistogramAggregationOmitsUnsetMinDocCount(t *testing.T) {
+	agg := NewHistogramAggregation().Field("price").Interval(10)
+
+	want := `{"histogram":{"field":"price","interval":10}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestHistogramAggregationOrdersAccumulate(t *testing.T) {
+	agg := NewHistogramAggregation().Field("price").Interval(10).OrderByKeyAsc()
+	want := `{"histogram":{"field":"price","interval":10,"order":{"_key":"asc"}}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	agg.SubAggregation("stats", NewStatsAggregation().Field("x")).OrderByAggregationAndMetric("stats", "max", false)
+	if err := agg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	want = `{"aggregations":{"stats":{"stats":{"field":"x"}}},"histogram":{"field":"price","interval":10,"order":[{"_key":"asc"},{"stats.max":"desc"}]}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	Clone(agg).(*HistogramAggregation).OrderByCountDesc()
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestHistogramAggregationOrderNeedsSubAggregation(t *testing.T) {
+	agg := NewHistogramAggregation().Field("price").Interval(10).OrderByAggregation("missing", true)
+	if err := agg.Validate(); err == nil {
+		t.Fatal("expected an error for the order by a missing subAggregation")
+	}
+}
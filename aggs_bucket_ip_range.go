@@ -1,14 +1,16 @@
 package aggretastic
 
+import "errors"
+
 // IPRangeAggregation is a range aggregation that is dedicated for
 // IP addresses.
 //
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-iprange-aggregation.html
 type IPRangeAggregation struct {
 	*tree
+	metaHolder
 
 	field   string
-	meta    map[string]interface{}
 	keyed   *bool
 	entries []IPRangeAggregationEntry
 }
@@ -20,6 +22,12 @@ type IPRangeAggregationEntry struct {
 	To   string
 }
 
+// isEmpty reports whether the range has neither a mask nor bounds (e.g. AddRange("", "")),
+// it would be rendered as {} matching all the addresses
+func (ent IPRangeAggregationEntry) isEmpty() bool {
+	return ent.Mask == "" && ent.From == "" && ent.To == ""
+}
+
 func NewIPRangeAggregation() *IPRangeAggregation {
 	a := &IPRangeAggregation{
 		entries: make([]IPRangeAggregationEntry, 0),
@@ -31,96 +39,153 @@ func NewIPRangeAggregation() *IPRangeAggregation {
 
 func (a *IPRangeAggregation) Field(field string) *IPRangeAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *IPRangeAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *IPRangeAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *IPRangeAggregation) SubAggregation(name string, subAggregation Aggregation) *IPRangeAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *IPRangeAggregation) Meta(metaData map[string]interface{}) *IPRangeAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *IPRangeAggregation) AddMeta(key string, value interface{}) *IPRangeAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) Keyed(keyed bool) *IPRangeAggregation {
 	a.keyed = &keyed
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) AddMaskRange(mask string) *IPRangeAggregation {
 	a.entries = append(a.entries, IPRangeAggregationEntry{Mask: mask})
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) AddMaskRangeWithKey(key, mask string) *IPRangeAggregation {
 	a.entries = append(a.entries, IPRangeAggregationEntry{Key: key, Mask: mask})
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) AddRange(from, to string) *IPRangeAggregation {
 	a.entries = append(a.entries, IPRangeAggregationEntry{From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) AddRangeWithKey(key, from, to string) *IPRangeAggregation {
 	a.entries = append(a.entries, IPRangeAggregationEntry{Key: key, From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) AddUnboundedTo(from string) *IPRangeAggregation {
 	a.entries = append(a.entries, IPRangeAggregationEntry{From: from, To: ""})
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) AddUnboundedToWithKey(key, from string) *IPRangeAggregation {
 	a.entries = append(a.entries, IPRangeAggregationEntry{Key: key, From: from, To: ""})
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) AddUnboundedFrom(to string) *IPRangeAggregation {
 	a.entries = append(a.entries, IPRangeAggregationEntry{From: "", To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) AddUnboundedFromWithKey(key, to string) *IPRangeAggregation {
 	a.entries = append(a.entries, IPRangeAggregationEntry{Key: key, From: "", To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) Lt(to string) *IPRangeAggregation {
 	a.entries = append(a.entries, IPRangeAggregationEntry{From: "", To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) LtWithKey(key, to string) *IPRangeAggregation {
 	a.entries = append(a.entries, IPRangeAggregationEntry{Key: key, From: "", To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) Between(from, to string) *IPRangeAggregation {
 	a.entries = append(a.entries, IPRangeAggregationEntry{From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) BetweenWithKey(key, from, to string) *IPRangeAggregation {
 	a.entries = append(a.entries, IPRangeAggregationEntry{Key: key, From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) Gt(from string) *IPRangeAggregation {
 	a.entries = append(a.entries, IPRangeAggregationEntry{From: from, To: ""})
+	a.markDirty()
 	return a
 }
 
 func (a *IPRangeAggregation) GtWithKey(key, from string) *IPRangeAggregation {
 	a.entries = append(a.entries, IPRangeAggregationEntry{Key: key, From: from, To: ""})
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *IPRangeAggregation) AggregationType() string {
+	return "ip_range"
+}
+
+func (a *IPRangeAggregation) Validate() error {
+	if a.field == "" {
+		return errors.New("elastic: IPRangeAggregation requires a field")
+	}
+	for _, ent := range a.entries {
+		if ent.isEmpty() {
+			return errors.New("elastic: IPRangeAggregation range requires a mask, from or to")
+		}
+	}
+
+	return nil
+}
+
 func (a *IPRangeAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *IPRangeAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	// {
 	//     "aggs" : {
@@ -154,6 +219,9 @@ func (a *IPRangeAggregation) Source() (interface{}, error) {
 
 	var ranges []interface{}
 	for _, ent := range a.entries {
+		if ent.isEmpty() {
+			return nil, errors.New("elastic: IPRangeAggregation range requires a mask, from or to")
+		}
 		r := make(map[string]interface{})
 		if ent.Key != "" {
 			r["key"] = ent.Key
@@ -173,22 +241,12 @@ func (a *IPRangeAggregation) Source() (interface{}, error) {
 	opts["ranges"] = ranges
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
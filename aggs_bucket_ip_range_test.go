@@ -0,0 +1,68 @@
+package aggretastic
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestIPRangeAggregation(t *testing.T) {
+	tests := []struct {
+		name string
+		agg  *IPRangeAggregation
+		want string
+	}{
+		{
+			"mask and bounds",
+			NewIPRangeAggregation().Field("ip").
+				AddMaskRange("10.0.0.0/25").
+				AddRange("10.0.0.5", "10.0.0.9").
+				AddUnboundedFrom("10.0.0.5"),
+			`{"ip_range":{"field":"ip","ranges":[{"mask":"10.0.0.0/25"},{"from":"10.0.0.5","to":"10.0.0.9"},{"to":"10.0.0.5"}]}}`,
+		},
+		{
+			"keyed",
+			NewIPRangeAggregation().Field("ip").Keyed(true).
+				AddMaskRangeWithKey("infra", "10.0.0.0/8").
+				AddRangeWithKey("office", "192.168.0.1", "192.168.0.255"),
+			`{"ip_range":{"field":"ip","keyed":true,"ranges":[{"key":"infra","mask":"10.0.0.0/8"},{"from":"192.168.0.1","key":"office","to":"192.168.0.255"}]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src, err := test.agg.Source()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.want {
+				t.Fatalf("expected %s, got %s", test.want, data)
+			}
+		})
+	}
+}
+
+func TestIPRangeAggregationRejectsEmptyRange(t *testing.T) {
+	tests := []struct {
+		name string
+		agg  *IPRangeAggregation
+	}{
+		{"range", NewIPRangeAggregation().Field("ip").AddMaskRange("10.0.0.0/8").AddRange("", "")},
+		{"range with key", NewIPRangeAggregation().Field("ip").AddRangeWithKey("all", "", "")},
+		{"mask", NewIPRangeAggregation().Field("ip").AddMaskRange("")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.agg.Source(); err == nil {
+				t.Fatal("expected an error from Source for the empty range")
+			}
+			if err := test.agg.Validate(); err == nil {
+				t.Fatal("expected an error from Validate for the empty range")
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package aggretastic
 
+import "errors"
+
 // MissingAggregation is a field data based single bucket aggregation,
 // that creates a bucket of all documents in the current document set context
 // that are missing a field value (effectively, missing a field or having
@@ -10,9 +12,9 @@ package aggretastic
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-missing-aggregation.html
 type MissingAggregation struct {
 	*tree
+	metaHolder
 
 	field string
-	meta  map[string]interface{}
 }
 
 func NewMissingAggregation() *MissingAggregation {
@@ -24,21 +26,58 @@ func NewMissingAggregation() *MissingAggregation {
 
 func (a *MissingAggregation) Field(field string) *MissingAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *MissingAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *MissingAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *MissingAggregation) SubAggregation(name string, subAggregation Aggregation) *MissingAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *MissingAggregation) Meta(metaData map[string]interface{}) *MissingAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *MissingAggregation) AddMeta(key string, value interface{}) *MissingAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *MissingAggregation) AggregationType() string {
+	return "missing"
+}
+
+func (a *MissingAggregation) Validate() error {
+	if a.field == "" {
+		return errors.New("elastic: MissingAggregation requires a field")
+	}
+
+	return nil
+}
+
 func (a *MissingAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *MissingAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -58,22 +97,12 @@ func (a *MissingAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -0,0 +1,58 @@
+package aggretastic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CollectMode is the collect mode of the terms aggregation.
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-terms-aggregation.html#search-aggregations-bucket-terms-aggregation-collect
+type CollectMode string
+
+// Collect modes of the terms aggregation
+const (
+	CollectModeDepthFirst   CollectMode = "depth_first"
+	CollectModeBreadthFirst CollectMode = "breadth_first"
+)
+
+// ExecutionHint is the execution hint of the terms based and the diversified sampler aggregations.
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-terms-aggregation.html#search-aggregations-bucket-terms-aggregation-execution-hint
+type ExecutionHint string
+
+// Execution hints of the terms based and the diversified sampler aggregations
+const (
+	ExecutionHintMap            ExecutionHint = "map"
+	ExecutionHintGlobalOrdinals ExecutionHint = "global_ordinals"
+	// ExecutionHintBytesHash is known by the diversified sampler aggregation only
+	ExecutionHintBytesHash ExecutionHint = "bytes_hash"
+)
+
+// termsExecutionHints are the execution hints known by the terms and the significant terms aggregations
+var termsExecutionHints = []ExecutionHint{ExecutionHintMap, ExecutionHintGlobalOrdinals}
+
+// samplerExecutionHints are the execution hints known by the diversified sampler aggregation
+var samplerExecutionHints = []ExecutionHint{ExecutionHintMap, ExecutionHintGlobalOrdinals, ExecutionHintBytesHash}
+
+// checkCollectMode returns an error if the collect mode is unknown
+func checkCollectMode(mode CollectMode) error {
+	switch mode {
+	case CollectModeDepthFirst, CollectModeBreadthFirst:
+		return nil
+	default:
+		return fmt.Errorf("elastic: unknown collect mode %q, expected %q or %q",
+			mode, CollectModeDepthFirst, CollectModeBreadthFirst)
+	}
+}
+
+// checkExecutionHint returns an error if the hint isn't one of the known ones
+func checkExecutionHint(hint ExecutionHint, known []ExecutionHint) error {
+	names := make([]string, len(known))
+	for i, k := range known {
+		if hint == k {
+			return nil
+		}
+		names[i] = fmt.Sprintf("%q", k)
+	}
+
+	return fmt.Errorf("elastic: unknown execution hint %q, expected one of %s", hint, strings.Join(names, ", "))
+}
@@ -0,0 +1,48 @@
+package aggretastic
+
+import "testing"
+
+func TestTypedModesAreValidated(t *testing.T) {
+	tests := []struct {
+		name  string
+		agg   Aggregation
+		valid bool
+	}{
+		{"terms collect mode", NewTermsAggregation().Field("f").TypedCollectionMode(CollectModeBreadthFirst), true},
+		{"terms unknown collect mode", NewTermsAggregation().Field("f").TypedCollectionMode("breadth"), false},
+		{"terms hint", NewTermsAggregation().Field("f").TypedExecutionHint(ExecutionHintMap), true},
+		{"terms sampler hint", NewTermsAggregation().Field("f").TypedExecutionHint(ExecutionHintBytesHash), false},
+		{"significant terms hint", NewSignificantTermsAggregation().Field("f").TypedExecutionHint(ExecutionHintGlobalOrdinals), true},
+		{"significant terms unknown hint", NewSignificantTermsAggregation().Field("f").TypedExecutionHint("maps"), false},
+		{"sampler hint", NewDiversifiedSamplerAggregation().Field("f").TypedExecutionHint(ExecutionHintBytesHash), true},
+		{"sampler unknown hint", NewDiversifiedSamplerAggregation().Field("f").TypedExecutionHint("hash"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.agg.Validate(); (err == nil) != test.valid {
+				t.Fatalf("expected valid %v, got %v", test.valid, err)
+			}
+			// the source is rendered anyway, the validation is up to Validate
+			if _, err := test.agg.Source(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestStringModesPassThrough(t *testing.T) {
+	agg := NewTermsAggregation().Field("f").
+		TypedCollectionMode("breadth").
+		CollectionMode("future_mode").
+		TypedExecutionHint("maps").
+		ExecutionHint("future_hint")
+
+	if err := agg.Validate(); err != nil {
+		t.Fatalf("expected the raw strings not validated, got %v", err)
+	}
+	want := `{"terms":{"collect_mode":"future_mode","execution_hint":"future_hint","field":"f"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
@@ -0,0 +1,176 @@
+package aggretastic
+
+import "errors"
+
+// MultiTermsAggregation is a multi-bucket value source based aggregation
+// where buckets are dynamically built - one per unique set of values.
+// It is similar to a composite aggregation of terms sources, but supports
+// ordering by doc count or sub-aggregations.
+//
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/7.12/search-aggregations-bucket-multi-terms-aggregation.html
+type MultiTermsAggregation struct {
+	*tree
+	metaHolder
+
+	terms       []MultiTermsField
+	size        *int
+	shardSize   *int
+	minDocCount *int
+	order       []TermsOrder
+}
+
+// MultiTermsField specifies a single term source of a MultiTermsAggregation.
+type MultiTermsField struct {
+	Field   string
+	Missing interface{}
+}
+
+// Source returns serializable JSON of the MultiTermsField.
+func (f *MultiTermsField) Source() (interface{}, error) {
+	source := make(map[string]interface{})
+	source["field"] = f.Field
+	if f.Missing != nil {
+		source["missing"] = f.Missing
+	}
+	return source, nil
+}
+
+func NewMultiTermsAggregation() *MultiTermsAggregation {
+	a := &MultiTermsAggregation{}
+	a.tree = nilAggregationTree(a)
+
+	return a
+}
+
+// Terms adds the fields to build the composed bucket key from.
+// The key parts keep the order the fields were added in.
+func (a *MultiTermsAggregation) Terms(terms ...MultiTermsField) *MultiTermsAggregation {
+	a.terms = append(a.terms, terms...)
+	a.markDirty()
+	return a
+}
+
+func (a *MultiTermsAggregation) SubAggregation(name string, subAggregation Aggregation) *MultiTermsAggregation {
+	a.setSub(name, subAggregation)
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *MultiTermsAggregation) Meta(metaData map[string]interface{}) *MultiTermsAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *MultiTermsAggregation) AddMeta(key string, value interface{}) *MultiTermsAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+func (a *MultiTermsAggregation) Size(size int) *MultiTermsAggregation {
+	a.size = &size
+	a.markDirty()
+	return a
+}
+
+func (a *MultiTermsAggregation) ShardSize(shardSize int) *MultiTermsAggregation {
+	a.shardSize = &shardSize
+	a.markDirty()
+	return a
+}
+
+func (a *MultiTermsAggregation) MinDocCount(minDocCount int) *MultiTermsAggregation {
+	a.minDocCount = &minDocCount
+	a.markDirty()
+	return a
+}
+
+// Order specifies the sort order. Valid values for order are:
+// "_key", "_count", a sub-aggregation name, or a sub-aggregation name
+// with a metric.
+func (a *MultiTermsAggregation) Order(order string, asc bool) *MultiTermsAggregation {
+	a.order = append(a.order, TermsOrder{Field: order, Ascending: asc})
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *MultiTermsAggregation) AggregationType() string {
+	return "multi_terms"
+}
+
+func (a *MultiTermsAggregation) Validate() error {
+	if len(a.terms) == 0 {
+		return errors.New("elastic: MultiTermsAggregation requires at least one term")
+	}
+
+	return nil
+}
+
+func (a *MultiTermsAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *MultiTermsAggregation) source(depth int) (interface{}, error) {
+	// Example:
+	//	{
+	//    "aggs" : {
+	//      "genres_and_products" : {
+	//        "multi_terms" : {
+	//          "terms" : [
+	//            { "field" : "genre" },
+	//            { "field" : "product" }
+	//          ]
+	//        }
+	//      }
+	//    }
+	//	}
+	// This method returns only the { "multi_terms" : { ... } } part.
+
+	source := make(map[string]interface{})
+	opts := make(map[string]interface{})
+	source["multi_terms"] = opts
+
+	terms := make([]interface{}, len(a.terms))
+	for i, term := range a.terms {
+		src, err := term.Source()
+		if err != nil {
+			return nil, err
+		}
+		terms[i] = src
+	}
+	opts["terms"] = terms
+
+	if a.size != nil {
+		opts["size"] = *a.size
+	}
+	if a.shardSize != nil {
+		opts["shard_size"] = *a.shardSize
+	}
+	if a.minDocCount != nil {
+		opts["min_doc_count"] = *a.minDocCount
+	}
+	if len(a.order) > 0 {
+		var orderSlice []interface{}
+		for _, order := range a.order {
+			src, err := order.Source()
+			if err != nil {
+				return nil, err
+			}
+			orderSlice = append(orderSlice, src)
+		}
+		opts["order"] = orderSlice
+	}
+
+	// AggregationBuilder (SubAggregations)
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
@@ -0,0 +1,23 @@
+package aggretastic
+
+import "testing"
+
+func TestMultiTermsAggregation(t *testing.T) {
+	agg := NewMultiTermsAggregation().
+		Terms(MultiTermsField{Field: "product"}, MultiTermsField{Field: "genre", Missing: "none"}).
+		Terms(MultiTermsField{Field: "artist"}).
+		Size(10).MinDocCount(1).
+		Order("_count", false).Order("_key", true)
+
+	want := `{"multi_terms":{"min_doc_count":1,"order":[{"_count":"desc"},{"_key":"asc"}],"size":10,` +
+		`"terms":[{"field":"product"},{"field":"genre","missing":"none"},{"field":"artist"}]}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestMultiTermsAggregationRequiresTerms(t *testing.T) {
+	if err := NewMultiTermsAggregation().Size(10).Validate(); err == nil {
+		t.Fatal("expected an error for no terms")
+	}
+}
@@ -1,13 +1,15 @@
 package aggretastic
 
+import "errors"
+
 // NestedAggregation is a special single bucket aggregation that enables
 // aggregating nested documents.
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-nested-aggregation.html
 type NestedAggregation struct {
 	*tree
+	metaHolder
 
 	path string
-	meta map[string]interface{}
 }
 
 func NewNestedAggregation() *NestedAggregation {
@@ -18,22 +20,48 @@ func NewNestedAggregation() *NestedAggregation {
 }
 
 func (a *NestedAggregation) SubAggregation(name string, subAggregation Aggregation) *NestedAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *NestedAggregation) Meta(metaData map[string]interface{}) *NestedAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *NestedAggregation) AddMeta(key string, value interface{}) *NestedAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 func (a *NestedAggregation) Path(path string) *NestedAggregation {
 	a.path = path
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *NestedAggregation) AggregationType() string {
+	return "nested"
+}
+
+func (a *NestedAggregation) Validate() error {
+	if a.path == "" {
+		return errors.New("elastic: NestedAggregation requires a path")
+	}
+
+	return nil
+}
+
 func (a *NestedAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *NestedAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//     "query" : {
@@ -59,22 +87,12 @@ func (a *NestedAggregation) Source() (interface{}, error) {
 	opts["path"] = a.path
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
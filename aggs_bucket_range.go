@@ -1,6 +1,7 @@
 package aggretastic
 
 import (
+	"errors"
 	"github.com/olivere/elastic"
 	"time"
 )
@@ -14,11 +15,11 @@ import (
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-range-aggregation.html
 type RangeAggregation struct {
 	*tree
+	metaHolder
 
 	field    string
 	script   *elastic.Script
 	missing  interface{}
-	meta     map[string]interface{}
 	keyed    *bool
 	unmapped *bool
 	entries  []rangeAggregationEntry
@@ -30,6 +31,14 @@ type rangeAggregationEntry struct {
 	To   interface{}
 }
 
+// RangeEntry is a numeric range of RangeAggregation, e.g. loaded from a configuration.
+// A nil bound leaves the range unbounded on that side, an empty key isn't rendered.
+type RangeEntry struct {
+	Key  string
+	From *float64
+	To   *float64
+}
+
 func NewRangeAggregation() *RangeAggregation {
 	a := &RangeAggregation{
 		entries: make([]rangeAggregationEntry, 0),
@@ -41,102 +50,180 @@ func NewRangeAggregation() *RangeAggregation {
 
 func (a *RangeAggregation) Field(field string) *RangeAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *RangeAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *RangeAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *RangeAggregation) Script(script *elastic.Script) *RangeAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *RangeAggregation) StoredScript(id string, params map[string]interface{}) *RangeAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 // Missing configures the value to use when documents miss a value.
 func (a *RangeAggregation) Missing(missing interface{}) *RangeAggregation {
 	a.missing = missing
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) SubAggregation(name string, subAggregation Aggregation) *RangeAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *RangeAggregation) Meta(metaData map[string]interface{}) *RangeAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *RangeAggregation) AddMeta(key string, value interface{}) *RangeAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) Keyed(keyed bool) *RangeAggregation {
 	a.keyed = &keyed
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) Unmapped(unmapped bool) *RangeAggregation {
 	a.unmapped = &unmapped
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) AddRange(from, to interface{}) *RangeAggregation {
 	a.entries = append(a.entries, rangeAggregationEntry{From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) AddRangeWithKey(key string, from, to interface{}) *RangeAggregation {
 	a.entries = append(a.entries, rangeAggregationEntry{Key: key, From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) AddUnboundedTo(from interface{}) *RangeAggregation {
 	a.entries = append(a.entries, rangeAggregationEntry{From: from, To: nil})
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) AddUnboundedToWithKey(key string, from interface{}) *RangeAggregation {
 	a.entries = append(a.entries, rangeAggregationEntry{Key: key, From: from, To: nil})
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) AddUnboundedFrom(to interface{}) *RangeAggregation {
 	a.entries = append(a.entries, rangeAggregationEntry{From: nil, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) AddUnboundedFromWithKey(key string, to interface{}) *RangeAggregation {
 	a.entries = append(a.entries, rangeAggregationEntry{Key: key, From: nil, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) Lt(to interface{}) *RangeAggregation {
 	a.entries = append(a.entries, rangeAggregationEntry{From: nil, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) LtWithKey(key string, to interface{}) *RangeAggregation {
 	a.entries = append(a.entries, rangeAggregationEntry{Key: key, From: nil, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) Between(from, to interface{}) *RangeAggregation {
 	a.entries = append(a.entries, rangeAggregationEntry{From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) BetweenWithKey(key string, from, to interface{}) *RangeAggregation {
 	a.entries = append(a.entries, rangeAggregationEntry{Key: key, From: from, To: to})
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) Gt(from interface{}) *RangeAggregation {
 	a.entries = append(a.entries, rangeAggregationEntry{From: from, To: nil})
+	a.markDirty()
 	return a
 }
 
 func (a *RangeAggregation) GtWithKey(key string, from interface{}) *RangeAggregation {
 	a.entries = append(a.entries, rangeAggregationEntry{Key: key, From: from, To: nil})
+	a.markDirty()
 	return a
 }
 
+// Ranges adds the ranges in the order of the slice (after the ones added before).
+func (a *RangeAggregation) Ranges(ranges []RangeEntry) *RangeAggregation {
+	for _, r := range ranges {
+		entry := rangeAggregationEntry{Key: r.Key}
+		// the bounds are copied, so the caller may reuse the slice
+		if r.From != nil {
+			entry.From = *r.From
+		}
+		if r.To != nil {
+			entry.To = *r.To
+		}
+		a.entries = append(a.entries, entry)
+	}
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *RangeAggregation) AggregationType() string {
+	return "range"
+}
+
+func (a *RangeAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: RangeAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
 func (a *RangeAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *RangeAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	// {
 	//     "aggs" : {
@@ -224,21 +311,11 @@ func (a *RangeAggregation) Source() (interface{}, error) {
 	opts["ranges"] = ranges
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 	return source, nil
 }
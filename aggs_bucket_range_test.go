@@ -0,0 +1,82 @@
+package aggretastic
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRangeFamilyKeyed(t *testing.T) {
+	tests := []struct {
+		keyed   Aggregation
+		unkeyed Aggregation
+		unset   Aggregation
+	}{
+		{
+			NewRangeAggregation().Field("f").Lt(10).Keyed(true),
+			NewRangeAggregation().Field("f").Lt(10).Keyed(false),
+			NewRangeAggregation().Field("f").Lt(10),
+		},
+		{
+			NewDateRangeAggregation().Field("f").Lt(10).Keyed(true),
+			NewDateRangeAggregation().Field("f").Lt(10).Keyed(false),
+			NewDateRangeAggregation().Field("f").Lt(10),
+		},
+		{
+			NewIPRangeAggregation().Field("f").Lt("10").Keyed(true),
+			NewIPRangeAggregation().Field("f").Lt("10").Keyed(false),
+			NewIPRangeAggregation().Field("f").Lt("10"),
+		},
+		{
+			NewGeoDistanceAggregation().Field("f").AddUnboundedFrom(10).Keyed(true),
+			NewGeoDistanceAggregation().Field("f").AddUnboundedFrom(10).Keyed(false),
+			NewGeoDistanceAggregation().Field("f").AddUnboundedFrom(10),
+		},
+	}
+
+	for _, test := range tests {
+		typ := test.keyed.AggregationType()
+		to := quoteIf(typ == "ip_range", "10")
+		t.Run(typ, func(t *testing.T) {
+			want := `{"` + typ + `":{"field":"f","keyed":true,"ranges":[{"to":` + to + `}]}}`
+			if test.keyed.String() != want {
+				t.Fatalf("expected %s, got %s", want, test.keyed)
+			}
+			// an explicit false is rendered, even though it's the default
+			want = `{"` + typ + `":{"field":"f","keyed":false,"ranges":[{"to":` + to + `}]}}`
+			if test.unkeyed.String() != want {
+				t.Fatalf("expected %s, got %s", want, test.unkeyed)
+			}
+			want = `{"` + typ + `":{"field":"f","ranges":[{"to":` + to + `}]}}`
+			if test.unset.String() != want {
+				t.Fatalf("expected %s, got %s", want, test.unset)
+			}
+		})
+	}
+}
+
+func quoteIf(quote bool, s string) string {
+	if quote {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+func TestRangeAggregationRanges(t *testing.T) {
+	var config []struct {
+		Key      string
+		From, To *float64
+	}
+	if err := json.Unmarshal([]byte(`[{"Key":"cheap","To":50},{"From":50,"To":100},{"Key":"pricey","From":100}]`), &config); err != nil {
+		t.Fatal(err)
+	}
+	ranges := make([]RangeEntry, len(config))
+	for i, c := range config {
+		ranges[i] = RangeEntry{Key: c.Key, From: c.From, To: c.To}
+	}
+
+	agg := NewRangeAggregation().Field("price").AddRange(0, 1).Ranges(ranges)
+	want := `{"range":{"field":"price","ranges":[{"from":0,"to":1},{"key":"cheap","to":50},{"from":50,"to":100},{"from":100,"key":"pricey"}]}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
@@ -0,0 +1,195 @@
+package aggretastic
+
+import "errors"
+
+// RareTermsAggregation is a multi-bucket value source based aggregation
+// which finds "rare" terms — terms that are at the long-tail of the
+// distribution and are not frequent.
+//
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/7.3/search-aggregations-bucket-rare-terms-aggregation.html
+type RareTermsAggregation struct {
+	*tree
+	metaHolder
+
+	field   string
+	missing interface{}
+
+	maxDocCount    *int64
+	precision      *float64
+	includeExclude *TermsAggregationIncludeExclude
+}
+
+func NewRareTermsAggregation() *RareTermsAggregation {
+	a := &RareTermsAggregation{}
+	a.tree = nilAggregationTree(a)
+
+	return a
+}
+
+func (a *RareTermsAggregation) Field(field string) *RareTermsAggregation {
+	a.field = field
+	a.markDirty()
+	return a
+}
+
+// GetField returns the field the aggregation works on.
+func (a *RareTermsAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *RareTermsAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
+// Missing configures the value to use when documents miss a value.
+func (a *RareTermsAggregation) Missing(missing interface{}) *RareTermsAggregation {
+	a.missing = missing
+	a.markDirty()
+	return a
+}
+
+func (a *RareTermsAggregation) SubAggregation(name string, subAggregation Aggregation) *RareTermsAggregation {
+	a.setSub(name, subAggregation)
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *RareTermsAggregation) Meta(metaData map[string]interface{}) *RareTermsAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *RareTermsAggregation) AddMeta(key string, value interface{}) *RareTermsAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// MaxDocCount is the maximum number of documents a term should appear in
+// to be considered rare. Defaults to 1.
+func (a *RareTermsAggregation) MaxDocCount(maxDocCount int64) *RareTermsAggregation {
+	a.maxDocCount = &maxDocCount
+	a.markDirty()
+	return a
+}
+
+// Precision of the internal CuckooFilters. Smaller precision leads to
+// better approximation, but higher memory usage. Defaults to 0.001.
+func (a *RareTermsAggregation) Precision(precision float64) *RareTermsAggregation {
+	a.precision = &precision
+	a.markDirty()
+	return a
+}
+
+func (a *RareTermsAggregation) Include(regexp string) *RareTermsAggregation {
+	if a.includeExclude == nil {
+		a.includeExclude = &TermsAggregationIncludeExclude{}
+	}
+	a.includeExclude.Include = regexp
+	a.markDirty()
+	return a
+}
+
+func (a *RareTermsAggregation) IncludeValues(values ...interface{}) *RareTermsAggregation {
+	if a.includeExclude == nil {
+		a.includeExclude = &TermsAggregationIncludeExclude{}
+	}
+	a.includeExclude.IncludeValues = append(a.includeExclude.IncludeValues, values...)
+	a.markDirty()
+	return a
+}
+
+func (a *RareTermsAggregation) Exclude(regexp string) *RareTermsAggregation {
+	if a.includeExclude == nil {
+		a.includeExclude = &TermsAggregationIncludeExclude{}
+	}
+	a.includeExclude.Exclude = regexp
+	a.markDirty()
+	return a
+}
+
+func (a *RareTermsAggregation) ExcludeValues(values ...interface{}) *RareTermsAggregation {
+	if a.includeExclude == nil {
+		a.includeExclude = &TermsAggregationIncludeExclude{}
+	}
+	a.includeExclude.ExcludeValues = append(a.includeExclude.ExcludeValues, values...)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *RareTermsAggregation) AggregationType() string {
+	return "rare_terms"
+}
+
+func (a *RareTermsAggregation) Validate() error {
+	if a.field == "" {
+		return errors.New("elastic: RareTermsAggregation requires a field")
+	}
+
+	return nil
+}
+
+func (a *RareTermsAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *RareTermsAggregation) source(depth int) (interface{}, error) {
+	// Example:
+	//	{
+	//    "aggs" : {
+	//      "genres" : {
+	//        "rare_terms" : { "field" : "genre", "max_doc_count" : 2 }
+	//      }
+	//    }
+	//	}
+	// This method returns only the { "rare_terms" : { ... } } part.
+
+	source := make(map[string]interface{})
+	opts := make(map[string]interface{})
+	source["rare_terms"] = opts
+
+	// ValuesSourceAggregationBuilder
+	if a.field != "" {
+		opts["field"] = a.field
+	}
+	if a.missing != nil {
+		opts["missing"] = a.missing
+	}
+
+	if a.maxDocCount != nil {
+		opts["max_doc_count"] = *a.maxDocCount
+	}
+	if a.precision != nil {
+		opts["precision"] = *a.precision
+	}
+	// Include/Exclude
+	if ie := a.includeExclude; ie != nil {
+		// Include
+		if ie.Include != "" {
+			opts["include"] = ie.Include
+		} else if len(ie.IncludeValues) > 0 {
+			opts["include"] = ie.IncludeValues
+		}
+		// Exclude
+		if ie.Exclude != "" {
+			opts["exclude"] = ie.Exclude
+		} else if len(ie.ExcludeValues) > 0 {
+			opts["exclude"] = ie.ExcludeValues
+		}
+	}
+
+	// AggregationBuilder (SubAggregations)
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
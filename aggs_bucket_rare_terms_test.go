@@ -0,0 +1,23 @@
+package aggretastic
+
+import "testing"
+
+func TestRareTermsAggregation(t *testing.T) {
+	agg := NewRareTermsAggregation().Field("genre").MaxDocCount(2).
+		Include("swi.*").Exclude("electro.*").
+		SubAggregation("avg", NewAvgAggregation().Field("price"))
+
+	want := `{"aggregations":{"avg":{"avg":{"field":"price"}}},"rare_terms":{"exclude":"electro.*","field":"genre","include":"swi.*","max_doc_count":2}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestRareTermsAggregationIncludeExcludeValues(t *testing.T) {
+	agg := NewRareTermsAggregation().Field("genre").IncludeValues("rock", "jazz").ExcludeValues("pop").Missing("none")
+
+	want := `{"rare_terms":{"exclude":["pop"],"field":"genre","include":["rock","jazz"],"missing":"none"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
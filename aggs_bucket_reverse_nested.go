@@ -10,9 +10,9 @@ package aggretastic
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-reverse-nested-aggregation.html
 type ReverseNestedAggregation struct {
 	*tree
+	metaHolder
 
 	path string
-	meta map[string]interface{}
 }
 
 // NewReverseNestedAggregation initializes a new ReverseNestedAggregation
@@ -29,21 +29,39 @@ func NewReverseNestedAggregation() *ReverseNestedAggregation {
 // then this aggregation will go back to the root document.
 func (a *ReverseNestedAggregation) Path(path string) *ReverseNestedAggregation {
 	a.path = path
+	a.markDirty()
 	return a
 }
 
 func (a *ReverseNestedAggregation) SubAggregation(name string, subAggregation Aggregation) *ReverseNestedAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *ReverseNestedAggregation) Meta(metaData map[string]interface{}) *ReverseNestedAggregation {
 	a.meta = metaData
+	a.markDirty()
 	return a
 }
 
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *ReverseNestedAggregation) AddMeta(key string, value interface{}) *ReverseNestedAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *ReverseNestedAggregation) AggregationType() string {
+	return "reverse_nested"
+}
+
 func (a *ReverseNestedAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *ReverseNestedAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -63,22 +81,12 @@ func (a *ReverseNestedAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -8,52 +8,68 @@ package aggretastic
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-sampler-aggregation.html
 type SamplerAggregation struct {
 	*tree
+	metaHolder
 
-	meta map[string]interface{}
-
-	shardSize       int
-	maxDocsPerValue int
+	shardSize       *int
+	maxDocsPerValue *int
 	executionHint   string
 }
 
 func NewSamplerAggregation() *SamplerAggregation {
-	a := &SamplerAggregation{
-		shardSize:       -1,
-		maxDocsPerValue: -1,
-	}
+	a := &SamplerAggregation{}
 	a.tree = nilAggregationTree(a)
 
 	return a
 }
 
 func (a *SamplerAggregation) SubAggregation(name string, subAggregation Aggregation) *SamplerAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *SamplerAggregation) Meta(metaData map[string]interface{}) *SamplerAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *SamplerAggregation) AddMeta(key string, value interface{}) *SamplerAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 // ShardSize sets the maximum number of docs returned from each shard.
 func (a *SamplerAggregation) ShardSize(shardSize int) *SamplerAggregation {
-	a.shardSize = shardSize
+	a.shardSize = &shardSize
+	a.markDirty()
 	return a
 }
 
 func (a *SamplerAggregation) MaxDocsPerValue(maxDocsPerValue int) *SamplerAggregation {
-	a.maxDocsPerValue = maxDocsPerValue
+	a.maxDocsPerValue = &maxDocsPerValue
+	a.markDirty()
 	return a
 }
 
 func (a *SamplerAggregation) ExecutionHint(hint string) *SamplerAggregation {
 	a.executionHint = hint
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *SamplerAggregation) AggregationType() string {
+	return "sampler"
+}
+
 func (a *SamplerAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *SamplerAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	// {
 	//     "aggs" : {
@@ -78,33 +94,23 @@ func (a *SamplerAggregation) Source() (interface{}, error) {
 	opts := make(map[string]interface{})
 	source["sampler"] = opts
 
-	if a.shardSize >= 0 {
-		opts["shard_size"] = a.shardSize
+	if a.shardSize != nil {
+		opts["shard_size"] = *a.shardSize
 	}
-	if a.maxDocsPerValue >= 0 {
-		opts["max_docs_per_value"] = a.maxDocsPerValue
+	if a.maxDocsPerValue != nil {
+		opts["max_docs_per_value"] = *a.maxDocsPerValue
 	}
 	if a.executionHint != "" {
 		opts["execution_hint"] = a.executionHint
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -0,0 +1,28 @@
+package aggretastic
+
+import "testing"
+
+func TestExplicitZeroIntegersAreRendered(t *testing.T) {
+	tests := []struct {
+		name string
+		agg  Aggregation
+		want string
+	}{
+		{"sampler", NewSamplerAggregation().ShardSize(0).MaxDocsPerValue(0), `{"sampler":{"max_docs_per_value":0,"shard_size":0}}`},
+		{"sampler unset", NewSamplerAggregation(), `{"sampler":{}}`},
+		{"diversified sampler", NewDiversifiedSamplerAggregation().Field("f").ShardSize(0).MaxDocsPerValue(0), `{"diversified_sampler":{"field":"f","max_docs_per_value":0,"shard_size":0}}`},
+		{"geohash grid", NewGeoHashGridAggregation().Field("loc").Size(0).ShardSize(0), `{"geohash_grid":{"field":"loc","shard_size":0,"size":0}}`},
+		{"bucket sort", NewBucketSortAggregation().From(0).Size(0), `{"bucket_sort":{"from":0,"size":0}}`},
+		{"terms", NewTermsAggregation().Field("f").Size(0).MinDocCount(0), `{"terms":{"field":"f","min_doc_count":0,"size":0}}`},
+		{"terms negative", NewTermsAggregation().Field("f").ShardSize(-1), `{"terms":{"field":"f","shard_size":-1}}`},
+		{"multi terms", NewMultiTermsAggregation().Terms(MultiTermsField{Field: "f"}).MinDocCount(0), `{"multi_terms":{"min_doc_count":0,"terms":[{"field":"f"}]}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.agg.String() != test.want {
+				t.Fatalf("expected %s, got %s", test.want, test.agg)
+			}
+		})
+	}
+}
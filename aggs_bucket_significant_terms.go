@@ -1,22 +1,27 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // SignificantTermsAggregation is an aggregation that returns interesting
 // or unusual occurrences of terms in a set.
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-significantterms-aggregation.html
 type SignificantTermsAggregation struct {
 	*tree
+	metaHolder
 
 	field string
-	meta  map[string]interface{}
 
 	minDocCount           *int
 	shardMinDocCount      *int
 	requiredSize          *int
 	shardSize             *int
 	filter                elastic.Query
+	filterRaw             map[string]interface{}
 	executionHint         string
+	executionHintErr      error
 	significanceHeuristic SignificanceHeuristic
 }
 
@@ -29,56 +34,122 @@ func NewSignificantTermsAggregation() *SignificantTermsAggregation {
 
 func (a *SignificantTermsAggregation) Field(field string) *SignificantTermsAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *SignificantTermsAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *SignificantTermsAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *SignificantTermsAggregation) SubAggregation(name string, subAggregation Aggregation) *SignificantTermsAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *SignificantTermsAggregation) Meta(metaData map[string]interface{}) *SignificantTermsAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *SignificantTermsAggregation) AddMeta(key string, value interface{}) *SignificantTermsAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 func (a *SignificantTermsAggregation) MinDocCount(minDocCount int) *SignificantTermsAggregation {
 	a.minDocCount = &minDocCount
+	a.markDirty()
 	return a
 }
 
 func (a *SignificantTermsAggregation) ShardMinDocCount(shardMinDocCount int) *SignificantTermsAggregation {
 	a.shardMinDocCount = &shardMinDocCount
+	a.markDirty()
 	return a
 }
 
 func (a *SignificantTermsAggregation) RequiredSize(requiredSize int) *SignificantTermsAggregation {
 	a.requiredSize = &requiredSize
+	a.markDirty()
 	return a
 }
 
 func (a *SignificantTermsAggregation) ShardSize(shardSize int) *SignificantTermsAggregation {
 	a.shardSize = &shardSize
+	a.markDirty()
 	return a
 }
 
 func (a *SignificantTermsAggregation) BackgroundFilter(filter elastic.Query) *SignificantTermsAggregation {
 	a.filter = filter
+	a.markDirty()
+	return a
+}
+
+// BackgroundFilterRaw sets an already built query body to be used as the
+// background filter as is. It takes precedence over the query set with BackgroundFilter.
+func (a *SignificantTermsAggregation) BackgroundFilterRaw(raw map[string]interface{}) *SignificantTermsAggregation {
+	a.filterRaw = raw
+	a.markDirty()
 	return a
 }
 
+// ExecutionHint sets the hint as it is, see TypedExecutionHint for the known ones.
 func (a *SignificantTermsAggregation) ExecutionHint(hint string) *SignificantTermsAggregation {
 	a.executionHint = hint
+	a.executionHintErr = nil
+	a.markDirty()
+	return a
+}
+
+// TypedExecutionHint sets ExecutionHintMap or ExecutionHintGlobalOrdinals,
+// any other hint makes Validate return an error.
+func (a *SignificantTermsAggregation) TypedExecutionHint(hint ExecutionHint) *SignificantTermsAggregation {
+	a.executionHint = string(hint)
+	a.executionHintErr = checkExecutionHint(hint, termsExecutionHints)
+	a.markDirty()
 	return a
 }
 
 func (a *SignificantTermsAggregation) SignificanceHeuristic(heuristic SignificanceHeuristic) *SignificantTermsAggregation {
 	a.significanceHeuristic = heuristic
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *SignificantTermsAggregation) AggregationType() string {
+	return "significant_terms"
+}
+
+func (a *SignificantTermsAggregation) Validate() error {
+	if a.field == "" {
+		return errors.New("elastic: SignificantTermsAggregation requires a field")
+	}
+	if a.executionHintErr != nil {
+		return a.executionHintErr
+	}
+
+	return nil
+}
+
 func (a *SignificantTermsAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *SignificantTermsAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	// {
 	//     "query" : {
@@ -117,7 +188,10 @@ func (a *SignificantTermsAggregation) Source() (interface{}, error) {
 	if a.executionHint != "" {
 		opts["execution_hint"] = a.executionHint
 	}
-	if a.filter != nil {
+	switch {
+	case a.filterRaw != nil:
+		opts["background_filter"] = a.filterRaw
+	case a.filter != nil:
 		src, err := a.filter.Source()
 		if err != nil {
 			return nil, err
@@ -134,22 +208,12 @@ func (a *SignificantTermsAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
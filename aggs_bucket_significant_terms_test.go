@@ -0,0 +1,36 @@
+package aggretastic
+
+import (
+	"github.com/olivere/elastic"
+	"testing"
+)
+
+func TestSignificantBackgroundFilter(t *testing.T) {
+	typed := elastic.NewTermQuery("text", "spain")
+	raw := map[string]interface{}{"term": map[string]interface{}{"text": "france"}}
+
+	tests := []struct {
+		name string
+		agg  Aggregation
+		want string
+	}{
+		{"terms typed", NewSignificantTermsAggregation().Field("f").BackgroundFilter(typed),
+			`{"significant_terms":{"background_filter":{"term":{"text":"spain"}},"field":"f"}}`},
+		{"terms raw", NewSignificantTermsAggregation().Field("f").BackgroundFilterRaw(raw),
+			`{"significant_terms":{"background_filter":{"term":{"text":"france"}},"field":"f"}}`},
+		{"terms raw wins", NewSignificantTermsAggregation().Field("f").BackgroundFilterRaw(raw).BackgroundFilter(typed),
+			`{"significant_terms":{"background_filter":{"term":{"text":"france"}},"field":"f"}}`},
+		{"text typed", NewSignificantTextAggregation().Field("f").BackgroundFilter(typed),
+			`{"significant_text":{"background_filter":{"term":{"text":"spain"}},"field":"f"}}`},
+		{"text raw wins", NewSignificantTextAggregation().Field("f").BackgroundFilter(typed).BackgroundFilterRaw(raw),
+			`{"significant_text":{"background_filter":{"term":{"text":"france"}},"field":"f"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.agg.String() != test.want {
+				t.Fatalf("expected %s, got %s", test.want, test.agg)
+			}
+		})
+	}
+}
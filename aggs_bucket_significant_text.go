@@ -1,20 +1,24 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // SignificantTextAggregation returns interesting or unusual occurrences
 // of free-text terms in a set.
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-significanttext-aggregation.html
 type SignificantTextAggregation struct {
 	*tree
+	metaHolder
 
 	field string
-	meta  map[string]interface{}
 
 	sourceFieldNames      []string
 	filterDuplicateText   *bool
 	includeExclude        *TermsAggregationIncludeExclude
 	filter                elastic.Query
+	filterRaw             map[string]interface{}
 	bucketCountThresholds *BucketCountThresholds
 	significanceHeuristic SignificanceHeuristic
 }
@@ -28,27 +32,49 @@ func NewSignificantTextAggregation() *SignificantTextAggregation {
 
 func (a *SignificantTextAggregation) Field(field string) *SignificantTextAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *SignificantTextAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *SignificantTextAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *SignificantTextAggregation) SubAggregation(name string, subAggregation Aggregation) *SignificantTextAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *SignificantTextAggregation) Meta(metaData map[string]interface{}) *SignificantTextAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *SignificantTextAggregation) AddMeta(key string, value interface{}) *SignificantTextAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 func (a *SignificantTextAggregation) SourceFieldNames(names ...string) *SignificantTextAggregation {
 	a.sourceFieldNames = names
+	a.markDirty()
 	return a
 }
 
 func (a *SignificantTextAggregation) FilterDuplicateText(filter bool) *SignificantTextAggregation {
 	a.filterDuplicateText = &filter
+	a.markDirty()
 	return a
 }
 
@@ -57,6 +83,7 @@ func (a *SignificantTextAggregation) MinDocCount(minDocCount int64) *Significant
 		a.bucketCountThresholds = &BucketCountThresholds{}
 	}
 	a.bucketCountThresholds.MinDocCount = &minDocCount
+	a.markDirty()
 	return a
 }
 
@@ -65,6 +92,7 @@ func (a *SignificantTextAggregation) ShardMinDocCount(shardMinDocCount int64) *S
 		a.bucketCountThresholds = &BucketCountThresholds{}
 	}
 	a.bucketCountThresholds.ShardMinDocCount = &shardMinDocCount
+	a.markDirty()
 	return a
 }
 
@@ -73,6 +101,7 @@ func (a *SignificantTextAggregation) Size(size int) *SignificantTextAggregation
 		a.bucketCountThresholds = &BucketCountThresholds{}
 	}
 	a.bucketCountThresholds.RequiredSize = &size
+	a.markDirty()
 	return a
 }
 
@@ -81,16 +110,27 @@ func (a *SignificantTextAggregation) ShardSize(shardSize int) *SignificantTextAg
 		a.bucketCountThresholds = &BucketCountThresholds{}
 	}
 	a.bucketCountThresholds.ShardSize = &shardSize
+	a.markDirty()
 	return a
 }
 
 func (a *SignificantTextAggregation) BackgroundFilter(filter elastic.Query) *SignificantTextAggregation {
 	a.filter = filter
+	a.markDirty()
+	return a
+}
+
+// BackgroundFilterRaw sets an already built query body to be used as the
+// background filter as is. It takes precedence over the query set with BackgroundFilter.
+func (a *SignificantTextAggregation) BackgroundFilterRaw(raw map[string]interface{}) *SignificantTextAggregation {
+	a.filterRaw = raw
+	a.markDirty()
 	return a
 }
 
 func (a *SignificantTextAggregation) SignificanceHeuristic(heuristic SignificanceHeuristic) *SignificantTextAggregation {
 	a.significanceHeuristic = heuristic
+	a.markDirty()
 	return a
 }
 
@@ -99,6 +139,7 @@ func (a *SignificantTextAggregation) Include(regexp string) *SignificantTextAggr
 		a.includeExclude = &TermsAggregationIncludeExclude{}
 	}
 	a.includeExclude.Include = regexp
+	a.markDirty()
 	return a
 }
 
@@ -107,6 +148,7 @@ func (a *SignificantTextAggregation) IncludeValues(values ...interface{}) *Signi
 		a.includeExclude = &TermsAggregationIncludeExclude{}
 	}
 	a.includeExclude.IncludeValues = append(a.includeExclude.IncludeValues, values...)
+	a.markDirty()
 	return a
 }
 
@@ -115,6 +157,7 @@ func (a *SignificantTextAggregation) Exclude(regexp string) *SignificantTextAggr
 		a.includeExclude = &TermsAggregationIncludeExclude{}
 	}
 	a.includeExclude.Exclude = regexp
+	a.markDirty()
 	return a
 }
 
@@ -123,6 +166,7 @@ func (a *SignificantTextAggregation) ExcludeValues(values ...interface{}) *Signi
 		a.includeExclude = &TermsAggregationIncludeExclude{}
 	}
 	a.includeExclude.ExcludeValues = append(a.includeExclude.ExcludeValues, values...)
+	a.markDirty()
 	return a
 }
 
@@ -131,6 +175,7 @@ func (a *SignificantTextAggregation) Partition(p int) *SignificantTextAggregatio
 		a.includeExclude = &TermsAggregationIncludeExclude{}
 	}
 	a.includeExclude.Partition = p
+	a.markDirty()
 	return a
 }
 
@@ -139,10 +184,28 @@ func (a *SignificantTextAggregation) NumPartitions(n int) *SignificantTextAggreg
 		a.includeExclude = &TermsAggregationIncludeExclude{}
 	}
 	a.includeExclude.NumPartitions = n
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *SignificantTextAggregation) AggregationType() string {
+	return "significant_text"
+}
+
+func (a *SignificantTextAggregation) Validate() error {
+	if a.field == "" {
+		return errors.New("elastic: SignificantTextAggregation requires a field")
+	}
+
+	return nil
+}
+
 func (a *SignificantTextAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *SignificantTextAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	// {
 	//     "query" : {
@@ -173,6 +236,12 @@ func (a *SignificantTextAggregation) Source() (interface{}, error) {
 	if a.field != "" {
 		opts["field"] = a.field
 	}
+	if len(a.sourceFieldNames) > 0 {
+		opts["source_fields"] = a.sourceFieldNames
+	}
+	if a.filterDuplicateText != nil {
+		opts["filter_duplicate_text"] = *a.filterDuplicateText
+	}
 	if a.bucketCountThresholds != nil {
 		if a.bucketCountThresholds.RequiredSize != nil {
 			opts["size"] = (*a.bucketCountThresholds).RequiredSize
@@ -187,7 +256,10 @@ func (a *SignificantTextAggregation) Source() (interface{}, error) {
 			opts["shard_min_doc_count"] = (*a.bucketCountThresholds).ShardMinDocCount
 		}
 	}
-	if a.filter != nil {
+	switch {
+	case a.filterRaw != nil:
+		opts["background_filter"] = a.filterRaw
+	case a.filter != nil:
 		src, err := a.filter.Source()
 		if err != nil {
 			return nil, err
@@ -224,22 +296,12 @@ func (a *SignificantTextAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
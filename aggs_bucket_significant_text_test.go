@@ -0,0 +1,25 @@
+package aggretastic
+
+import "testing"
+
+func TestSignificantTextAggregation(t *testing.T) {
+	agg := NewSignificantTextAggregation().Field("content").
+		SourceFieldNames("content", "title").
+		FilterDuplicateText(true).
+		Size(5)
+
+	want := `{"significant_text":{"field":"content","filter_duplicate_text":true,"size":5,"source_fields":["content","title"]}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestSignificantTextAggregationHeuristic(t *testing.T) {
+	agg := NewSignificantTextAggregation().Field("content").
+		SignificanceHeuristic(NewChiSquareSignificanceHeuristic().IncludeNegatives(true))
+
+	want := `{"significant_text":{"chi_square":{"include_negatives":true},"field":"content"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
@@ -1,6 +1,11 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"fmt"
+	"github.com/olivere/elastic"
+	"strings"
+)
 
 // TermsAggregation is a multi-bucket value source based aggregation
 // where buckets are dynamically built - one per unique value.
@@ -8,11 +13,11 @@ import "github.com/olivere/elastic"
 // See: http://www.elasticsearch.org/guide/en/elasticsearch/reference/6.2/search-aggregations-bucket-terms-aggregation.html
 type TermsAggregation struct {
 	*tree
+	metaHolder
 
 	field   string
 	script  *elastic.Script
 	missing interface{}
-	meta    map[string]interface{}
 
 	size                  *int
 	shardSize             *int
@@ -21,9 +26,12 @@ type TermsAggregation struct {
 	shardMinDocCount      *int
 	valueType             string
 	includeExclude        *TermsAggregationIncludeExclude
+	partitionErr          error
 	executionHint         string
+	executionHintErr      error
 	collectionMode        string
-	showTermDocCountError *bool
+	collectionModeErr     error
+	showTermDocCountError bool
 	order                 []TermsOrder
 }
 
@@ -36,53 +44,90 @@ func NewTermsAggregation() *TermsAggregation {
 
 func (a *TermsAggregation) Field(field string) *TermsAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *TermsAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *TermsAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *TermsAggregation) Script(script *elastic.Script) *TermsAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *TermsAggregation) StoredScript(id string, params map[string]interface{}) *TermsAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 // Missing configures the value to use when documents miss a value.
 func (a *TermsAggregation) Missing(missing interface{}) *TermsAggregation {
 	a.missing = missing
+	a.markDirty()
 	return a
 }
 
 func (a *TermsAggregation) SubAggregation(name string, subAggregation Aggregation) *TermsAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *TermsAggregation) Meta(metaData map[string]interface{}) *TermsAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *TermsAggregation) AddMeta(key string, value interface{}) *TermsAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 func (a *TermsAggregation) Size(size int) *TermsAggregation {
 	a.size = &size
+	a.markDirty()
 	return a
 }
 
 func (a *TermsAggregation) RequiredSize(requiredSize int) *TermsAggregation {
 	a.requiredSize = &requiredSize
+	a.markDirty()
 	return a
 }
 
 func (a *TermsAggregation) ShardSize(shardSize int) *TermsAggregation {
 	a.shardSize = &shardSize
+	a.markDirty()
 	return a
 }
 
+// MinDocCount sets the minimum number of documents a bucket needs to be returned.
+// 0 is rendered as well, together with Include it returns the listed terms having no documents.
 func (a *TermsAggregation) MinDocCount(minDocCount int) *TermsAggregation {
 	a.minDocCount = &minDocCount
+	a.markDirty()
 	return a
 }
 
 func (a *TermsAggregation) ShardMinDocCount(shardMinDocCount int) *TermsAggregation {
 	a.shardMinDocCount = &shardMinDocCount
+	a.markDirty()
 	return a
 }
 
@@ -91,6 +136,7 @@ func (a *TermsAggregation) Include(regexp string) *TermsAggregation {
 		a.includeExclude = &TermsAggregationIncludeExclude{}
 	}
 	a.includeExclude.Include = regexp
+	a.markDirty()
 	return a
 }
 
@@ -99,6 +145,7 @@ func (a *TermsAggregation) IncludeValues(values ...interface{}) *TermsAggregatio
 		a.includeExclude = &TermsAggregationIncludeExclude{}
 	}
 	a.includeExclude.IncludeValues = append(a.includeExclude.IncludeValues, values...)
+	a.markDirty()
 	return a
 }
 
@@ -107,6 +154,7 @@ func (a *TermsAggregation) Exclude(regexp string) *TermsAggregation {
 		a.includeExclude = &TermsAggregationIncludeExclude{}
 	}
 	a.includeExclude.Exclude = regexp
+	a.markDirty()
 	return a
 }
 
@@ -115,6 +163,7 @@ func (a *TermsAggregation) ExcludeValues(values ...interface{}) *TermsAggregatio
 		a.includeExclude = &TermsAggregationIncludeExclude{}
 	}
 	a.includeExclude.ExcludeValues = append(a.includeExclude.ExcludeValues, values...)
+	a.markDirty()
 	return a
 }
 
@@ -123,6 +172,7 @@ func (a *TermsAggregation) Partition(p int) *TermsAggregation {
 		a.includeExclude = &TermsAggregationIncludeExclude{}
 	}
 	a.includeExclude.Partition = p
+	a.markDirty()
 	return a
 }
 
@@ -131,23 +181,41 @@ func (a *TermsAggregation) NumPartitions(n int) *TermsAggregation {
 		a.includeExclude = &TermsAggregationIncludeExclude{}
 	}
 	a.includeExclude.NumPartitions = n
+	a.partitionErr = nil
+	a.markDirty()
+	return a
+}
+
+// IncludePartition splits the terms into numPartitions groups and includes only the given one,
+// so all the terms of a high cardinality field can be scanned partition by partition.
+// It can't be combined with Include or IncludeValues. A numPartitions <= 0
+// makes Validate and Source return an error.
+func (a *TermsAggregation) IncludePartition(partition, numPartitions int) *TermsAggregation {
+	a.Partition(partition).NumPartitions(numPartitions)
+	if numPartitions <= 0 {
+		a.partitionErr = fmt.Errorf("elastic: TermsAggregation needs a positive number of partitions, got %d", numPartitions)
+	}
 	return a
 }
 
 // ValueType can be string, long, or double.
+// It's a hint for the values produced by a script, so it's rendered for the scripted terms only.
 func (a *TermsAggregation) ValueType(valueType string) *TermsAggregation {
 	a.valueType = valueType
+	a.markDirty()
 	return a
 }
 
 func (a *TermsAggregation) Order(order string, asc bool) *TermsAggregation {
 	a.order = append(a.order, TermsOrder{Field: order, Ascending: asc})
+	a.markDirty()
 	return a
 }
 
 func (a *TermsAggregation) OrderByCount(asc bool) *TermsAggregation {
 	// "order" : { "_count" : "asc" }
 	a.order = append(a.order, TermsOrder{Field: "_count", Ascending: asc})
+	a.markDirty()
 	return a
 }
 
@@ -162,6 +230,7 @@ func (a *TermsAggregation) OrderByCountDesc() *TermsAggregation {
 func (a *TermsAggregation) OrderByTerm(asc bool) *TermsAggregation {
 	// "order" : { "_term" : "asc" }
 	a.order = append(a.order, TermsOrder{Field: "_term", Ascending: asc})
+	a.markDirty()
 	return a
 }
 
@@ -173,8 +242,24 @@ func (a *TermsAggregation) OrderByTermDesc() *TermsAggregation {
 	return a.OrderByTerm(false)
 }
 
+func (a *TermsAggregation) OrderByKey(asc bool) *TermsAggregation {
+	// "order" : { "_key" : "asc" }
+	a.order = append(a.order, TermsOrder{Field: "_key", Ascending: asc})
+	a.markDirty()
+	return a
+}
+
+func (a *TermsAggregation) OrderByKeyAsc() *TermsAggregation {
+	return a.OrderByKey(true)
+}
+
+func (a *TermsAggregation) OrderByKeyDesc() *TermsAggregation {
+	return a.OrderByKey(false)
+}
+
 // OrderByAggregation creates a bucket ordering strategy which sorts buckets
-// based on a single-valued calc get.
+// based on a single-valued calc get. Nested metrics are referenced with
+// the Elasticsearch path syntax, e.g. "by_store>avg_price".
 func (a *TermsAggregation) OrderByAggregation(aggName string, asc bool) *TermsAggregation {
 	// {
 	//     "aggs" : {
@@ -190,6 +275,7 @@ func (a *TermsAggregation) OrderByAggregation(aggName string, asc bool) *TermsAg
 	//     }
 	// }
 	a.order = append(a.order, TermsOrder{Field: aggName, Ascending: asc})
+	a.markDirty()
 	return a
 }
 
@@ -210,26 +296,84 @@ func (a *TermsAggregation) OrderByAggregationAndMetric(aggName, metric string, a
 	//     }
 	// }
 	a.order = append(a.order, TermsOrder{Field: aggName + "." + metric, Ascending: asc})
+	a.markDirty()
 	return a
 }
 
+// ExecutionHint sets the hint as it is, see TypedExecutionHint for the known ones.
 func (a *TermsAggregation) ExecutionHint(hint string) *TermsAggregation {
 	a.executionHint = hint
+	a.executionHintErr = nil
+	a.markDirty()
+	return a
+}
+
+// TypedExecutionHint sets ExecutionHintMap or ExecutionHintGlobalOrdinals,
+// any other hint makes Validate return an error.
+func (a *TermsAggregation) TypedExecutionHint(hint ExecutionHint) *TermsAggregation {
+	a.executionHint = string(hint)
+	a.executionHintErr = checkExecutionHint(hint, termsExecutionHints)
+	a.markDirty()
 	return a
 }
 
 // Collection mode can be depth_first or breadth_first as of 1.4.0.
+// It's set as it is, see TypedCollectionMode for the known ones.
 func (a *TermsAggregation) CollectionMode(collectionMode string) *TermsAggregation {
 	a.collectionMode = collectionMode
+	a.collectionModeErr = nil
+	a.markDirty()
+	return a
+}
+
+// TypedCollectionMode sets CollectModeDepthFirst or CollectModeBreadthFirst,
+// any other mode makes Validate return an error.
+func (a *TermsAggregation) TypedCollectionMode(mode CollectMode) *TermsAggregation {
+	a.collectionMode = string(mode)
+	a.collectionModeErr = checkCollectMode(mode)
+	a.markDirty()
 	return a
 }
 
+// ShowTermDocCountError returns the worst case error of the doc count of every term,
+// useful to assess the accuracy of the counts on sharded indices.
+// It's off by default, so false is not rendered.
 func (a *TermsAggregation) ShowTermDocCountError(showTermDocCountError bool) *TermsAggregation {
-	a.showTermDocCountError = &showTermDocCountError
+	a.showTermDocCountError = showTermDocCountError
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *TermsAggregation) AggregationType() string {
+	return "terms"
+}
+
+func (a *TermsAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: TermsAggregation requires a field or a script")
+	}
+	if a.collectionModeErr != nil {
+		return a.collectionModeErr
+	}
+	if a.executionHintErr != nil {
+		return a.executionHintErr
+	}
+	if a.partitionErr != nil {
+		return a.partitionErr
+	}
+	if err := validateOrderPaths(a, "TermsAggregation", a.order); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (a *TermsAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *TermsAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -260,28 +404,28 @@ func (a *TermsAggregation) Source() (interface{}, error) {
 	}
 
 	// TermsBuilder
-	if a.size != nil && *a.size >= 0 {
+	if a.size != nil {
 		opts["size"] = *a.size
 	}
-	if a.shardSize != nil && *a.shardSize >= 0 {
+	if a.shardSize != nil {
 		opts["shard_size"] = *a.shardSize
 	}
-	if a.requiredSize != nil && *a.requiredSize >= 0 {
+	if a.requiredSize != nil {
 		opts["required_size"] = *a.requiredSize
 	}
-	if a.minDocCount != nil && *a.minDocCount >= 0 {
+	if a.minDocCount != nil {
 		opts["min_doc_count"] = *a.minDocCount
 	}
-	if a.shardMinDocCount != nil && *a.shardMinDocCount >= 0 {
+	if a.shardMinDocCount != nil {
 		opts["shard_min_doc_count"] = *a.shardMinDocCount
 	}
-	if a.showTermDocCountError != nil {
-		opts["show_term_doc_count_error"] = *a.showTermDocCountError
+	if a.showTermDocCountError {
+		opts["show_term_doc_count_error"] = true
 	}
 	if a.collectionMode != "" {
 		opts["collect_mode"] = a.collectionMode
 	}
-	if a.valueType != "" {
+	if a.script != nil && a.valueType != "" {
 		opts["value_type"] = a.valueType
 	}
 	if len(a.order) > 0 {
@@ -298,6 +442,12 @@ func (a *TermsAggregation) Source() (interface{}, error) {
 	// Include/Exclude
 	if ie := a.includeExclude; ie != nil {
 		// Include
+		if a.partitionErr != nil {
+			return nil, a.partitionErr
+		}
+		if ie.NumPartitions > 0 && (ie.Include != "" || len(ie.IncludeValues) > 0) {
+			return nil, errors.New("elastic: TermsAggregation can't include both partition and terms")
+		}
 		if ie.Include != "" {
 			opts["include"] = ie.Include
 		} else if len(ie.IncludeValues) > 0 {
@@ -321,22 +471,12 @@ func (a *TermsAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -351,6 +491,19 @@ type TermsAggregationIncludeExclude struct {
 	NumPartitions int
 }
 
+// clone returns a copy of ie (nil for nil), the setters of the aggregations change it in place
+func (ie *TermsAggregationIncludeExclude) clone() *TermsAggregationIncludeExclude {
+	if ie == nil {
+		return nil
+	}
+
+	c := *ie
+	c.IncludeValues = append([]interface{}(nil), ie.IncludeValues...)
+	c.ExcludeValues = append([]interface{}(nil), ie.ExcludeValues...)
+
+	return &c
+}
+
 // TermsOrder specifies a single order field for a terms aggregation.
 type TermsOrder struct {
 	Field     string
@@ -367,3 +520,48 @@ func (order *TermsOrder) Source() (interface{}, error) {
 	}
 	return source, nil
 }
+
+// validateOrderPaths checks the subAggs the buckets are ordered by (e.g. "avg_price" or "stats.max")
+// exist in agg, the built-in keys like "_key" and "_count" need none.
+// The whole field is tried as the path first, so the names containing dots are found too.
+func validateOrderPaths(agg Aggregation, typ string, orders []TermsOrder) error {
+	for _, order := range orders {
+		if strings.HasPrefix(order.Field, "_") || !IsNilTree(agg.Select(ParsePath(order.Field)...)) {
+			continue
+		}
+		if path := ParseBucketsPath(order.Field); IsNilTree(agg.Select(path...)) {
+			return fmt.Errorf("elastic: %s is ordered by %q, but has no subAggregation %q", typ, order.Field, path.String())
+		}
+	}
+
+	return nil
+}
+
+// SetTermsSize sets the size of root and every TermsAggregation and
+// SignificantTermsAggregation in its subAggregations.
+// It returns the number of updated aggregations.
+func SetTermsSize(root Aggregation, size int) int {
+	updated := 0
+	setSize := func(agg Aggregation) {
+		switch agg := agg.(type) {
+		case *TermsAggregation:
+			agg.Size(size)
+			updated++
+		case *SignificantTermsAggregation:
+			agg.RequiredSize(size)
+			updated++
+		}
+	}
+
+	if root == nil {
+		return updated
+	}
+
+	setSize(root)
+	root.Walk(func(path []string, agg Aggregation) bool {
+		setSize(agg)
+		return true
+	})
+
+	return updated
+}
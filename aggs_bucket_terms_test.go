@@ -0,0 +1,201 @@
+package aggretastic
+
+import (
+	"github.com/olivere/elastic"
+	"strings"
+	"testing"
+)
+
+func TestTermsAggregationOrderHelpers(t *testing.T) {
+	tests := []struct {
+		name string
+		agg  *TermsAggregation
+		want string
+	}{
+		{"count asc", NewTermsAggregation().Field("f").OrderByCountAsc(), `[{"_count":"asc"}]`},
+		{"count desc", NewTermsAggregation().Field("f").OrderByCountDesc(), `[{"_count":"desc"}]`},
+		{"key asc", NewTermsAggregation().Field("f").OrderByKeyAsc(), `[{"_key":"asc"}]`},
+		{"key desc", NewTermsAggregation().Field("f").OrderByKeyDesc(), `[{"_key":"desc"}]`},
+		{"aggregation", NewTermsAggregation().Field("f").OrderByAggregation("revenue", true), `[{"revenue":"asc"}]`},
+		{"nested aggregation", NewTermsAggregation().Field("f").OrderByAggregation("by_store>revenue", false), `[{"by_store>revenue":"desc"}]`},
+		{"several", NewTermsAggregation().Field("f").OrderByAggregation("revenue", false).OrderByKeyAsc(), `[{"revenue":"desc"},{"_key":"asc"}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want := `{"terms":{"field":"f","order":` + test.want + `}}`
+			if test.agg.String() != want {
+				t.Fatalf("expected %s, got %s", want, test.agg)
+			}
+		})
+	}
+}
+
+func TestTermsAggregationOrderNeedsSubAggregation(t *testing.T) {
+	agg := NewTermsAggregation().Field("store").OrderByAggregation("avg_price", false).OrderByKeyAsc()
+	err := agg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `"avg_price"`) {
+		t.Fatalf("expected an error naming the missing metric, got %v", err)
+	}
+
+	agg.SubAggregation("avg_price", NewAvgAggregation().Field("price"))
+	if err := agg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTermsAggregationOrderByDottedName(t *testing.T) {
+	agg := NewTermsAggregation().Field("store").
+		OrderByAggregation("price.avg", false).
+		OrderByAggregationAndMetric("price.stats", "max", true)
+	agg.SubAggregation("price.avg", NewAvgAggregation().Field("price"))
+	agg.SubAggregation("price.stats", NewStatsAggregation().Field("price"))
+	if err := agg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the metric is cut at the last dot only, so "missing" doesn't match "missing.stats"
+	agg.OrderByAggregationAndMetric("missing.stats", "max", true).
+		SubAggregation("missing", NewStatsAggregation().Field("price"))
+	err := agg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `"missing.stats"`) {
+		t.Fatalf("expected an error naming the missing subAggregation, got %v", err)
+	}
+}
+
+func TestTermsAggregationOrderByNestedMetric(t *testing.T) {
+	agg := NewTermsAggregation().Field("store").
+		OrderByAggregationAndMetric("price_stats", "max", true).
+		OrderByAggregation("sales>total", false)
+	if err := agg.Validate(); err == nil {
+		t.Fatal("expected an error for the missing metrics")
+	}
+
+	agg.SubAggregation("price_stats", NewStatsAggregation().Field("price"))
+	agg.SubAggregation("sales", NewFilterAggregation().Filter(elastic.NewMatchAllQuery()).
+		SubAggregation("total", NewSumAggregation().Field("price")))
+	if err := agg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTermsAggregationShowTermDocCountError(t *testing.T) {
+	agg := NewTermsAggregation().Field("user").ShowTermDocCountError(true)
+	want := `{"terms":{"field":"user","show_term_doc_count_error":true}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	agg.ShowTermDocCountError(false)
+	want = `{"terms":{"field":"user"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestTermsAggregationMinDocCount(t *testing.T) {
+	tests := []struct {
+		name string
+		agg  *TermsAggregation
+		want string
+	}{
+		{"zero", NewTermsAggregation().Field("tag").IncludeValues("a", "b").MinDocCount(0), `{"terms":{"field":"tag","include":["a","b"],"min_doc_count":0}}`},
+		{"set", NewTermsAggregation().Field("tag").MinDocCount(2), `{"terms":{"field":"tag","min_doc_count":2}}`},
+		{"unset", NewTermsAggregation().Field("tag"), `{"terms":{"field":"tag"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.agg.String() != test.want {
+				t.Fatalf("expected %s, got %s", test.want, test.agg)
+			}
+		})
+	}
+}
+
+func TestTermsAggregationValueTypeNeedsScript(t *testing.T) {
+	script := elastic.NewScript("doc['price'].value")
+
+	tests := []struct {
+		name string
+		agg  *TermsAggregation
+		want string
+	}{
+		{"script", NewTermsAggregation().Script(script).ValueType("long"), `{"terms":{"script":"doc['price'].value","value_type":"long"}}`},
+		{"field", NewTermsAggregation().Field("price").ValueType("long"), `{"terms":{"field":"price"}}`},
+		{"script without type", NewTermsAggregation().Script(script), `{"terms":{"script":"doc['price'].value"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.agg.String() != test.want {
+				t.Fatalf("expected %s, got %s", test.want, test.agg)
+			}
+		})
+	}
+}
+
+func TestSetTermsSize(t *testing.T) {
+	root := NewGlobalAggregation().
+		SubAggregation("users", NewTermsAggregation().Field("user").
+			SubAggregation("tags", NewSignificantTermsAggregation().Field("tag"))).
+		SubAggregation("avg", NewAvgAggregation().Field("x"))
+
+	if updated := SetTermsSize(root, 5); updated != 2 {
+		t.Fatalf("expected 2 aggregations updated, got %d", updated)
+	}
+	want := `{"aggregations":{"avg":{"avg":{"field":"x"}},"users":{"aggregations":{"tags":{"significant_terms":{"field":"tag","size":5}}},"terms":{"field":"user","size":5}}},"global":{}}`
+	if root.String() != want {
+		t.Fatalf("expected %s, got %s", want, root)
+	}
+
+	terms := NewTermsAggregation().Field("user")
+	if updated := SetTermsSize(terms, 3); updated != 1 {
+		t.Fatalf("expected the root updated, got %d", updated)
+	}
+	want = `{"terms":{"field":"user","size":3}}`
+	if terms.String() != want {
+		t.Fatalf("expected %s, got %s", want, terms)
+	}
+}
+
+func TestTermsAggregationIncludePartition(t *testing.T) {
+	agg := NewTermsAggregation().Field("account_id").IncludePartition(0, 20).Size(10000)
+	want := `{"terms":{"field":"account_id","include":{"num_partitions":20,"partition":0},"size":10000}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	tests := []struct {
+		name string
+		agg  *TermsAggregation
+	}{
+		{"regexp", NewTermsAggregation().Field("account_id").IncludePartition(0, 20).Include("a.*")},
+		{"values", NewTermsAggregation().Field("account_id").IncludeValues("a", "b").IncludePartition(0, 20)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.agg.Source(); err == nil {
+				t.Fatal("expected an error for the partition mixed with another include")
+			}
+		})
+	}
+}
+
+func TestTermsAggregationIncludePartitionNeedsPartitions(t *testing.T) {
+	for _, numPartitions := range []int{0, -1} {
+		agg := NewTermsAggregation().Field("account_id").IncludePartition(0, numPartitions)
+		if _, err := agg.Source(); err == nil {
+			t.Fatalf("expected an error from Source for %d partitions", numPartitions)
+		}
+		if err := agg.Validate(); err == nil {
+			t.Fatalf("expected an error from Validate for %d partitions", numPartitions)
+		}
+
+		// setting the number of partitions again clears the error
+		agg.NumPartitions(4)
+		if err := agg.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package aggretastic
+
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
+
+// VariableWidthHistogramAggregation is a multi-bucket aggregation similar
+// to the histogram. However, the width of each bucket is not specified.
+// Rather, a target number of buckets is provided and bucket intervals
+// are dynamically determined based on the document distribution.
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/7.9/search-aggregations-bucket-variablewidthhistogram-aggregation.html
+type VariableWidthHistogramAggregation struct {
+	*tree
+	metaHolder
+
+	field  string
+	script *elastic.Script
+
+	buckets *int
+}
+
+func NewVariableWidthHistogramAggregation() *VariableWidthHistogramAggregation {
+	a := &VariableWidthHistogramAggregation{}
+	a.tree = nilAggregationTree(a)
+
+	return a
+}
+
+func (a *VariableWidthHistogramAggregation) Field(field string) *VariableWidthHistogramAggregation {
+	a.field = field
+	a.markDirty()
+	return a
+}
+
+// GetField returns the field the aggregation works on.
+func (a *VariableWidthHistogramAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *VariableWidthHistogramAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
+func (a *VariableWidthHistogramAggregation) Script(script *elastic.Script) *VariableWidthHistogramAggregation {
+	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *VariableWidthHistogramAggregation) StoredScript(id string, params map[string]interface{}) *VariableWidthHistogramAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
+	return a
+}
+
+func (a *VariableWidthHistogramAggregation) SubAggregation(name string, subAggregation Aggregation) *VariableWidthHistogramAggregation {
+	a.setSub(name, subAggregation)
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *VariableWidthHistogramAggregation) Meta(metaData map[string]interface{}) *VariableWidthHistogramAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *VariableWidthHistogramAggregation) AddMeta(key string, value interface{}) *VariableWidthHistogramAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// Buckets sets the target number of buckets. Defaults to 10.
+func (a *VariableWidthHistogramAggregation) Buckets(buckets int) *VariableWidthHistogramAggregation {
+	a.buckets = &buckets
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *VariableWidthHistogramAggregation) AggregationType() string {
+	return "variable_width_histogram"
+}
+
+func (a *VariableWidthHistogramAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: VariableWidthHistogramAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
+func (a *VariableWidthHistogramAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *VariableWidthHistogramAggregation) source(depth int) (interface{}, error) {
+	// Example:
+	// {
+	//     "aggs" : {
+	//         "prices" : {
+	//             "variable_width_histogram" : {
+	//                 "field" : "price",
+	//                 "buckets" : 2
+	//             }
+	//         }
+	//     }
+	// }
+	//
+	// This method returns only the { "variable_width_histogram" : { ... } } part.
+
+	source := make(map[string]interface{})
+	opts := make(map[string]interface{})
+	source["variable_width_histogram"] = opts
+
+	// ValuesSourceAggregationBuilder
+	if a.field != "" {
+		opts["field"] = a.field
+	}
+	if a.script != nil {
+		src, err := a.script.Source()
+		if err != nil {
+			return nil, err
+		}
+		opts["script"] = src
+	}
+
+	if a.buckets != nil {
+		opts["buckets"] = *a.buckets
+	}
+
+	// AggregationBuilder (SubAggregations)
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
@@ -0,0 +1,14 @@
+package aggretastic
+
+import "testing"
+
+func TestVariableWidthHistogramAggregation(t *testing.T) {
+	agg := NewVariableWidthHistogramAggregation().Field("price").Buckets(3).
+		AddMeta("team", "sales").
+		SubAggregation("avg", NewAvgAggregation().Field("price"))
+
+	want := `{"aggregations":{"avg":{"avg":{"field":"price"}}},"meta":{"team":"sales"},"variable_width_histogram":{"buckets":3,"field":"price"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
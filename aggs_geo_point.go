@@ -0,0 +1,35 @@
+package aggretastic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValidateGeoPoint checks a geo point given as "lat,lon": the latitude must be within [-90, 90]
+// and the longitude within [-180, 180], which catches the swapped lat/lon most of the time.
+// Points given in another format (e.g. a geohash) are not checked.
+func ValidateGeoPoint(latLon string) error {
+	parts := strings.Split(latLon, ",")
+	if len(parts) != 2 {
+		return nil
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return fmt.Errorf("elastic: malformed latitude in geo point %q", latLon)
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return fmt.Errorf("elastic: malformed longitude in geo point %q", latLon)
+	}
+
+	if lat < -90 || lat > 90 {
+		return fmt.Errorf("elastic: latitude %v of geo point %q is out of range [-90, 90]", lat, latLon)
+	}
+	if lon < -180 || lon > 180 {
+		return fmt.Errorf("elastic: longitude %v of geo point %q is out of range [-180, 180]", lon, latLon)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package aggretastic
+
+import "testing"
+
+func TestValidateGeoPoint(t *testing.T) {
+	tests := []struct {
+		point string
+		valid bool
+	}{
+		{"90,180", true},
+		{"-90,-180", true},
+		{"52.3760, 4.894", true},
+		{"0,0", true},
+		{"u173zq", true},
+		{"90.0001,0", false},
+		{"-90.1,0", false},
+		{"0,180.5", false},
+		{"0,-181", false},
+		{"4.894, 152.3760x", false},
+		{"x,1", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.point, func(t *testing.T) {
+			if err := ValidateGeoPoint(test.point); (err == nil) != test.valid {
+				t.Fatalf("expected valid %v, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestGeoDistanceAggregationChecksPoint(t *testing.T) {
+	agg := NewGeoDistanceAggregation().Field("location").Point("4.894, 252.376").AddRange(nil, 100)
+	if err := agg.Validate(); err == nil {
+		t.Fatal("expected Validate to fail with the swapped lat/lon")
+	}
+	if _, err := agg.Source(); err == nil {
+		t.Fatal("expected Source to fail with the swapped lat/lon")
+	}
+
+	agg.Point("52.376, 4.894")
+	if err := agg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := agg.Source(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,23 +1,22 @@
 package aggretastic
 
-// MatrixMatrixStatsAggregation is a multi-value metrics aggregation
-// that computes stats over numeric values extracted from the
-// aggregated documents. These values can be extracted either from
-// specific numeric fields in the documents, or be generated by a provided script.
-//
-// The stats that are returned consist of: min, max, sum, count and avg.
+import "errors"
+
+// MatrixStatsAggregation is a numeric aggregation that computes
+// statistics over a set of document fields: count, mean, variance,
+// skewness, kurtosis, covariance and correlation.
 //
-// See https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-metrics-stats-aggregation.html
+// See https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-matrix-stats-aggregation.html
 // for details.
 type MatrixStatsAggregation struct {
 	*tree
+	metaHolder
 
 	fields    []string
 	missing   interface{}
 	format    string
 	valueType interface{}
 	mode      string
-	meta      map[string]interface{}
 }
 
 // NewMatrixStatsAggregation initializes a new MatrixStatsAggregation.
@@ -30,44 +29,76 @@ func NewMatrixStatsAggregation() *MatrixStatsAggregation {
 
 func (a *MatrixStatsAggregation) Fields(fields ...string) *MatrixStatsAggregation {
 	a.fields = append(a.fields, fields...)
+	a.markDirty()
 	return a
 }
 
-// Missing configures the value to use when documents miss a value.
+// Missing configures the values to use when documents miss a value,
+// e.g. map[string]interface{}{"income": 50000}.
 func (a *MatrixStatsAggregation) Missing(missing interface{}) *MatrixStatsAggregation {
 	a.missing = missing
+	a.markDirty()
 	return a
 }
 
 // Mode specifies how to operate. Valid values are: sum, avg, median, min, or max.
 func (a *MatrixStatsAggregation) Mode(mode string) *MatrixStatsAggregation {
 	a.mode = mode
+	a.markDirty()
 	return a
 }
 
 func (a *MatrixStatsAggregation) Format(format string) *MatrixStatsAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 func (a *MatrixStatsAggregation) ValueType(valueType interface{}) *MatrixStatsAggregation {
 	a.valueType = valueType
+	a.markDirty()
 	return a
 }
 
 func (a *MatrixStatsAggregation) SubAggregation(name string, subAggregation Aggregation) *MatrixStatsAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *MatrixStatsAggregation) Meta(metaData map[string]interface{}) *MatrixStatsAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *MatrixStatsAggregation) AddMeta(key string, value interface{}) *MatrixStatsAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *MatrixStatsAggregation) AggregationType() string {
+	return "matrix_stats"
+}
+
+func (a *MatrixStatsAggregation) Validate() error {
+	if len(a.fields) == 0 {
+		return errors.New("elastic: MatrixStatsAggregation requires at least one field")
+	}
+
+	return nil
+}
+
 // Source returns the JSON to serialize into the request, or an error.
+// At least one field is required.
 func (a *MatrixStatsAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *MatrixStatsAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -83,6 +114,10 @@ func (a *MatrixStatsAggregation) Source() (interface{}, error) {
 	//	}
 	// This method returns only the { "matrix_stats" : { ... } } part.
 
+	if len(a.fields) == 0 {
+		return nil, errors.New("elastic: MatrixStatsAggregation requires at least one field")
+	}
+
 	source := make(map[string]interface{})
 	opts := make(map[string]interface{})
 	source["matrix_stats"] = opts
@@ -103,22 +138,12 @@ func (a *MatrixStatsAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -0,0 +1,24 @@
+package aggretastic
+
+import "testing"
+
+func TestMatrixStatsAggregation(t *testing.T) {
+	agg := NewMatrixStatsAggregation().Fields("poverty", "income").
+		Missing(map[string]interface{}{"income": 50000}).
+		Mode("avg")
+
+	want := `{"matrix_stats":{"fields":["poverty","income"],"missing":{"income":50000},"mode":"avg"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestMatrixStatsAggregationRequiresFields(t *testing.T) {
+	agg := NewMatrixStatsAggregation().Mode("avg")
+	if err := agg.Validate(); err == nil {
+		t.Fatal("expected Validate to fail without fields")
+	}
+	if _, err := agg.Source(); err == nil {
+		t.Fatal("expected Source to fail without fields")
+	}
+}
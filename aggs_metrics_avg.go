@@ -1,6 +1,9 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // AvgAggregation is a single-value metrics aggregation that computes
 // the average of numeric values that are extracted from the
@@ -11,11 +14,11 @@ import "github.com/olivere/elastic"
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-metrics-avg-aggregation.html
 type AvgAggregation struct {
 	*tree
+	metaHolder
 
 	field  string
 	script *elastic.Script
 	format string
-	meta   map[string]interface{}
 }
 
 func NewAvgAggregation() *AvgAggregation {
@@ -27,31 +30,78 @@ func NewAvgAggregation() *AvgAggregation {
 
 func (a *AvgAggregation) Field(field string) *AvgAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *AvgAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *AvgAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *AvgAggregation) Script(script *elastic.Script) *AvgAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *AvgAggregation) StoredScript(id string, params map[string]interface{}) *AvgAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 func (a *AvgAggregation) Format(format string) *AvgAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 func (a *AvgAggregation) SubAggregation(name string, subAggregation Aggregation) *AvgAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *AvgAggregation) Meta(metaData map[string]interface{}) *AvgAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *AvgAggregation) AddMeta(key string, value interface{}) *AvgAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *AvgAggregation) AggregationType() string {
+	return "avg"
+}
+
+func (a *AvgAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: AvgAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
 func (a *AvgAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *AvgAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -81,22 +131,12 @@ func (a *AvgAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
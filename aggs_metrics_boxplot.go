@@ -0,0 +1,155 @@
+package aggretastic
+
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
+
+// BoxplotAggregation is a multi-value metrics aggregation that computes
+// a boxplot (min, max, median, first and third quartiles) of numeric values
+// extracted from the aggregated documents.
+//
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/7.7/search-aggregations-metrics-boxplot-aggregation.html
+type BoxplotAggregation struct {
+	*tree
+	metaHolder
+
+	field       string
+	script      *elastic.Script
+	missing     interface{}
+	compression *float64
+}
+
+func NewBoxplotAggregation() *BoxplotAggregation {
+	a := &BoxplotAggregation{}
+	a.tree = nilAggregationTree(a)
+
+	return a
+}
+
+func (a *BoxplotAggregation) Field(field string) *BoxplotAggregation {
+	a.field = field
+	a.markDirty()
+	return a
+}
+
+// GetField returns the field the aggregation works on.
+func (a *BoxplotAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *BoxplotAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
+func (a *BoxplotAggregation) Script(script *elastic.Script) *BoxplotAggregation {
+	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *BoxplotAggregation) StoredScript(id string, params map[string]interface{}) *BoxplotAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
+	return a
+}
+
+// Missing configures the value to use when documents miss a value.
+func (a *BoxplotAggregation) Missing(missing interface{}) *BoxplotAggregation {
+	a.missing = missing
+	a.markDirty()
+	return a
+}
+
+// Compression trades accuracy of the underlying TDigest for memory usage.
+func (a *BoxplotAggregation) Compression(compression float64) *BoxplotAggregation {
+	a.compression = &compression
+	a.markDirty()
+	return a
+}
+
+func (a *BoxplotAggregation) SubAggregation(name string, subAggregation Aggregation) *BoxplotAggregation {
+	a.setSub(name, subAggregation)
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *BoxplotAggregation) Meta(metaData map[string]interface{}) *BoxplotAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *BoxplotAggregation) AddMeta(key string, value interface{}) *BoxplotAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *BoxplotAggregation) AggregationType() string {
+	return "boxplot"
+}
+
+func (a *BoxplotAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: BoxplotAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
+func (a *BoxplotAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *BoxplotAggregation) source(depth int) (interface{}, error) {
+	// Example:
+	//	{
+	//    "aggs" : {
+	//      "load_time_boxplot" : {
+	//        "boxplot" : {
+	//          "field" : "load_time"
+	//        }
+	//      }
+	//    }
+	//	}
+	// This method returns only the { "boxplot" : { ... } } part.
+
+	source := make(map[string]interface{})
+	opts := make(map[string]interface{})
+	source["boxplot"] = opts
+
+	// ValuesSourceAggregationBuilder
+	if a.field != "" {
+		opts["field"] = a.field
+	}
+	if a.script != nil {
+		src, err := a.script.Source()
+		if err != nil {
+			return nil, err
+		}
+		opts["script"] = src
+	}
+	if a.missing != nil {
+		opts["missing"] = a.missing
+	}
+	if a.compression != nil {
+		opts["compression"] = *a.compression
+	}
+
+	// AggregationBuilder (SubAggregations)
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
@@ -0,0 +1,27 @@
+package aggretastic
+
+import "testing"
+
+func TestBoxplotAggregation(t *testing.T) {
+	agg := NewBoxplotAggregation().Field("load_time").Missing(10)
+
+	want := `{"boxplot":{"field":"load_time","missing":10}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	agg.Compression(200).AddMeta("unit", "ms")
+	want = `{"boxplot":{"compression":200,"field":"load_time","missing":10},"meta":{"unit":"ms"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestBoxplotAggregationUnderTerms(t *testing.T) {
+	agg := NewTermsAggregation().Field("host").SubAggregation("load", NewBoxplotAggregation().Field("load_time"))
+
+	want := `{"aggregations":{"load":{"boxplot":{"field":"load_time"}}},"terms":{"field":"host"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
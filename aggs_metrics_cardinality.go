@@ -1,6 +1,14 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"fmt"
+	"github.com/olivere/elastic"
+)
+
+// MaxPrecisionThreshold is the highest precision threshold of the cardinality aggregation.
+// Elasticsearch silently uses it instead of any higher value.
+const MaxPrecisionThreshold = 40000
 
 // CardinalityAggregation is a single-value metrics aggregation that
 // calculates an approximate count of distinct values.
@@ -9,11 +17,11 @@ import "github.com/olivere/elastic"
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-metrics-cardinality-aggregation.html
 type CardinalityAggregation struct {
 	*tree
+	metaHolder
 
 	field              string
 	script             *elastic.Script
 	format             string
-	meta               map[string]interface{}
 	precisionThreshold *int64
 	rehash             *bool
 }
@@ -27,41 +35,103 @@ func NewCardinalityAggregation() *CardinalityAggregation {
 
 func (a *CardinalityAggregation) Field(field string) *CardinalityAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *CardinalityAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *CardinalityAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *CardinalityAggregation) Script(script *elastic.Script) *CardinalityAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *CardinalityAggregation) StoredScript(id string, params map[string]interface{}) *CardinalityAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 func (a *CardinalityAggregation) Format(format string) *CardinalityAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 func (a *CardinalityAggregation) SubAggregation(name string, subAggregation Aggregation) *CardinalityAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *CardinalityAggregation) Meta(metaData map[string]interface{}) *CardinalityAggregation {
 	a.meta = metaData
+	a.markDirty()
 	return a
 }
 
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *CardinalityAggregation) AddMeta(key string, value interface{}) *CardinalityAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// PrecisionThreshold sets the count below which the counts are expected to be close to accurate,
+// from 0 to MaxPrecisionThreshold. Higher thresholds take more memory (about threshold * 8 bytes per bucket).
 func (a *CardinalityAggregation) PrecisionThreshold(threshold int64) *CardinalityAggregation {
 	a.precisionThreshold = &threshold
+	a.markDirty()
 	return a
 }
 
+// Rehash controls whether the values are hashed again before counting.
+// It's meant for older Elasticsearch versions and the fields holding pre-computed hashes.
 func (a *CardinalityAggregation) Rehash(rehash bool) *CardinalityAggregation {
 	a.rehash = &rehash
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *CardinalityAggregation) AggregationType() string {
+	return "cardinality"
+}
+
+func (a *CardinalityAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: CardinalityAggregation requires a field or a script")
+	}
+
+	return a.validatePrecisionThreshold()
+}
+
+func (a *CardinalityAggregation) validatePrecisionThreshold() error {
+	if a.precisionThreshold != nil && (*a.precisionThreshold < 0 || *a.precisionThreshold > MaxPrecisionThreshold) {
+		return fmt.Errorf("elastic: CardinalityAggregation precision threshold %d is out of range [0, %d]",
+			*a.precisionThreshold, MaxPrecisionThreshold)
+	}
+
+	return nil
+}
+
 func (a *CardinalityAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *CardinalityAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -92,6 +162,9 @@ func (a *CardinalityAggregation) Source() (interface{}, error) {
 		opts["format"] = a.format
 	}
 	if a.precisionThreshold != nil {
+		if err := a.validatePrecisionThreshold(); err != nil {
+			return nil, err
+		}
 		opts["precision_threshold"] = *a.precisionThreshold
 	}
 	if a.rehash != nil {
@@ -99,22 +172,12 @@ func (a *CardinalityAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
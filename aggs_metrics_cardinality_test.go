@@ -0,0 +1,24 @@
+package aggretastic
+
+import "testing"
+
+func TestCardinalityAggregationPrecisionThreshold(t *testing.T) {
+	agg := NewCardinalityAggregation().Field("user").PrecisionThreshold(MaxPrecisionThreshold).Rehash(false)
+	want := `{"cardinality":{"field":"user","precision_threshold":40000,"rehash":false}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+	if err := agg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, threshold := range []int64{MaxPrecisionThreshold + 1, -1} {
+		agg.PrecisionThreshold(threshold)
+		if err := agg.Validate(); err == nil {
+			t.Errorf("%d: expected a validation error", threshold)
+		}
+		if _, err := agg.Source(); err == nil {
+			t.Errorf("%d: expected a source error", threshold)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // ExtendedExtendedStatsAggregation is a multi-value metrics aggregation that
 // computes stats over numeric values extracted from the aggregated documents.
@@ -9,11 +12,11 @@ import "github.com/olivere/elastic"
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-metrics-extendedstats-aggregation.html
 type ExtendedStatsAggregation struct {
 	*tree
+	metaHolder
 
 	field  string
 	script *elastic.Script
 	format string
-	meta   map[string]interface{}
 }
 
 func NewExtendedStatsAggregation() *ExtendedStatsAggregation {
@@ -25,31 +28,78 @@ func NewExtendedStatsAggregation() *ExtendedStatsAggregation {
 
 func (a *ExtendedStatsAggregation) Field(field string) *ExtendedStatsAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *ExtendedStatsAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *ExtendedStatsAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *ExtendedStatsAggregation) Script(script *elastic.Script) *ExtendedStatsAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *ExtendedStatsAggregation) StoredScript(id string, params map[string]interface{}) *ExtendedStatsAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 func (a *ExtendedStatsAggregation) Format(format string) *ExtendedStatsAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 func (a *ExtendedStatsAggregation) SubAggregation(name string, subAggregation Aggregation) *ExtendedStatsAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *ExtendedStatsAggregation) Meta(metaData map[string]interface{}) *ExtendedStatsAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *ExtendedStatsAggregation) AddMeta(key string, value interface{}) *ExtendedStatsAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *ExtendedStatsAggregation) AggregationType() string {
+	return "extended_stats"
+}
+
+func (a *ExtendedStatsAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: ExtendedStatsAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
 func (a *ExtendedStatsAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *ExtendedStatsAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -78,22 +128,12 @@ func (a *ExtendedStatsAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
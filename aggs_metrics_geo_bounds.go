@@ -1,17 +1,20 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // GeoBoundsAggregation is a metric aggregation that computes the
 // bounding box containing all geo_point values for a field.
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-metrics-geobounds-aggregation.html
 type GeoBoundsAggregation struct {
 	*tree
+	metaHolder
 
 	field         string
 	script        *elastic.Script
 	wrapLongitude *bool
-	meta          map[string]interface{}
 }
 
 func NewGeoBoundsAggregation() *GeoBoundsAggregation {
@@ -23,31 +26,78 @@ func NewGeoBoundsAggregation() *GeoBoundsAggregation {
 
 func (a *GeoBoundsAggregation) Field(field string) *GeoBoundsAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *GeoBoundsAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *GeoBoundsAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *GeoBoundsAggregation) Script(script *elastic.Script) *GeoBoundsAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *GeoBoundsAggregation) StoredScript(id string, params map[string]interface{}) *GeoBoundsAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 func (a *GeoBoundsAggregation) WrapLongitude(wrapLongitude bool) *GeoBoundsAggregation {
 	a.wrapLongitude = &wrapLongitude
+	a.markDirty()
 	return a
 }
 
 func (a *GeoBoundsAggregation) SubAggregation(name string, subAggregation Aggregation) *GeoBoundsAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *GeoBoundsAggregation) Meta(metaData map[string]interface{}) *GeoBoundsAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *GeoBoundsAggregation) AddMeta(key string, value interface{}) *GeoBoundsAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *GeoBoundsAggregation) AggregationType() string {
+	return "geo_bounds"
+}
+
+func (a *GeoBoundsAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: GeoBoundsAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
 func (a *GeoBoundsAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *GeoBoundsAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	// {
 	//     "query" : {
@@ -84,22 +134,12 @@ func (a *GeoBoundsAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
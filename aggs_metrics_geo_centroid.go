@@ -1,16 +1,19 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // GeoCentroidAggregation is a metric aggregation that computes the weighted centroid
 // from all coordinate values for a Geo-point datatype field.
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-metrics-geocentroid-aggregation.html
 type GeoCentroidAggregation struct {
 	*tree
+	metaHolder
 
 	field  string
 	script *elastic.Script
-	meta   map[string]interface{}
 }
 
 func NewGeoCentroidAggregation() *GeoCentroidAggregation {
@@ -22,26 +25,72 @@ func NewGeoCentroidAggregation() *GeoCentroidAggregation {
 
 func (a *GeoCentroidAggregation) Field(field string) *GeoCentroidAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *GeoCentroidAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *GeoCentroidAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *GeoCentroidAggregation) Script(script *elastic.Script) *GeoCentroidAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *GeoCentroidAggregation) StoredScript(id string, params map[string]interface{}) *GeoCentroidAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 func (a *GeoCentroidAggregation) SubAggregation(name string, subAggregation Aggregation) *GeoCentroidAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *GeoCentroidAggregation) Meta(metaData map[string]interface{}) *GeoCentroidAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *GeoCentroidAggregation) AddMeta(key string, value interface{}) *GeoCentroidAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *GeoCentroidAggregation) AggregationType() string {
+	return "geo_centroid"
+}
+
+func (a *GeoCentroidAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: GeoCentroidAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
 func (a *GeoCentroidAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *GeoCentroidAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	// {
 	//     "query" : {
@@ -74,22 +123,12 @@ func (a *GeoCentroidAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
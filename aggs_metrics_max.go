@@ -1,6 +1,9 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // MaxAggregation is a single-value metrics aggregation that keeps track and
 // returns the maximum value among the numeric values extracted from
@@ -10,11 +13,11 @@ import "github.com/olivere/elastic"
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-metrics-max-aggregation.html
 type MaxAggregation struct {
 	*tree
+	metaHolder
 
 	field  string
 	script *elastic.Script
 	format string
-	meta   map[string]interface{}
 }
 
 func NewMaxAggregation() *MaxAggregation {
@@ -26,30 +29,78 @@ func NewMaxAggregation() *MaxAggregation {
 
 func (a *MaxAggregation) Field(field string) *MaxAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *MaxAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *MaxAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *MaxAggregation) Script(script *elastic.Script) *MaxAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *MaxAggregation) StoredScript(id string, params map[string]interface{}) *MaxAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 func (a *MaxAggregation) Format(format string) *MaxAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 func (a *MaxAggregation) SubAggregation(name string, subAggregation Aggregation) *MaxAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *MaxAggregation) Meta(metaData map[string]interface{}) *MaxAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *MaxAggregation) AddMeta(key string, value interface{}) *MaxAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *MaxAggregation) AggregationType() string {
+	return "max"
+}
+
+func (a *MaxAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: MaxAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
 func (a *MaxAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *MaxAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -78,22 +129,12 @@ func (a *MaxAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
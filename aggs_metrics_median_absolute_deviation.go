@@ -0,0 +1,168 @@
+package aggretastic
+
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
+
+// MedianAbsoluteDeviationAggregation is a measure of variability.
+// It is a robust statistic, meaning that it is useful for describing data
+// that may have outliers, or may not be normally distributed.
+// For such data it can be more descriptive than standard deviation.
+//
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/6.6/search-aggregations-metrics-median-absolute-deviation-aggregation.html
+type MedianAbsoluteDeviationAggregation struct {
+	*tree
+	metaHolder
+
+	field       string
+	script      *elastic.Script
+	missing     interface{}
+	format      string
+	compression *float64
+}
+
+func NewMedianAbsoluteDeviationAggregation() *MedianAbsoluteDeviationAggregation {
+	a := &MedianAbsoluteDeviationAggregation{}
+	a.tree = nilAggregationTree(a)
+
+	return a
+}
+
+func (a *MedianAbsoluteDeviationAggregation) Field(field string) *MedianAbsoluteDeviationAggregation {
+	a.field = field
+	a.markDirty()
+	return a
+}
+
+// GetField returns the field the aggregation works on.
+func (a *MedianAbsoluteDeviationAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *MedianAbsoluteDeviationAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
+func (a *MedianAbsoluteDeviationAggregation) Script(script *elastic.Script) *MedianAbsoluteDeviationAggregation {
+	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *MedianAbsoluteDeviationAggregation) StoredScript(id string, params map[string]interface{}) *MedianAbsoluteDeviationAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
+	return a
+}
+
+// Missing configures the value to use when documents miss a value.
+func (a *MedianAbsoluteDeviationAggregation) Missing(missing interface{}) *MedianAbsoluteDeviationAggregation {
+	a.missing = missing
+	a.markDirty()
+	return a
+}
+
+func (a *MedianAbsoluteDeviationAggregation) Format(format string) *MedianAbsoluteDeviationAggregation {
+	a.format = format
+	a.markDirty()
+	return a
+}
+
+// Compression trades accuracy of the underlying TDigest for memory usage.
+func (a *MedianAbsoluteDeviationAggregation) Compression(compression float64) *MedianAbsoluteDeviationAggregation {
+	a.compression = &compression
+	a.markDirty()
+	return a
+}
+
+func (a *MedianAbsoluteDeviationAggregation) SubAggregation(name string, subAggregation Aggregation) *MedianAbsoluteDeviationAggregation {
+	a.setSub(name, subAggregation)
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *MedianAbsoluteDeviationAggregation) Meta(metaData map[string]interface{}) *MedianAbsoluteDeviationAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *MedianAbsoluteDeviationAggregation) AddMeta(key string, value interface{}) *MedianAbsoluteDeviationAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *MedianAbsoluteDeviationAggregation) AggregationType() string {
+	return "median_absolute_deviation"
+}
+
+func (a *MedianAbsoluteDeviationAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: MedianAbsoluteDeviationAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
+func (a *MedianAbsoluteDeviationAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *MedianAbsoluteDeviationAggregation) source(depth int) (interface{}, error) {
+	// Example:
+	//	{
+	//    "aggs" : {
+	//      "review_variability" : {
+	//        "median_absolute_deviation" : {
+	//          "field" : "rating",
+	//          "compression" : 100
+	//        }
+	//      }
+	//    }
+	//	}
+	// This method returns only the { "median_absolute_deviation" : { ... } } part.
+
+	source := make(map[string]interface{})
+	opts := make(map[string]interface{})
+	source["median_absolute_deviation"] = opts
+
+	// ValuesSourceAggregationBuilder
+	if a.field != "" {
+		opts["field"] = a.field
+	}
+	if a.script != nil {
+		src, err := a.script.Source()
+		if err != nil {
+			return nil, err
+		}
+		opts["script"] = src
+	}
+	if a.missing != nil {
+		opts["missing"] = a.missing
+	}
+
+	if a.format != "" {
+		opts["format"] = a.format
+	}
+	if a.compression != nil {
+		opts["compression"] = *a.compression
+	}
+
+	// AggregationBuilder (SubAggregations)
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
@@ -0,0 +1,26 @@
+package aggretastic
+
+import "testing"
+
+func TestMedianAbsoluteDeviationAggregation(t *testing.T) {
+	tests := []struct {
+		name string
+		agg  Aggregation
+		want string
+	}{
+		{"field", NewMedianAbsoluteDeviationAggregation().Field("rating"), `{"median_absolute_deviation":{"field":"rating"}}`},
+		{"options", NewMedianAbsoluteDeviationAggregation().Field("rating").Missing(5).Format("0.0").Compression(100),
+			`{"median_absolute_deviation":{"compression":100,"field":"rating","format":"0.0","missing":5}}`},
+		{"under terms", NewTermsAggregation().Field("genre").
+			SubAggregation("spread", NewMedianAbsoluteDeviationAggregation().Field("rating").Meta(map[string]interface{}{"unit": "stars"})),
+			`{"aggregations":{"spread":{"median_absolute_deviation":{"field":"rating"},"meta":{"unit":"stars"}}},"terms":{"field":"genre"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.agg.String() != test.want {
+				t.Fatalf("expected %s, got %s", test.want, test.agg)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // MinAggregation is a single-value metrics aggregation that keeps track and
 // returns the minimum value among numeric values extracted from the
@@ -10,11 +13,11 @@ import "github.com/olivere/elastic"
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-metrics-min-aggregation.html
 type MinAggregation struct {
 	*tree
+	metaHolder
 
 	field  string
 	script *elastic.Script
 	format string
-	meta   map[string]interface{}
 }
 
 func NewMinAggregation() *MinAggregation {
@@ -26,31 +29,78 @@ func NewMinAggregation() *MinAggregation {
 
 func (a *MinAggregation) Field(field string) *MinAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *MinAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *MinAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *MinAggregation) Script(script *elastic.Script) *MinAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *MinAggregation) StoredScript(id string, params map[string]interface{}) *MinAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 func (a *MinAggregation) Format(format string) *MinAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 func (a *MinAggregation) SubAggregation(name string, subAggregation Aggregation) *MinAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *MinAggregation) Meta(metaData map[string]interface{}) *MinAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *MinAggregation) AddMeta(key string, value interface{}) *MinAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *MinAggregation) AggregationType() string {
+	return "min"
+}
+
+func (a *MinAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: MinAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
 func (a *MinAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *MinAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -79,22 +129,12 @@ func (a *MinAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -1,16 +1,19 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // PercentileRanksAggregation
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-metrics-percentile-rank-aggregation.html
 type PercentileRanksAggregation struct {
 	*tree
+	metaHolder
 
 	field       string
 	script      *elastic.Script
 	format      string
-	meta        map[string]interface{}
 	values      []float64
 	compression *float64
 	estimator   string
@@ -25,46 +28,96 @@ func NewPercentileRanksAggregation() *PercentileRanksAggregation {
 
 func (a *PercentileRanksAggregation) Field(field string) *PercentileRanksAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *PercentileRanksAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *PercentileRanksAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *PercentileRanksAggregation) Script(script *elastic.Script) *PercentileRanksAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *PercentileRanksAggregation) StoredScript(id string, params map[string]interface{}) *PercentileRanksAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 func (a *PercentileRanksAggregation) Format(format string) *PercentileRanksAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 func (a *PercentileRanksAggregation) SubAggregation(name string, subAggregation Aggregation) *PercentileRanksAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *PercentileRanksAggregation) Meta(metaData map[string]interface{}) *PercentileRanksAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *PercentileRanksAggregation) AddMeta(key string, value interface{}) *PercentileRanksAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 func (a *PercentileRanksAggregation) Values(values ...float64) *PercentileRanksAggregation {
 	a.values = append(a.values, values...)
+	a.markDirty()
 	return a
 }
 
 func (a *PercentileRanksAggregation) Compression(compression float64) *PercentileRanksAggregation {
 	a.compression = &compression
+	a.markDirty()
 	return a
 }
 
 func (a *PercentileRanksAggregation) Estimator(estimator string) *PercentileRanksAggregation {
 	a.estimator = estimator
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *PercentileRanksAggregation) AggregationType() string {
+	return "percentile_ranks"
+}
+
+func (a *PercentileRanksAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: PercentileRanksAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
 func (a *PercentileRanksAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *PercentileRanksAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -109,22 +162,12 @@ func (a *PercentileRanksAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -1,6 +1,9 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // PercentilesAggregation is a multi-value metrics aggregation
 // that calculates one or more percentiles over numeric values
@@ -11,11 +14,11 @@ import "github.com/olivere/elastic"
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-metrics-percentile-aggregation.html
 type PercentilesAggregation struct {
 	*tree
+	metaHolder
 
 	field       string
 	script      *elastic.Script
 	format      string
-	meta        map[string]interface{}
 	percentiles []float64
 	compression *float64
 	estimator   string
@@ -30,46 +33,96 @@ func NewPercentilesAggregation() *PercentilesAggregation {
 
 func (a *PercentilesAggregation) Field(field string) *PercentilesAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *PercentilesAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *PercentilesAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *PercentilesAggregation) Script(script *elastic.Script) *PercentilesAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *PercentilesAggregation) StoredScript(id string, params map[string]interface{}) *PercentilesAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 func (a *PercentilesAggregation) Format(format string) *PercentilesAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 func (a *PercentilesAggregation) SubAggregation(name string, subAggregation Aggregation) *PercentilesAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *PercentilesAggregation) Meta(metaData map[string]interface{}) *PercentilesAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *PercentilesAggregation) AddMeta(key string, value interface{}) *PercentilesAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 func (a *PercentilesAggregation) Percentiles(percentiles ...float64) *PercentilesAggregation {
 	a.percentiles = append(a.percentiles, percentiles...)
+	a.markDirty()
 	return a
 }
 
 func (a *PercentilesAggregation) Compression(compression float64) *PercentilesAggregation {
 	a.compression = &compression
+	a.markDirty()
 	return a
 }
 
 func (a *PercentilesAggregation) Estimator(estimator string) *PercentilesAggregation {
 	a.estimator = estimator
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *PercentilesAggregation) AggregationType() string {
+	return "percentiles"
+}
+
+func (a *PercentilesAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: PercentilesAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
 func (a *PercentilesAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *PercentilesAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -113,22 +166,12 @@ func (a *PercentilesAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
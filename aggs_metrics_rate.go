@@ -0,0 +1,157 @@
+package aggretastic
+
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
+
+// RateAggregation is a metrics aggregation that calculates a rate of
+// documents or a field in each date_histogram bucket.
+//
+// It can only be used inside of a date_histogram aggregation, so inject it
+// as a sub-aggregation of a DateHistogramAggregation.
+//
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/7.10/search-aggregations-metrics-rate-aggregation.html
+type RateAggregation struct {
+	*notInjectable
+	metaHolder
+
+	field  string
+	script *elastic.Script
+	unit   string
+	mode   string
+	format string
+}
+
+func NewRateAggregation() *RateAggregation {
+	a := &RateAggregation{}
+	a.notInjectable = newNotInjectable(a)
+
+	return a
+}
+
+func (a *RateAggregation) Field(field string) *RateAggregation {
+	a.field = field
+	a.markDirty()
+	return a
+}
+
+// GetField returns the field the aggregation works on.
+func (a *RateAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *RateAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
+func (a *RateAggregation) Script(script *elastic.Script) *RateAggregation {
+	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *RateAggregation) StoredScript(id string, params map[string]interface{}) *RateAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
+	return a
+}
+
+// Unit sets the rate unit, e.g. "second", "minute", "hour", "day", "week",
+// "month", "quarter" or "year". Defaults to the parent date_histogram interval.
+func (a *RateAggregation) Unit(unit string) *RateAggregation {
+	a.unit = unit
+	a.markDirty()
+	return a
+}
+
+// Mode sets how the field values are accumulated. Valid values are
+// "sum" and "value_count". Default is "sum".
+func (a *RateAggregation) Mode(mode string) *RateAggregation {
+	a.mode = mode
+	a.markDirty()
+	return a
+}
+
+func (a *RateAggregation) Format(format string) *RateAggregation {
+	a.format = format
+	a.markDirty()
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *RateAggregation) Meta(metaData map[string]interface{}) *RateAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *RateAggregation) AddMeta(key string, value interface{}) *RateAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *RateAggregation) AggregationType() string {
+	return "rate"
+}
+
+func (a *RateAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: RateAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
+func (a *RateAggregation) Source() (interface{}, error) {
+	// Example:
+	//	{
+	//    "aggs" : {
+	//      "by_date" : {
+	//        "date_histogram" : { "field" : "date", "calendar_interval" : "month" },
+	//        "aggs" : {
+	//          "my_rate" : { "rate" : { "unit" : "year" } }
+	//        }
+	//      }
+	//    }
+	//	}
+	// This method returns only the { "rate" : { ... } } part.
+
+	source := make(map[string]interface{})
+	opts := make(map[string]interface{})
+	source["rate"] = opts
+
+	// ValuesSourceAggregationBuilder
+	if a.field != "" {
+		opts["field"] = a.field
+	}
+	if a.script != nil {
+		src, err := a.script.Source()
+		if err != nil {
+			return nil, err
+		}
+		opts["script"] = src
+	}
+
+	if a.unit != "" {
+		opts["unit"] = a.unit
+	}
+	if a.mode != "" {
+		opts["mode"] = a.mode
+	}
+	if a.format != "" {
+		opts["format"] = a.format
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
@@ -0,0 +1,15 @@
+package aggretastic
+
+import "testing"
+
+func TestRateAggregationUnderDateHistogram(t *testing.T) {
+	histogram := NewDateHistogramAggregation().Field("date").Interval("month")
+	if err := histogram.Inject(NewRateAggregation().Field("price").Unit("year").Mode("value_count"), "sales_per_year"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"aggregations":{"sales_per_year":{"rate":{"field":"price","mode":"value_count","unit":"year"}}},"date_histogram":{"field":"date","interval":"month"}}`
+	if histogram.String() != want {
+		t.Fatalf("expected %s, got %s", want, histogram)
+	}
+}
@@ -1,6 +1,9 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // ScriptedMetricAggregation
 // wip (larry) careful to be used
@@ -8,6 +11,7 @@ import "github.com/olivere/elastic"
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/current/search-aggregations-metrics-scripted-metric-aggregation.html
 type ScriptedMetricAggregation struct {
 	*notInjectable
+	metaHolder
 
 	initScript    *elastic.Script
 	mapScript     *elastic.Script
@@ -15,7 +19,6 @@ type ScriptedMetricAggregation struct {
 	reduceScript  *elastic.Script
 
 	params map[string]interface{}
-	meta   map[string]interface{}
 }
 
 func NewScriptedMetricAggregation() *ScriptedMetricAggregation {
@@ -27,35 +30,71 @@ func NewScriptedMetricAggregation() *ScriptedMetricAggregation {
 
 func (a *ScriptedMetricAggregation) InitScript(script *elastic.Script) *ScriptedMetricAggregation {
 	a.initScript = script
+	a.markDirty()
 	return a
 }
 
 func (a *ScriptedMetricAggregation) MapScript(script *elastic.Script) *ScriptedMetricAggregation {
 	a.mapScript = script
+	a.markDirty()
 	return a
 }
 
 func (a *ScriptedMetricAggregation) CombineScript(script *elastic.Script) *ScriptedMetricAggregation {
 	a.combineScript = script
+	a.markDirty()
 	return a
 }
 
 func (a *ScriptedMetricAggregation) ReduceScript(script *elastic.Script) *ScriptedMetricAggregation {
 	a.reduceScript = script
+	a.markDirty()
 	return a
 }
 
 func (a *ScriptedMetricAggregation) Params(params map[string]interface{}) *ScriptedMetricAggregation {
 	a.params = params
+	a.markDirty()
+	return a
+}
+
+// AddParam adds the key to the params of the scripts keeping the rest of them.
+func (a *ScriptedMetricAggregation) AddParam(key string, value interface{}) *ScriptedMetricAggregation {
+	if a.params == nil {
+		a.params = make(map[string]interface{})
+	}
+	a.params[key] = value
+	a.markDirty()
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *ScriptedMetricAggregation) Meta(metaData map[string]interface{}) *ScriptedMetricAggregation {
 	a.meta = metaData
+	a.markDirty()
 	return a
 }
 
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *ScriptedMetricAggregation) AddMeta(key string, value interface{}) *ScriptedMetricAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *ScriptedMetricAggregation) AggregationType() string {
+	return "scripted_metric"
+}
+
+func (a *ScriptedMetricAggregation) Validate() error {
+	if a.mapScript == nil {
+		return errors.New("elastic: ScriptedMetricAggregation requires a map script")
+	}
+
+	return nil
+}
+
 func (a *ScriptedMetricAggregation) Source() (interface{}, error) {
 	// Example:
 	//	{
@@ -70,6 +109,11 @@ func (a *ScriptedMetricAggregation) Source() (interface{}, error) {
 	//	}
 	// This method returns only the { "scripted_metric" : { ... } } part.
 
+	// Elasticsearch requires the map phase at least
+	if a.mapScript == nil {
+		return nil, errors.New("elastic: ScriptedMetricAggregation requires a map script")
+	}
+
 	source := make(map[string]interface{})
 	opts := make(map[string]interface{})
 	source["scripted_metric"] = opts
@@ -108,9 +152,7 @@ func (a *ScriptedMetricAggregation) Source() (interface{}, error) {
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -0,0 +1,32 @@
+package aggretastic
+
+import (
+	"github.com/olivere/elastic"
+	"testing"
+)
+
+func TestScriptedMetricAggregationAddParam(t *testing.T) {
+	agg := NewScriptedMetricAggregation().
+		MapScript(elastic.NewScript("state.total += doc['amount'].value * params.rate")).
+		AddParam("rate", 2)
+	agg.AddParam("currency", "EUR")
+
+	want := `{"scripted_metric":{"map_script":"state.total += doc['amount'].value * params.rate","params":{"currency":"EUR","rate":2}}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestScriptedMetricAggregationRequiresMapScript(t *testing.T) {
+	agg := NewScriptedMetricAggregation().
+		InitScript(elastic.NewScript("state.total = 0")).
+		ReduceScript(elastic.NewScript("states.sum()")).
+		AddParam("rate", 2)
+
+	if err := agg.Validate(); err == nil {
+		t.Fatal("expected Validate to fail without a map script")
+	}
+	if _, err := agg.Source(); err == nil {
+		t.Fatal("expected Source to fail without a map script")
+	}
+}
@@ -1,6 +1,9 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // StatsAggregation is a multi-value metrics aggregation that computes stats
 // over numeric values extracted from the aggregated documents.
@@ -9,11 +12,12 @@ import "github.com/olivere/elastic"
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-metrics-stats-aggregation.html
 type StatsAggregation struct {
 	*tree
+	metaHolder
 
-	field  string
-	script *elastic.Script
-	format string
-	meta   map[string]interface{}
+	field   string
+	script  *elastic.Script
+	missing interface{}
+	format  string
 }
 
 func NewStatsAggregation() *StatsAggregation {
@@ -25,31 +29,85 @@ func NewStatsAggregation() *StatsAggregation {
 
 func (a *StatsAggregation) Field(field string) *StatsAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *StatsAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *StatsAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *StatsAggregation) Script(script *elastic.Script) *StatsAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *StatsAggregation) StoredScript(id string, params map[string]interface{}) *StatsAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
+	return a
+}
+
+// Missing configures the value to use when documents miss a value.
+func (a *StatsAggregation) Missing(missing interface{}) *StatsAggregation {
+	a.missing = missing
+	a.markDirty()
 	return a
 }
 
 func (a *StatsAggregation) Format(format string) *StatsAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 func (a *StatsAggregation) SubAggregation(name string, subAggregation Aggregation) *StatsAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *StatsAggregation) Meta(metaData map[string]interface{}) *StatsAggregation {
 	a.meta = metaData
+	a.markDirty()
 	return a
 }
 
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *StatsAggregation) AddMeta(key string, value interface{}) *StatsAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *StatsAggregation) AggregationType() string {
+	return "stats"
+}
+
+func (a *StatsAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: StatsAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
 func (a *StatsAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *StatsAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -73,27 +131,20 @@ func (a *StatsAggregation) Source() (interface{}, error) {
 		}
 		opts["script"] = src
 	}
+	if a.missing != nil {
+		opts["missing"] = a.missing
+	}
 	if a.format != "" {
 		opts["format"] = a.format
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -0,0 +1,46 @@
+package aggretastic
+
+import (
+	"github.com/olivere/elastic"
+	"testing"
+)
+
+func TestStatsAggregationMissing(t *testing.T) {
+	agg := NewStatsAggregation().Field("grade")
+	want := `{"stats":{"field":"grade"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	agg.Missing(50)
+	want = `{"stats":{"field":"grade","missing":50}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestStoredScript(t *testing.T) {
+	params := map[string]interface{}{"factor": 2}
+	inline := elastic.NewScript("doc['grade'].value")
+
+	tests := []struct {
+		name string
+		agg  Aggregation
+		want string
+	}{
+		{"stats stored", NewStatsAggregation().StoredScript("grades", params), `{"stats":{"script":{"id":"grades","params":{"factor":2}}}}`},
+		{"stats inline", NewStatsAggregation().Script(inline), `{"stats":{"script":"doc['grade'].value"}}`},
+		{"value count stored", NewValueCountAggregation().StoredScript("grades", nil), `{"value_count":{"script":{"id":"grades"}}}`},
+		{"boxplot stored", NewBoxplotAggregation().StoredScript("grades", params), `{"boxplot":{"script":{"id":"grades","params":{"factor":2}}}}`},
+		{"stored wins", NewStatsAggregation().Script(inline).StoredScript("grades", nil), `{"stats":{"script":{"id":"grades"}}}`},
+		{"inline wins", NewStatsAggregation().StoredScript("grades", params).Script(inline), `{"stats":{"script":"doc['grade'].value"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.agg.String() != test.want {
+				t.Fatalf("expected %s, got %s", test.want, test.agg)
+			}
+		})
+	}
+}
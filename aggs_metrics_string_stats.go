@@ -0,0 +1,152 @@
+package aggretastic
+
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
+
+// StringStatsAggregation is a multi-value metrics aggregation that computes
+// statistics over string values extracted from the aggregated documents:
+// count, min_length, max_length, avg_length and entropy.
+//
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/7.6/search-aggregations-metrics-string-stats-aggregation.html
+type StringStatsAggregation struct {
+	*tree
+	metaHolder
+
+	field            string
+	script           *elastic.Script
+	missing          interface{}
+	showDistribution bool
+}
+
+func NewStringStatsAggregation() *StringStatsAggregation {
+	a := &StringStatsAggregation{}
+	a.tree = nilAggregationTree(a)
+
+	return a
+}
+
+func (a *StringStatsAggregation) Field(field string) *StringStatsAggregation {
+	a.field = field
+	a.markDirty()
+	return a
+}
+
+// GetField returns the field the aggregation works on.
+func (a *StringStatsAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *StringStatsAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
+func (a *StringStatsAggregation) Script(script *elastic.Script) *StringStatsAggregation {
+	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *StringStatsAggregation) StoredScript(id string, params map[string]interface{}) *StringStatsAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
+	return a
+}
+
+// Missing configures the value to use when documents miss a value.
+func (a *StringStatsAggregation) Missing(missing interface{}) *StringStatsAggregation {
+	a.missing = missing
+	a.markDirty()
+	return a
+}
+
+// ShowDistribution enables the probability distribution of all characters
+// in the response.
+func (a *StringStatsAggregation) ShowDistribution(showDistribution bool) *StringStatsAggregation {
+	a.showDistribution = showDistribution
+	a.markDirty()
+	return a
+}
+
+func (a *StringStatsAggregation) SubAggregation(name string, subAggregation Aggregation) *StringStatsAggregation {
+	a.setSub(name, subAggregation)
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *StringStatsAggregation) Meta(metaData map[string]interface{}) *StringStatsAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *StringStatsAggregation) AddMeta(key string, value interface{}) *StringStatsAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *StringStatsAggregation) AggregationType() string {
+	return "string_stats"
+}
+
+func (a *StringStatsAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: StringStatsAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
+func (a *StringStatsAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *StringStatsAggregation) source(depth int) (interface{}, error) {
+	// Example:
+	//	{
+	//    "aggs" : {
+	//      "message_stats" : { "string_stats" : { "field" : "message.keyword" } }
+	//    }
+	//	}
+	// This method returns only the { "string_stats" : { ... } } part.
+
+	source := make(map[string]interface{})
+	opts := make(map[string]interface{})
+	source["string_stats"] = opts
+
+	// ValuesSourceAggregationBuilder
+	if a.field != "" {
+		opts["field"] = a.field
+	}
+	if a.script != nil {
+		src, err := a.script.Source()
+		if err != nil {
+			return nil, err
+		}
+		opts["script"] = src
+	}
+	if a.missing != nil {
+		opts["missing"] = a.missing
+	}
+	if a.showDistribution {
+		opts["show_distribution"] = true
+	}
+
+	// AggregationBuilder (SubAggregations)
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
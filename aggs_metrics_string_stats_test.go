@@ -0,0 +1,23 @@
+package aggretastic
+
+import "testing"
+
+func TestStringStatsAggregationShowDistribution(t *testing.T) {
+	agg := NewStringStatsAggregation().Field("message.keyword").Missing("[empty]")
+	want := `{"string_stats":{"field":"message.keyword","missing":"[empty]"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	agg.ShowDistribution(true)
+	want = `{"string_stats":{"field":"message.keyword","missing":"[empty]","show_distribution":true}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	agg.ShowDistribution(false)
+	want = `{"string_stats":{"field":"message.keyword","missing":"[empty]"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
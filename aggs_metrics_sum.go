@@ -1,6 +1,9 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // SumAggregation is a single-value metrics aggregation that sums up
 // numeric values that are extracted from the aggregated documents.
@@ -9,11 +12,11 @@ import "github.com/olivere/elastic"
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-metrics-sum-aggregation.html
 type SumAggregation struct {
 	*tree
+	metaHolder
 
 	field  string
 	script *elastic.Script
 	format string
-	meta   map[string]interface{}
 }
 
 func NewSumAggregation() *SumAggregation {
@@ -25,31 +28,78 @@ func NewSumAggregation() *SumAggregation {
 
 func (a *SumAggregation) Field(field string) *SumAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *SumAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *SumAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *SumAggregation) Script(script *elastic.Script) *SumAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *SumAggregation) StoredScript(id string, params map[string]interface{}) *SumAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 func (a *SumAggregation) Format(format string) *SumAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 func (a *SumAggregation) SubAggregation(name string, subAggregation Aggregation) *SumAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *SumAggregation) Meta(metaData map[string]interface{}) *SumAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *SumAggregation) AddMeta(key string, value interface{}) *SumAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *SumAggregation) AggregationType() string {
+	return "sum"
+}
+
+func (a *SumAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: SumAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
 func (a *SumAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *SumAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -78,22 +128,12 @@ func (a *SumAggregation) Source() (interface{}, error) {
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -0,0 +1,273 @@
+package aggretastic
+
+import "github.com/olivere/elastic"
+
+// TopHitsAggregation keeps track of the most relevant document
+// being aggregated. This aggregator is intended to be used as a
+// sub aggregator, so that the top matching documents
+// can be aggregated per bucket.
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-metrics-top-hits-aggregation.html
+type TopHitsAggregation struct {
+	*notInjectable
+	metaHolder
+
+	from        *int
+	size        *int
+	trackScores *bool
+	explain     *bool
+	version     *bool
+	sorters     []elastic.Sorter
+	highlight   *elastic.Highlight
+
+	fetchSourceContext *elastic.FetchSourceContext
+	sourceIncludes     []string
+	sourceExcludes     []string
+	docvalueFields     []string
+	storedFields       []string
+	noStoredFields     bool
+	scriptFields       []topHitsScriptField
+}
+
+// topHitsScriptField is a field of the hits computed by the script
+type topHitsScriptField struct {
+	name   string
+	script *elastic.Script
+}
+
+func NewTopHitsAggregation() *TopHitsAggregation {
+	a := &TopHitsAggregation{}
+	a.notInjectable = newNotInjectable(a)
+
+	return a
+}
+
+func (a *TopHitsAggregation) From(from int) *TopHitsAggregation {
+	a.from = &from
+	a.markDirty()
+	return a
+}
+
+// Size sets the maximum number of top matching hits to return per bucket.
+func (a *TopHitsAggregation) Size(size int) *TopHitsAggregation {
+	a.size = &size
+	a.markDirty()
+	return a
+}
+
+func (a *TopHitsAggregation) TrackScores(trackScores bool) *TopHitsAggregation {
+	a.trackScores = &trackScores
+	a.markDirty()
+	return a
+}
+
+func (a *TopHitsAggregation) Explain(explain bool) *TopHitsAggregation {
+	a.explain = &explain
+	a.markDirty()
+	return a
+}
+
+func (a *TopHitsAggregation) Version(version bool) *TopHitsAggregation {
+	a.version = &version
+	a.markDirty()
+	return a
+}
+
+// FetchSource enables or disables returning the _source of the hits.
+func (a *TopHitsAggregation) FetchSource(fetchSource bool) *TopHitsAggregation {
+	return a.FetchSourceContext(elastic.NewFetchSourceContext(fetchSource))
+}
+
+// FetchSourceContext sets the _source fields to return.
+// It replaces the fields set with FetchSourceIncludeExclude.
+func (a *TopHitsAggregation) FetchSourceContext(fetchSourceContext *elastic.FetchSourceContext) *TopHitsAggregation {
+	a.fetchSourceContext = fetchSourceContext
+	a.sourceIncludes, a.sourceExcludes = nil, nil
+	a.markDirty()
+	return a
+}
+
+// FetchSourceIncludeExclude sets the _source fields to include into and exclude from the hits
+// without building an elastic.FetchSourceContext. Empty lists are omitted.
+// It replaces the context set with FetchSource or FetchSourceContext.
+func (a *TopHitsAggregation) FetchSourceIncludeExclude(includes, excludes []string) *TopHitsAggregation {
+	a.fetchSourceContext = nil
+	a.sourceIncludes, a.sourceExcludes = includes, excludes
+	a.markDirty()
+	return a
+}
+
+// DocValueFields adds the fields to return from the doc values of the hits.
+func (a *TopHitsAggregation) DocValueFields(fields ...string) *TopHitsAggregation {
+	a.docvalueFields = append(a.docvalueFields, fields...)
+	a.markDirty()
+	return a
+}
+
+// ScriptField adds the field computed by the script for every hit.
+func (a *TopHitsAggregation) ScriptField(name string, script *elastic.Script) *TopHitsAggregation {
+	a.scriptFields = append(a.scriptFields, topHitsScriptField{name: name, script: script})
+	a.markDirty()
+	return a
+}
+
+// StoredFields sets the stored fields to return for the hits.
+// It replaces NoStoredFields.
+func (a *TopHitsAggregation) StoredFields(fields ...string) *TopHitsAggregation {
+	a.noStoredFields = false
+	a.storedFields = append(a.storedFields, fields...)
+	a.markDirty()
+	return a
+}
+
+// NoStoredFields disables returning the stored fields (and the metadata like _id) of the hits.
+// It replaces the fields set with StoredFields.
+func (a *TopHitsAggregation) NoStoredFields() *TopHitsAggregation {
+	a.noStoredFields = true
+	a.storedFields = nil
+	a.markDirty()
+	return a
+}
+
+// Sort adds a sort order to the list of sorters.
+func (a *TopHitsAggregation) Sort(field string, ascending bool) *TopHitsAggregation {
+	a.sorters = append(a.sorters, elastic.SortInfo{Field: field, Ascending: ascending})
+	a.markDirty()
+	return a
+}
+
+// SortWithInfo adds a SortInfo to the list of sorters.
+func (a *TopHitsAggregation) SortWithInfo(info elastic.SortInfo) *TopHitsAggregation {
+	a.sorters = append(a.sorters, info)
+	a.markDirty()
+	return a
+}
+
+// SortBy adds the sorters to the list of sorters.
+func (a *TopHitsAggregation) SortBy(sorter ...elastic.Sorter) *TopHitsAggregation {
+	a.sorters = append(a.sorters, sorter...)
+	a.markDirty()
+	return a
+}
+
+func (a *TopHitsAggregation) Highlight(highlight *elastic.Highlight) *TopHitsAggregation {
+	a.highlight = highlight
+	a.markDirty()
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *TopHitsAggregation) Meta(metaData map[string]interface{}) *TopHitsAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *TopHitsAggregation) AddMeta(key string, value interface{}) *TopHitsAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *TopHitsAggregation) AggregationType() string {
+	return "top_hits"
+}
+
+func (a *TopHitsAggregation) Source() (interface{}, error) {
+	// Example:
+	//	{
+	//    "aggs" : {
+	//      "top_tag_hits" : {
+	//        "top_hits" : {
+	//          "sort" : [{ "last_activity_date" : { "order" : "desc" } }],
+	//          "_source" : { "includes" : ["title"] },
+	//          "size" : 1
+	//        }
+	//      }
+	//    }
+	//	}
+	// This method returns only the { "top_hits" : { ... } } part.
+
+	source := make(map[string]interface{})
+	opts := make(map[string]interface{})
+	source["top_hits"] = opts
+
+	if a.from != nil {
+		opts["from"] = *a.from
+	}
+	if a.size != nil {
+		opts["size"] = *a.size
+	}
+	if a.trackScores != nil {
+		opts["track_scores"] = *a.trackScores
+	}
+	if a.explain != nil {
+		opts["explain"] = *a.explain
+	}
+	if a.version != nil {
+		opts["version"] = *a.version
+	}
+
+	// _source
+	if a.fetchSourceContext != nil {
+		src, err := a.fetchSourceContext.Source()
+		if err != nil {
+			return nil, err
+		}
+		opts["_source"] = src
+	} else if a.sourceIncludes != nil || a.sourceExcludes != nil {
+		src := make(map[string]interface{})
+		if len(a.sourceIncludes) > 0 {
+			src["includes"] = a.sourceIncludes
+		}
+		if len(a.sourceExcludes) > 0 {
+			src["excludes"] = a.sourceExcludes
+		}
+		opts["_source"] = src
+	}
+
+	if a.noStoredFields {
+		opts["stored_fields"] = "_none_"
+	} else if len(a.storedFields) > 0 {
+		opts["stored_fields"] = a.storedFields
+	}
+	if len(a.docvalueFields) > 0 {
+		opts["docvalue_fields"] = a.docvalueFields
+	}
+	if len(a.scriptFields) > 0 {
+		scriptFields := make(map[string]interface{})
+		for _, field := range a.scriptFields {
+			src, err := field.script.Source()
+			if err != nil {
+				return nil, err
+			}
+			scriptFields[field.name] = map[string]interface{}{"script": src}
+		}
+		opts["script_fields"] = scriptFields
+	}
+
+	if len(a.sorters) > 0 {
+		sorters := make([]interface{}, len(a.sorters))
+		for idx, sorter := range a.sorters {
+			src, err := sorter.Source()
+			if err != nil {
+				return nil, err
+			}
+			sorters[idx] = src
+		}
+		opts["sort"] = sorters
+	}
+	if a.highlight != nil {
+		src, err := a.highlight.Source()
+		if err != nil {
+			return nil, err
+		}
+		opts["highlight"] = src
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
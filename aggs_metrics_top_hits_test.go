@@ -0,0 +1,57 @@
+package aggretastic
+
+import (
+	"github.com/olivere/elastic"
+	"testing"
+)
+
+func TestTopHitsAggregationFetchSourceIncludeExclude(t *testing.T) {
+	tests := []struct {
+		name     string
+		includes []string
+		excludes []string
+		want     string
+	}{
+		{"both", []string{"title", "date"}, []string{"body"}, `{"excludes":["body"],"includes":["title","date"]}`},
+		{"includes only", []string{"title"}, nil, `{"includes":["title"]}`},
+		{"excludes only", nil, []string{"body"}, `{"excludes":["body"]}`},
+		{"empty", []string{}, nil, `{}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			agg := NewTopHitsAggregation().Size(1).FetchSourceIncludeExclude(test.includes, test.excludes)
+			want := `{"top_hits":{"_source":` + test.want + `,"size":1}}`
+			if agg.String() != want {
+				t.Fatalf("expected %s, got %s", want, agg)
+			}
+		})
+	}
+}
+
+func TestTopHitsAggregationDocValueAndScriptFields(t *testing.T) {
+	script := elastic.NewScriptInline("doc['price'].value * params.rate").Param("rate", 1.2)
+	agg := NewTopHitsAggregation().Size(1).FetchSource(false).
+		DocValueFields("date", "user").
+		ScriptField("gross", script)
+
+	want := `{"top_hits":{"_source":false,"docvalue_fields":["date","user"],` +
+		`"script_fields":{"gross":{"script":{"params":{"rate":1.2},"source":"doc['price'].value * params.rate"}}},"size":1}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestTopHitsAggregationStoredFields(t *testing.T) {
+	agg := NewTopHitsAggregation().StoredFields("title", "date")
+	want := `{"top_hits":{"stored_fields":["title","date"]}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	agg.NoStoredFields()
+	want = `{"top_hits":{"stored_fields":"_none_"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
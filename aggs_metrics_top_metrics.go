@@ -0,0 +1,131 @@
+package aggretastic
+
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
+
+// TopMetricsAggregation selects metrics from the document with the largest
+// or smallest "sort" value. It is a lighter alternative to top_hits when only
+// a few field values of the top document are needed.
+//
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/7.7/search-aggregations-metrics-top-metrics.html
+type TopMetricsAggregation struct {
+	*notInjectable
+	metaHolder
+
+	fields  []string
+	sorters []elastic.Sorter
+	size    *int
+}
+
+func NewTopMetricsAggregation() *TopMetricsAggregation {
+	a := &TopMetricsAggregation{}
+	a.notInjectable = newNotInjectable(a)
+
+	return a
+}
+
+// Metrics adds the fields whose values are returned for the top documents.
+func (a *TopMetricsAggregation) Metrics(fields ...string) *TopMetricsAggregation {
+	a.fields = append(a.fields, fields...)
+	a.markDirty()
+	return a
+}
+
+// Sort adds a sort order to the list of sorters.
+func (a *TopMetricsAggregation) Sort(field string, ascending bool) *TopMetricsAggregation {
+	a.sorters = append(a.sorters, elastic.SortInfo{Field: field, Ascending: ascending})
+	a.markDirty()
+	return a
+}
+
+// SortWithInfo adds a SortInfo to the list of sorters.
+func (a *TopMetricsAggregation) SortWithInfo(info elastic.SortInfo) *TopMetricsAggregation {
+	a.sorters = append(a.sorters, info)
+	a.markDirty()
+	return a
+}
+
+// Size sets the number of top documents to return metrics for.
+func (a *TopMetricsAggregation) Size(size int) *TopMetricsAggregation {
+	a.size = &size
+	a.markDirty()
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *TopMetricsAggregation) Meta(metaData map[string]interface{}) *TopMetricsAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *TopMetricsAggregation) AddMeta(key string, value interface{}) *TopMetricsAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *TopMetricsAggregation) AggregationType() string {
+	return "top_metrics"
+}
+
+func (a *TopMetricsAggregation) Validate() error {
+	if len(a.fields) == 0 {
+		return errors.New("elastic: TopMetricsAggregation requires at least one metric")
+	}
+	if len(a.sorters) == 0 {
+		return errors.New("elastic: TopMetricsAggregation requires a sort")
+	}
+
+	return nil
+}
+
+func (a *TopMetricsAggregation) Source() (interface{}, error) {
+	// Example:
+	//	{
+	//    "aggs" : {
+	//      "tm" : {
+	//        "top_metrics" : {
+	//          "metrics" : [{ "field" : "m" }],
+	//          "sort" : { "s" : "desc" },
+	//          "size" : 1
+	//        }
+	//      }
+	//    }
+	//	}
+	// This method returns only the { "top_metrics" : { ... } } part.
+
+	source := make(map[string]interface{})
+	opts := make(map[string]interface{})
+	source["top_metrics"] = opts
+
+	metrics := make([]interface{}, len(a.fields))
+	for i, field := range a.fields {
+		metrics[i] = map[string]interface{}{"field": field}
+	}
+	opts["metrics"] = metrics
+
+	if len(a.sorters) > 0 {
+		sorters := make([]interface{}, len(a.sorters))
+		for idx, sorter := range a.sorters {
+			src, err := sorter.Source()
+			if err != nil {
+				return nil, err
+			}
+			sorters[idx] = src
+		}
+		opts["sort"] = sorters
+	}
+	if a.size != nil {
+		opts["size"] = *a.size
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
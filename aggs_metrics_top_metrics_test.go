@@ -0,0 +1,19 @@
+package aggretastic
+
+import (
+	"github.com/olivere/elastic"
+	"testing"
+)
+
+func TestTopMetricsAggregation(t *testing.T) {
+	agg := NewTopMetricsAggregation().
+		Metrics("price", "stock").
+		Sort("date", false).
+		SortWithInfo(elastic.SortInfo{Field: "id", Ascending: true}).
+		Size(1)
+
+	want := `{"top_metrics":{"metrics":[{"field":"price"},{"field":"stock"}],"size":1,"sort":[{"date":{"order":"desc"}},{"id":{"order":"asc"}}]}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
@@ -0,0 +1,144 @@
+package aggretastic
+
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
+
+// TTestAggregation is a metrics aggregation that performs a statistical
+// hypothesis test in which the test statistic follows a Student's
+// t-distribution under the null hypothesis on numeric values extracted
+// from the aggregated documents.
+//
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/7.8/search-aggregations-metrics-ttest-aggregation.html
+type TTestAggregation struct {
+	*notInjectable
+	metaHolder
+
+	a        *TTestPopulation
+	b        *TTestPopulation
+	testType string
+}
+
+// TTestPopulation is one of the two populations compared by a TTestAggregation.
+type TTestPopulation struct {
+	Field  string
+	Filter elastic.Query
+}
+
+// Source returns serializable JSON of the TTestPopulation.
+func (p *TTestPopulation) Source() (interface{}, error) {
+	if p.Field == "" {
+		return nil, errors.New("elastic: TTestAggregation population requires a field")
+	}
+
+	source := make(map[string]interface{})
+	source["field"] = p.Field
+	if p.Filter != nil {
+		src, err := p.Filter.Source()
+		if err != nil {
+			return nil, err
+		}
+		source["filter"] = src
+	}
+	return source, nil
+}
+
+func NewTTestAggregation() *TTestAggregation {
+	a := &TTestAggregation{}
+	a.notInjectable = newNotInjectable(a)
+
+	return a
+}
+
+// A sets the first population. The filter is optional.
+func (a *TTestAggregation) A(field string, filter elastic.Query) *TTestAggregation {
+	a.a = &TTestPopulation{Field: field, Filter: filter}
+	a.markDirty()
+	return a
+}
+
+// B sets the second population. The filter is optional.
+func (a *TTestAggregation) B(field string, filter elastic.Query) *TTestAggregation {
+	a.b = &TTestPopulation{Field: field, Filter: filter}
+	a.markDirty()
+	return a
+}
+
+// Type of the test. Valid values are: "paired", "homoscedastic"
+// or "heteroscedastic". Default is "heteroscedastic".
+func (a *TTestAggregation) Type(testType string) *TTestAggregation {
+	a.testType = testType
+	a.markDirty()
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *TTestAggregation) Meta(metaData map[string]interface{}) *TTestAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *TTestAggregation) AddMeta(key string, value interface{}) *TTestAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *TTestAggregation) AggregationType() string {
+	return "t_test"
+}
+
+func (a *TTestAggregation) Validate() error {
+	if a.a == nil || a.b == nil {
+		return errors.New("elastic: TTestAggregation requires both populations")
+	}
+
+	return nil
+}
+
+func (a *TTestAggregation) Source() (interface{}, error) {
+	// Example:
+	//	{
+	//    "aggs" : {
+	//      "startup_load_time_ttest" : {
+	//        "t_test" : {
+	//          "a" : { "field" : "startup_time_before" },
+	//          "b" : { "field" : "startup_time_after" },
+	//          "type" : "paired"
+	//        }
+	//      }
+	//    }
+	//	}
+	// This method returns only the { "t_test" : { ... } } part.
+
+	source := make(map[string]interface{})
+	opts := make(map[string]interface{})
+	source["t_test"] = opts
+
+	if a.a == nil || a.b == nil {
+		return nil, errors.New("elastic: TTestAggregation requires both populations")
+	}
+	src, err := a.a.Source()
+	if err != nil {
+		return nil, err
+	}
+	opts["a"] = src
+	src, err = a.b.Source()
+	if err != nil {
+		return nil, err
+	}
+	opts["b"] = src
+
+	if a.testType != "" {
+		opts["type"] = a.testType
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
@@ -0,0 +1,40 @@
+package aggretastic
+
+import (
+	"github.com/olivere/elastic"
+	"testing"
+)
+
+func TestTTestAggregationFilteredPopulations(t *testing.T) {
+	agg := NewTTestAggregation().
+		A("startup_time_before", elastic.NewTermQuery("group", "A")).
+		B("startup_time_before", elastic.NewTermQuery("group", "B")).
+		Type("heteroscedastic")
+
+	want := `{"t_test":{"a":{"field":"startup_time_before","filter":{"term":{"group":"A"}}},"b":{"field":"startup_time_before","filter":{"term":{"group":"B"}}},"type":"heteroscedastic"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+	if err := agg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTTestAggregationRequiresFields(t *testing.T) {
+	tests := []struct {
+		name string
+		agg  *TTestAggregation
+	}{
+		{"no populations", NewTTestAggregation()},
+		{"no b", NewTTestAggregation().A("x", nil)},
+		{"empty field", NewTTestAggregation().A("x", nil).B("", elastic.NewMatchAllQuery())},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.agg.Source(); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // ValueCountAggregation is a single-value metrics aggregation that counts
 // the number of values that are extracted from the aggregated documents.
@@ -12,11 +15,12 @@ import "github.com/olivere/elastic"
 // See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-metrics-valuecount-aggregation.html
 type ValueCountAggregation struct {
 	*tree
+	metaHolder
 
-	field  string
-	script *elastic.Script
-	format string
-	meta   map[string]interface{}
+	field   string
+	script  *elastic.Script
+	missing interface{}
+	format  string
 }
 
 func NewValueCountAggregation() *ValueCountAggregation {
@@ -28,31 +32,85 @@ func NewValueCountAggregation() *ValueCountAggregation {
 
 func (a *ValueCountAggregation) Field(field string) *ValueCountAggregation {
 	a.field = field
+	a.markDirty()
 	return a
 }
 
+// GetField returns the field the aggregation works on.
+func (a *ValueCountAggregation) GetField() string {
+	return a.field
+}
+
+// SetField sets the field like Field does, for the code working with FieldSetter.
+func (a *ValueCountAggregation) SetField(field string) {
+	a.field = field
+	a.markDirty()
+}
+
 func (a *ValueCountAggregation) Script(script *elastic.Script) *ValueCountAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *ValueCountAggregation) StoredScript(id string, params map[string]interface{}) *ValueCountAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
+	return a
+}
+
+// Missing configures the value to use when documents miss a value.
+func (a *ValueCountAggregation) Missing(missing interface{}) *ValueCountAggregation {
+	a.missing = missing
+	a.markDirty()
 	return a
 }
 
 func (a *ValueCountAggregation) Format(format string) *ValueCountAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 func (a *ValueCountAggregation) SubAggregation(name string, subAggregation Aggregation) *ValueCountAggregation {
-	a.subAggregations[name] = subAggregation
+	a.setSub(name, subAggregation)
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *ValueCountAggregation) Meta(metaData map[string]interface{}) *ValueCountAggregation {
 	a.meta = metaData
+	a.markDirty()
 	return a
 }
 
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *ValueCountAggregation) AddMeta(key string, value interface{}) *ValueCountAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *ValueCountAggregation) AggregationType() string {
+	return "value_count"
+}
+
+func (a *ValueCountAggregation) Validate() error {
+	if a.field == "" && a.script == nil {
+		return errors.New("elastic: ValueCountAggregation requires a field or a script")
+	}
+
+	return nil
+}
+
 func (a *ValueCountAggregation) Source() (interface{}, error) {
+	return a.cachedSource(a.source)
+}
+
+func (a *ValueCountAggregation) source(depth int) (interface{}, error) {
 	// Example:
 	//	{
 	//    "aggs" : {
@@ -76,27 +134,20 @@ func (a *ValueCountAggregation) Source() (interface{}, error) {
 		}
 		opts["script"] = src
 	}
+	if a.missing != nil {
+		opts["missing"] = a.missing
+	}
 	if a.format != "" {
 		opts["format"] = a.format
 	}
 
 	// AggregationBuilder (SubAggregations)
-	if len(a.subAggregations) > 0 {
-		aggsMap := make(map[string]interface{})
-		source["aggregations"] = aggsMap
-		for name, aggregate := range a.subAggregations {
-			src, err := aggregate.Source()
-			if err != nil {
-				return nil, err
-			}
-			aggsMap[name] = src
-		}
+	if err := a.renderSubAggregations(source, depth); err != nil {
+		return nil, err
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
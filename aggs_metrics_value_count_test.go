@@ -0,0 +1,17 @@
+package aggretastic
+
+import "testing"
+
+func TestValueCountAggregationMissing(t *testing.T) {
+	agg := NewValueCountAggregation().Field("grade")
+	want := `{"value_count":{"field":"grade"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+
+	agg.Missing(0)
+	want = `{"value_count":{"field":"grade","missing":0}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
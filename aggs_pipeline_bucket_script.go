@@ -3,7 +3,10 @@
 
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // BucketScriptAggregation is a parent pipeline aggregation which executes
 // a script which can perform per bucket computations on specified metrics
@@ -14,12 +17,12 @@ import "github.com/olivere/elastic"
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-pipeline-bucket-script-aggregation.html
 type BucketScriptAggregation struct {
 	*notInjectable
+	metaHolder
 
 	format    string
 	gapPolicy string
 	script    *elastic.Script
 
-	meta            map[string]interface{}
 	bucketsPathsMap map[string]string
 }
 
@@ -34,43 +37,73 @@ func NewBucketScriptAggregation() *BucketScriptAggregation {
 // Format to use on the output of this aggregation.
 func (a *BucketScriptAggregation) Format(format string) *BucketScriptAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 // GapPolicy defines what should be done when a gap in the series is discovered.
-// Valid values include "insert_zeros" or "skip". Default is "insert_zeros".
+// Valid values include "insert_zeros", "skip" or "keep_values". Default is "insert_zeros".
 func (a *BucketScriptAggregation) GapPolicy(gapPolicy string) *BucketScriptAggregation {
 	a.gapPolicy = gapPolicy
+	a.markDirty()
 	return a
 }
 
 // GapInsertZeros inserts zeros for gaps in the series.
 func (a *BucketScriptAggregation) GapInsertZeros() *BucketScriptAggregation {
 	a.gapPolicy = "insert_zeros"
+	a.markDirty()
 	return a
 }
 
 // GapSkip skips gaps in the series.
 func (a *BucketScriptAggregation) GapSkip() *BucketScriptAggregation {
 	a.gapPolicy = "skip"
+	a.markDirty()
+	return a
+}
+
+// GapKeepValues skips gaps in the series like GapSkip, but keeps the non-null
+// values produced by the metrics the buckets path refers to (Elasticsearch 7.4+).
+func (a *BucketScriptAggregation) GapKeepValues() *BucketScriptAggregation {
+	a.gapPolicy = "keep_values"
+	a.markDirty()
 	return a
 }
 
 // Script is the script to run.
 func (a *BucketScriptAggregation) Script(script *elastic.Script) *BucketScriptAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *BucketScriptAggregation) StoredScript(id string, params map[string]interface{}) *BucketScriptAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *BucketScriptAggregation) Meta(metaData map[string]interface{}) *BucketScriptAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *BucketScriptAggregation) AddMeta(key string, value interface{}) *BucketScriptAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 // BucketsPathsMap sets the paths to the buckets to use for this pipeline aggregator.
 func (a *BucketScriptAggregation) BucketsPathsMap(bucketsPathsMap map[string]string) *BucketScriptAggregation {
 	a.bucketsPathsMap = bucketsPathsMap
+	a.markDirty()
 	return a
 }
 
@@ -80,9 +113,26 @@ func (a *BucketScriptAggregation) AddBucketsPath(name, path string) *BucketScrip
 		a.bucketsPathsMap = make(map[string]string)
 	}
 	a.bucketsPathsMap[name] = path
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *BucketScriptAggregation) AggregationType() string {
+	return "bucket_script"
+}
+
+func (a *BucketScriptAggregation) Validate() error {
+	if len(a.bucketsPathsMap) == 0 {
+		return errors.New("elastic: BucketScriptAggregation requires a buckets path")
+	}
+	if a.script == nil {
+		return errors.New("elastic: BucketScriptAggregation requires a script")
+	}
+
+	return nil
+}
+
 // Source returns the a JSON-serializable interface.
 func (a *BucketScriptAggregation) Source() (interface{}, error) {
 	source := make(map[string]interface{})
@@ -93,6 +143,9 @@ func (a *BucketScriptAggregation) Source() (interface{}, error) {
 		params["format"] = a.format
 	}
 	if a.gapPolicy != "" {
+		if err := ValidateGapPolicy(a.gapPolicy); err != nil {
+			return nil, err
+		}
 		params["gap_policy"] = a.gapPolicy
 	}
 	if a.script != nil {
@@ -109,9 +162,7 @@ func (a *BucketScriptAggregation) Source() (interface{}, error) {
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
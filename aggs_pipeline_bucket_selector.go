@@ -1,6 +1,9 @@
 package aggretastic
 
-import "github.com/olivere/elastic"
+import (
+	"errors"
+	"github.com/olivere/elastic"
+)
 
 // BucketSelectorAggregation is a parent pipeline aggregation which
 // determines whether the current bucket will be retained in the parent
@@ -13,12 +16,12 @@ import "github.com/olivere/elastic"
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-pipeline-bucket-selector-aggregation.html
 type BucketSelectorAggregation struct {
 	*notInjectable
+	metaHolder
 
 	format    string
 	gapPolicy string
 	script    *elastic.Script
 
-	meta            map[string]interface{}
 	bucketsPathsMap map[string]string
 }
 
@@ -33,43 +36,73 @@ func NewBucketSelectorAggregation() *BucketSelectorAggregation {
 // Format to use on the output of this aggregation.
 func (a *BucketSelectorAggregation) Format(format string) *BucketSelectorAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 // GapPolicy defines what should be done when a gap in the series is discovered.
-// Valid values include "insert_zeros" or "skip". Default is "insert_zeros".
+// Valid values include "insert_zeros", "skip" or "keep_values". Default is "insert_zeros".
 func (a *BucketSelectorAggregation) GapPolicy(gapPolicy string) *BucketSelectorAggregation {
 	a.gapPolicy = gapPolicy
+	a.markDirty()
 	return a
 }
 
 // GapInsertZeros inserts zeros for gaps in the series.
 func (a *BucketSelectorAggregation) GapInsertZeros() *BucketSelectorAggregation {
 	a.gapPolicy = "insert_zeros"
+	a.markDirty()
 	return a
 }
 
 // GapSkip skips gaps in the series.
 func (a *BucketSelectorAggregation) GapSkip() *BucketSelectorAggregation {
 	a.gapPolicy = "skip"
+	a.markDirty()
+	return a
+}
+
+// GapKeepValues skips gaps in the series like GapSkip, but keeps the non-null
+// values produced by the metrics the buckets path refers to (Elasticsearch 7.4+).
+func (a *BucketSelectorAggregation) GapKeepValues() *BucketSelectorAggregation {
+	a.gapPolicy = "keep_values"
+	a.markDirty()
 	return a
 }
 
 // Script is the script to run.
 func (a *BucketSelectorAggregation) Script(script *elastic.Script) *BucketSelectorAggregation {
 	a.script = script
+	a.markDirty()
+	return a
+}
+
+// StoredScript sets the stored script with the given id and params.
+// It replaces the script set with Script (and vice versa).
+func (a *BucketSelectorAggregation) StoredScript(id string, params map[string]interface{}) *BucketSelectorAggregation {
+	a.script = elastic.NewScriptStored(id).Params(params)
+	a.markDirty()
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *BucketSelectorAggregation) Meta(metaData map[string]interface{}) *BucketSelectorAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *BucketSelectorAggregation) AddMeta(key string, value interface{}) *BucketSelectorAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 // BucketsPathsMap sets the paths to the buckets to use for this pipeline aggregator.
 func (a *BucketSelectorAggregation) BucketsPathsMap(bucketsPathsMap map[string]string) *BucketSelectorAggregation {
 	a.bucketsPathsMap = bucketsPathsMap
+	a.markDirty()
 	return a
 }
 
@@ -79,9 +112,26 @@ func (a *BucketSelectorAggregation) AddBucketsPath(name, path string) *BucketSel
 		a.bucketsPathsMap = make(map[string]string)
 	}
 	a.bucketsPathsMap[name] = path
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *BucketSelectorAggregation) AggregationType() string {
+	return "bucket_selector"
+}
+
+func (a *BucketSelectorAggregation) Validate() error {
+	if len(a.bucketsPathsMap) == 0 {
+		return errors.New("elastic: BucketSelectorAggregation requires a buckets path")
+	}
+	if a.script == nil {
+		return errors.New("elastic: BucketSelectorAggregation requires a script")
+	}
+
+	return nil
+}
+
 // Source returns the a JSON-serializable interface.
 func (a *BucketSelectorAggregation) Source() (interface{}, error) {
 	source := make(map[string]interface{})
@@ -92,6 +142,9 @@ func (a *BucketSelectorAggregation) Source() (interface{}, error) {
 		params["format"] = a.format
 	}
 	if a.gapPolicy != "" {
+		if err := ValidateGapPolicy(a.gapPolicy); err != nil {
+			return nil, err
+		}
 		params["gap_policy"] = a.gapPolicy
 	}
 	if a.script != nil {
@@ -108,9 +161,7 @@ func (a *BucketSelectorAggregation) Source() (interface{}, error) {
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
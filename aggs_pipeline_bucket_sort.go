@@ -12,20 +12,17 @@ import "github.com/olivere/elastic"
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-pipeline-bucket-sort-aggregation.html
 type BucketSortAggregation struct {
 	*notInjectable
+	metaHolder
 
 	sorters   []elastic.Sorter
-	from      int
-	size      int
+	from      *int
+	size      *int
 	gapPolicy string
-
-	meta map[string]interface{}
 }
 
 // NewBucketSortAggregation creates and initializes a new BucketSortAggregation.
 func NewBucketSortAggregation() *BucketSortAggregation {
-	a := &BucketSortAggregation{
-		size: -1,
-	}
+	a := &BucketSortAggregation{}
 	a.notInjectable = newNotInjectable(a)
 
 	return a
@@ -34,43 +31,58 @@ func NewBucketSortAggregation() *BucketSortAggregation {
 // Sort adds a sort order to the list of sorters.
 func (a *BucketSortAggregation) Sort(field string, ascending bool) *BucketSortAggregation {
 	a.sorters = append(a.sorters, elastic.SortInfo{Field: field, Ascending: ascending})
+	a.markDirty()
 	return a
 }
 
 // SortWithInfo adds a SortInfo to the list of sorters.
 func (a *BucketSortAggregation) SortWithInfo(info elastic.SortInfo) *BucketSortAggregation {
 	a.sorters = append(a.sorters, info)
+	a.markDirty()
 	return a
 }
 
 // From adds the "from" parameter to the aggregation.
 func (a *BucketSortAggregation) From(from int) *BucketSortAggregation {
-	a.from = from
+	a.from = &from
+	a.markDirty()
 	return a
 }
 
 // Size adds the "size" parameter to the aggregation.
 func (a *BucketSortAggregation) Size(size int) *BucketSortAggregation {
-	a.size = size
+	a.size = &size
+	a.markDirty()
 	return a
 }
 
 // GapPolicy defines what should be done when a gap in the series is discovered.
-// Valid values include "insert_zeros" or "skip". Default is "skip".
+// Valid values include "insert_zeros", "skip" or "keep_values". Default is "skip".
 func (a *BucketSortAggregation) GapPolicy(gapPolicy string) *BucketSortAggregation {
 	a.gapPolicy = gapPolicy
+	a.markDirty()
 	return a
 }
 
 // GapInsertZeros inserts zeros for gaps in the series.
 func (a *BucketSortAggregation) GapInsertZeros() *BucketSortAggregation {
 	a.gapPolicy = "insert_zeros"
+	a.markDirty()
 	return a
 }
 
 // GapSkip skips gaps in the series.
 func (a *BucketSortAggregation) GapSkip() *BucketSortAggregation {
 	a.gapPolicy = "skip"
+	a.markDirty()
+	return a
+}
+
+// GapKeepValues skips gaps in the series like GapSkip, but keeps the non-null
+// values produced by the metrics the buckets path refers to (Elasticsearch 7.4+).
+func (a *BucketSortAggregation) GapKeepValues() *BucketSortAggregation {
+	a.gapPolicy = "keep_values"
+	a.markDirty()
 	return a
 }
 
@@ -80,23 +92,39 @@ func (a *BucketSortAggregation) GapSkip() *BucketSortAggregation {
 // response. It merely reorders parent buckets.
 func (a *BucketSortAggregation) Meta(meta map[string]interface{}) *BucketSortAggregation {
 	a.meta = meta
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *BucketSortAggregation) AddMeta(key string, value interface{}) *BucketSortAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *BucketSortAggregation) AggregationType() string {
+	return "bucket_sort"
+}
+
 // Source returns the a JSON-serializable interface.
 func (a *BucketSortAggregation) Source() (interface{}, error) {
 	source := make(map[string]interface{})
 	params := make(map[string]interface{})
 	source["bucket_sort"] = params
 
-	if a.from != 0 {
-		params["from"] = a.from
+	if a.from != nil {
+		params["from"] = *a.from
 	}
-	if a.size != -1 {
-		params["size"] = a.size
+	if a.size != nil {
+		params["size"] = *a.size
 	}
 
 	if a.gapPolicy != "" {
+		if err := ValidateGapPolicy(a.gapPolicy); err != nil {
+			return nil, err
+		}
 		params["gap_policy"] = a.gapPolicy
 	}
 
@@ -114,9 +142,7 @@ func (a *BucketSortAggregation) Source() (interface{}, error) {
 	}
 
 	// Add metadata if available.
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -0,0 +1,96 @@
+package aggretastic
+
+import "errors"
+
+// CumulativeCardinalityAggregation is a parent pipeline aggregation which
+// calculates the cumulative cardinality in a parent histogram (or date_histogram)
+// aggregation. The specified metric must be a cardinality aggregation and the
+// enclosing histogram must have min_doc_count set to 0 (default for histogram
+// aggregations).
+//
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.4/search-aggregations-pipeline-cumulative-cardinality-aggregation.html
+type CumulativeCardinalityAggregation struct {
+	*notInjectable
+	metaHolder
+
+	format string
+
+	bucketsPaths []string
+}
+
+// NewCumulativeCardinalityAggregation creates and initializes a new CumulativeCardinalityAggregation.
+func NewCumulativeCardinalityAggregation() *CumulativeCardinalityAggregation {
+	a := &CumulativeCardinalityAggregation{
+		bucketsPaths: make([]string, 0),
+	}
+	a.notInjectable = newNotInjectable(a)
+
+	return a
+}
+
+// Format to use on the output of this aggregation.
+func (a *CumulativeCardinalityAggregation) Format(format string) *CumulativeCardinalityAggregation {
+	a.format = format
+	a.markDirty()
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *CumulativeCardinalityAggregation) Meta(metaData map[string]interface{}) *CumulativeCardinalityAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *CumulativeCardinalityAggregation) AddMeta(key string, value interface{}) *CumulativeCardinalityAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// BucketsPath sets the paths to the buckets to use for this pipeline aggregator.
+func (a *CumulativeCardinalityAggregation) BucketsPath(bucketsPaths ...string) *CumulativeCardinalityAggregation {
+	a.bucketsPaths = append(a.bucketsPaths, bucketsPaths...)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *CumulativeCardinalityAggregation) AggregationType() string {
+	return "cumulative_cardinality"
+}
+
+func (a *CumulativeCardinalityAggregation) Validate() error {
+	if len(a.bucketsPaths) == 0 {
+		return errors.New("elastic: CumulativeCardinalityAggregation requires a buckets path")
+	}
+
+	return nil
+}
+
+// Source returns the a JSON-serializable interface.
+func (a *CumulativeCardinalityAggregation) Source() (interface{}, error) {
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["cumulative_cardinality"] = params
+
+	if a.format != "" {
+		params["format"] = a.format
+	}
+
+	// Add buckets paths
+	switch len(a.bucketsPaths) {
+	case 0:
+	case 1:
+		params["buckets_path"] = a.bucketsPaths[0]
+	default:
+		params["buckets_path"] = a.bucketsPaths
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
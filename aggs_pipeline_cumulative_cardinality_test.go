@@ -0,0 +1,24 @@
+package aggretastic
+
+import "testing"
+
+func TestCumulativeCardinalityAggregation(t *testing.T) {
+	agg := NewDateHistogramAggregation().Field("timestamp").Interval("day").
+		SubAggregation("distinct_users", NewCardinalityAggregation().Field("user_id")).
+		SubAggregation("total_new_users", NewCumulativeCardinalityAggregation().BucketsPath("distinct_users"))
+
+	want := `{"aggregations":{"distinct_users":{"cardinality":{"field":"user_id"}},` +
+		`"total_new_users":{"cumulative_cardinality":{"buckets_path":"distinct_users"}}},"date_histogram":{"field":"timestamp","interval":"day"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+	if agg.Select("distinct_users") == nil {
+		t.Fatal("expected the buckets path to point at the sibling cardinality")
+	}
+}
+
+func TestCumulativeCardinalityAggregationRequiresBucketsPath(t *testing.T) {
+	if err := NewCumulativeCardinalityAggregation().Validate(); err == nil {
+		t.Fatal("expected an error without a buckets path")
+	}
+}
@@ -1,5 +1,7 @@
 package aggretastic
 
+import "errors"
+
 // CumulativeSumAggregation is a parent pipeline aggregation which calculates
 // the cumulative sum of a specified metric in a parent histogram (or date_histogram)
 // aggregation. The specified metric must be numeric and the enclosing
@@ -9,10 +11,10 @@ package aggretastic
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-pipeline-cumulative-sum-aggregation.html
 type CumulativeSumAggregation struct {
 	*notInjectable
+	metaHolder
 
 	format string
 
-	meta         map[string]interface{}
 	bucketsPaths []string
 }
 
@@ -29,21 +31,44 @@ func NewCumulativeSumAggregation() *CumulativeSumAggregation {
 // Format to use on the output of this aggregation.
 func (a *CumulativeSumAggregation) Format(format string) *CumulativeSumAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *CumulativeSumAggregation) Meta(metaData map[string]interface{}) *CumulativeSumAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *CumulativeSumAggregation) AddMeta(key string, value interface{}) *CumulativeSumAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 // BucketsPath sets the paths to the buckets to use for this pipeline aggregator.
 func (a *CumulativeSumAggregation) BucketsPath(bucketsPaths ...string) *CumulativeSumAggregation {
 	a.bucketsPaths = append(a.bucketsPaths, bucketsPaths...)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *CumulativeSumAggregation) AggregationType() string {
+	return "cumulative_sum"
+}
+
+func (a *CumulativeSumAggregation) Validate() error {
+	if len(a.bucketsPaths) == 0 {
+		return errors.New("elastic: CumulativeSumAggregation requires a buckets path")
+	}
+
+	return nil
+}
+
 // Source returns the a JSON-serializable interface.
 func (a *CumulativeSumAggregation) Source() (interface{}, error) {
 	source := make(map[string]interface{})
@@ -64,9 +89,7 @@ func (a *CumulativeSumAggregation) Source() (interface{}, error) {
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -1,5 +1,7 @@
 package aggretastic
 
+import "errors"
+
 // DerivativeAggregation is a parent pipeline aggregation which calculates
 // the derivative of a specified metric in a parent histogram (or date_histogram)
 // aggregation. The specified metric must be numeric and the enclosing
@@ -9,12 +11,12 @@ package aggretastic
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-pipeline-derivative-aggregation.html
 type DerivativeAggregation struct {
 	*notInjectable
+	metaHolder
 
 	format    string
 	gapPolicy string
 	unit      string
 
-	meta         map[string]interface{}
 	bucketsPaths []string
 }
 
@@ -31,25 +33,37 @@ func NewDerivativeAggregation() *DerivativeAggregation {
 // Format to use on the output of this aggregation.
 func (a *DerivativeAggregation) Format(format string) *DerivativeAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 // GapPolicy defines what should be done when a gap in the series is discovered.
-// Valid values include "insert_zeros" or "skip". Default is "insert_zeros".
+// Valid values include "insert_zeros", "skip" or "keep_values". Default is "insert_zeros".
 func (a *DerivativeAggregation) GapPolicy(gapPolicy string) *DerivativeAggregation {
 	a.gapPolicy = gapPolicy
+	a.markDirty()
 	return a
 }
 
 // GapInsertZeros inserts zeros for gaps in the series.
 func (a *DerivativeAggregation) GapInsertZeros() *DerivativeAggregation {
 	a.gapPolicy = "insert_zeros"
+	a.markDirty()
 	return a
 }
 
 // GapSkip skips gaps in the series.
 func (a *DerivativeAggregation) GapSkip() *DerivativeAggregation {
 	a.gapPolicy = "skip"
+	a.markDirty()
+	return a
+}
+
+// GapKeepValues skips gaps in the series like GapSkip, but keeps the non-null
+// values produced by the metrics the buckets path refers to (Elasticsearch 7.4+).
+func (a *DerivativeAggregation) GapKeepValues() *DerivativeAggregation {
+	a.gapPolicy = "keep_values"
+	a.markDirty()
 	return a
 }
 
@@ -57,21 +71,44 @@ func (a *DerivativeAggregation) GapSkip() *DerivativeAggregation {
 // It is only useful when calculating the derivative using a date_histogram.
 func (a *DerivativeAggregation) Unit(unit string) *DerivativeAggregation {
 	a.unit = unit
+	a.markDirty()
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *DerivativeAggregation) Meta(metaData map[string]interface{}) *DerivativeAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *DerivativeAggregation) AddMeta(key string, value interface{}) *DerivativeAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 // BucketsPath sets the paths to the buckets to use for this pipeline aggregator.
 func (a *DerivativeAggregation) BucketsPath(bucketsPaths ...string) *DerivativeAggregation {
 	a.bucketsPaths = append(a.bucketsPaths, bucketsPaths...)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *DerivativeAggregation) AggregationType() string {
+	return "derivative"
+}
+
+func (a *DerivativeAggregation) Validate() error {
+	if len(a.bucketsPaths) == 0 {
+		return errors.New("elastic: DerivativeAggregation requires a buckets path")
+	}
+
+	return nil
+}
+
 // Source returns the a JSON-serializable interface.
 func (a *DerivativeAggregation) Source() (interface{}, error) {
 	source := make(map[string]interface{})
@@ -82,6 +119,9 @@ func (a *DerivativeAggregation) Source() (interface{}, error) {
 		params["format"] = a.format
 	}
 	if a.gapPolicy != "" {
+		if err := ValidateGapPolicy(a.gapPolicy); err != nil {
+			return nil, err
+		}
 		params["gap_policy"] = a.gapPolicy
 	}
 	if a.unit != "" {
@@ -98,9 +138,7 @@ func (a *DerivativeAggregation) Source() (interface{}, error) {
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -0,0 +1,15 @@
+package aggretastic
+
+import "fmt"
+
+// ValidateGapPolicy checks the gap policy of a pipeline aggregation.
+// Elasticsearch knows "insert_zeros", "skip" and (since 7.4) "keep_values".
+// See: https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-pipeline.html#gap-policy
+func ValidateGapPolicy(gapPolicy string) error {
+	switch gapPolicy {
+	case "insert_zeros", "skip", "keep_values":
+		return nil
+	default:
+		return fmt.Errorf("elastic: unknown gap policy %q, expected \"insert_zeros\", \"skip\" or \"keep_values\"", gapPolicy)
+	}
+}
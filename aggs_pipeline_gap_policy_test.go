@@ -0,0 +1,65 @@
+package aggretastic
+
+import "testing"
+
+func TestValidateGapPolicy(t *testing.T) {
+	for _, policy := range []string{"insert_zeros", "skip", "keep_values"} {
+		if err := ValidateGapPolicy(policy); err != nil {
+			t.Errorf("%s: %v", policy, err)
+		}
+	}
+	for _, policy := range []string{"", "keep", "zeros"} {
+		if err := ValidateGapPolicy(policy); err == nil {
+			t.Errorf("%q: expected an error", policy)
+		}
+	}
+}
+
+func TestGapKeepValues(t *testing.T) {
+	tests := []struct {
+		agg  Aggregation
+		want string
+	}{
+		{NewDerivativeAggregation().BucketsPath("sales").GapKeepValues(), `{"derivative":{"buckets_path":"sales","gap_policy":"keep_values"}}`},
+		{NewStatsBucketAggregation().BucketsPath("a>b").GapKeepValues(), `{"stats_bucket":{"buckets_path":"a>b","gap_policy":"keep_values"}}`},
+		{NewSerialDiffAggregation().BucketsPath("sales").GapKeepValues(), `{"serial_diff":{"buckets_path":"sales","gap_policy":"keep_values"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.agg.AggregationType(), func(t *testing.T) {
+			if test.agg.String() != test.want {
+				t.Fatalf("expected %s, got %s", test.want, test.agg)
+			}
+			if err := test.agg.Validate(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestPipelinesRejectUnknownGapPolicies(t *testing.T) {
+	tests := []Aggregation{
+		NewDerivativeAggregation().BucketsPath("sales").GapPolicy("skipp"),
+		NewAvgBucketAggregation().BucketsPath("a>b").GapPolicy("zeros"),
+		NewBucketScriptAggregation().BucketsPathsMap(map[string]string{"a": "b"}).GapPolicy("none"),
+	}
+
+	for _, agg := range tests {
+		t.Run(agg.AggregationType(), func(t *testing.T) {
+			if _, err := agg.Source(); err == nil {
+				t.Fatal("expected a source error")
+			}
+		})
+	}
+
+	agg := NewDerivativeAggregation().BucketsPath("sales").GapSkip()
+	want := `{"derivative":{"buckets_path":"sales","gap_policy":"skip"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+	agg.GapInsertZeros()
+	want = `{"derivative":{"buckets_path":"sales","gap_policy":"insert_zeros"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
@@ -0,0 +1,116 @@
+package aggretastic
+
+import "errors"
+
+// InferenceBucketAggregation is a parent pipeline aggregation which loads
+// a pre-trained model and performs inference on the collated result fields
+// from the parent bucket aggregation.
+//
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.9/search-aggregations-pipeline-inference-bucket-aggregation.html
+type InferenceBucketAggregation struct {
+	*notInjectable
+	metaHolder
+
+	modelID         string
+	inferenceConfig map[string]interface{}
+
+	bucketsPathsMap map[string]string
+}
+
+// NewInferenceBucketAggregation creates and initializes a new InferenceBucketAggregation.
+func NewInferenceBucketAggregation() *InferenceBucketAggregation {
+	a := &InferenceBucketAggregation{}
+	a.notInjectable = newNotInjectable(a)
+
+	return a
+}
+
+// ModelID is the ID or alias of the trained model. It is required.
+func (a *InferenceBucketAggregation) ModelID(modelID string) *InferenceBucketAggregation {
+	a.modelID = modelID
+	a.markDirty()
+	return a
+}
+
+// InferenceConfig overrides the inference configuration of the model,
+// e.g. {"regression": {"results_field": "value"}}.
+func (a *InferenceBucketAggregation) InferenceConfig(config map[string]interface{}) *InferenceBucketAggregation {
+	a.inferenceConfig = config
+	a.markDirty()
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *InferenceBucketAggregation) Meta(metaData map[string]interface{}) *InferenceBucketAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *InferenceBucketAggregation) AddMeta(key string, value interface{}) *InferenceBucketAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// BucketsPathsMap sets the paths to the buckets to use for this pipeline aggregator.
+// The keys are the model's input field names.
+func (a *InferenceBucketAggregation) BucketsPathsMap(bucketsPathsMap map[string]string) *InferenceBucketAggregation {
+	a.bucketsPathsMap = bucketsPathsMap
+	a.markDirty()
+	return a
+}
+
+// AddBucketsPath adds a bucket path to use for this pipeline aggregator.
+func (a *InferenceBucketAggregation) AddBucketsPath(name, path string) *InferenceBucketAggregation {
+	if a.bucketsPathsMap == nil {
+		a.bucketsPathsMap = make(map[string]string)
+	}
+	a.bucketsPathsMap[name] = path
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *InferenceBucketAggregation) AggregationType() string {
+	return "inference"
+}
+
+func (a *InferenceBucketAggregation) Validate() error {
+	if a.modelID == "" {
+		return errors.New("elastic: InferenceBucketAggregation requires a model id")
+	}
+	if len(a.bucketsPathsMap) == 0 {
+		return errors.New("elastic: InferenceBucketAggregation requires a buckets path")
+	}
+
+	return nil
+}
+
+// Source returns the a JSON-serializable interface.
+func (a *InferenceBucketAggregation) Source() (interface{}, error) {
+	if a.modelID == "" {
+		return nil, errors.New("elastic: InferenceBucketAggregation requires a model id")
+	}
+
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["inference"] = params
+
+	params["model_id"] = a.modelID
+	if len(a.inferenceConfig) > 0 {
+		params["inference_config"] = a.inferenceConfig
+	}
+
+	// Add buckets paths
+	if len(a.bucketsPathsMap) > 0 {
+		params["buckets_path"] = a.bucketsPathsMap
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
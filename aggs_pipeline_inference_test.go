@@ -0,0 +1,29 @@
+package aggretastic
+
+import "testing"
+
+func TestInferenceBucketAggregation(t *testing.T) {
+	agg := NewInferenceBucketAggregation().
+		ModelID("malicious_clients_model").
+		AddBucketsPath("response_count", "responses>_count").
+		AddBucketsPath("url_dc", "url_dc").
+		InferenceConfig(map[string]interface{}{"classification": map[string]interface{}{"num_top_classes": 2}})
+
+	want := `{"inference":{"buckets_path":{"response_count":"responses>_count","url_dc":"url_dc"},"inference_config":{"classification":{"num_top_classes":2}},"model_id":"malicious_clients_model"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+	if err := agg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInferenceBucketAggregationRequiresModelID(t *testing.T) {
+	agg := NewInferenceBucketAggregation().AddBucketsPath("x", "y")
+	if _, err := agg.Source(); err == nil {
+		t.Fatal("expected a source error")
+	}
+	if err := agg.Validate(); err == nil {
+		t.Fatal("expected a validation error")
+	}
+}
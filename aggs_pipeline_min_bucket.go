@@ -1,5 +1,7 @@
 package aggretastic
 
+import "errors"
+
 // MinBucketAggregation is a sibling pipeline aggregation which identifies
 // the bucket(s) with the maximum value of a specified metric in a sibling
 // aggregation and outputs both the value and the key(s) of the bucket(s).
@@ -10,11 +12,11 @@ package aggretastic
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-pipeline-min-bucket-aggregation.html
 type MinBucketAggregation struct {
 	*notInjectable
+	metaHolder
 
 	format    string
 	gapPolicy string
 
-	meta         map[string]interface{}
 	bucketsPaths []string
 }
 
@@ -31,40 +33,74 @@ func NewMinBucketAggregation() *MinBucketAggregation {
 // Format to use on the output of this aggregation.
 func (a *MinBucketAggregation) Format(format string) *MinBucketAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 // GapPolicy defines what should be done when a gap in the series is discovered.
-// Valid values include "insert_zeros" or "skip". Default is "insert_zeros".
+// Valid values include "insert_zeros", "skip" or "keep_values". Default is "insert_zeros".
 func (a *MinBucketAggregation) GapPolicy(gapPolicy string) *MinBucketAggregation {
 	a.gapPolicy = gapPolicy
+	a.markDirty()
 	return a
 }
 
 // GapInsertZeros inserts zeros for gaps in the series.
 func (a *MinBucketAggregation) GapInsertZeros() *MinBucketAggregation {
 	a.gapPolicy = "insert_zeros"
+	a.markDirty()
 	return a
 }
 
 // GapSkip skips gaps in the series.
 func (a *MinBucketAggregation) GapSkip() *MinBucketAggregation {
 	a.gapPolicy = "skip"
+	a.markDirty()
+	return a
+}
+
+// GapKeepValues skips gaps in the series like GapSkip, but keeps the non-null
+// values produced by the metrics the buckets path refers to (Elasticsearch 7.4+).
+func (a *MinBucketAggregation) GapKeepValues() *MinBucketAggregation {
+	a.gapPolicy = "keep_values"
+	a.markDirty()
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *MinBucketAggregation) Meta(metaData map[string]interface{}) *MinBucketAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *MinBucketAggregation) AddMeta(key string, value interface{}) *MinBucketAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 // BucketsPath sets the paths to the buckets to use for this pipeline aggregator.
 func (a *MinBucketAggregation) BucketsPath(bucketsPaths ...string) *MinBucketAggregation {
 	a.bucketsPaths = append(a.bucketsPaths, bucketsPaths...)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *MinBucketAggregation) AggregationType() string {
+	return "min_bucket"
+}
+
+func (a *MinBucketAggregation) Validate() error {
+	if len(a.bucketsPaths) == 0 {
+		return errors.New("elastic: MinBucketAggregation requires a buckets path")
+	}
+
+	return nil
+}
+
 // Source returns the a JSON-serializable interface.
 func (a *MinBucketAggregation) Source() (interface{}, error) {
 	source := make(map[string]interface{})
@@ -75,6 +111,9 @@ func (a *MinBucketAggregation) Source() (interface{}, error) {
 		params["format"] = a.format
 	}
 	if a.gapPolicy != "" {
+		if err := ValidateGapPolicy(a.gapPolicy); err != nil {
+			return nil, err
+		}
 		params["gap_policy"] = a.gapPolicy
 	}
 
@@ -88,9 +127,7 @@ func (a *MinBucketAggregation) Source() (interface{}, error) {
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -1,5 +1,7 @@
 package aggretastic
 
+import "errors"
+
 // MovAvgAggregation operates on a series of data. It will slide a window
 // across the data and emit the average value of that window.
 //
@@ -7,6 +9,7 @@ package aggretastic
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-pipeline-movavg-aggregation.html
 type MovAvgAggregation struct {
 	*notInjectable
+	metaHolder
 
 	format    string
 	gapPolicy string
@@ -15,7 +18,6 @@ type MovAvgAggregation struct {
 	predict   *int
 	minimize  *bool
 
-	meta         map[string]interface{}
 	bucketsPaths []string
 }
 
@@ -32,25 +34,37 @@ func NewMovAvgAggregation() *MovAvgAggregation {
 // Format to use on the output of this aggregation.
 func (a *MovAvgAggregation) Format(format string) *MovAvgAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 // GapPolicy defines what should be done when a gap in the series is discovered.
-// Valid values include "insert_zeros" or "skip". Default is "insert_zeros".
+// Valid values include "insert_zeros", "skip" or "keep_values". Default is "insert_zeros".
 func (a *MovAvgAggregation) GapPolicy(gapPolicy string) *MovAvgAggregation {
 	a.gapPolicy = gapPolicy
+	a.markDirty()
 	return a
 }
 
 // GapInsertZeros inserts zeros for gaps in the series.
 func (a *MovAvgAggregation) GapInsertZeros() *MovAvgAggregation {
 	a.gapPolicy = "insert_zeros"
+	a.markDirty()
 	return a
 }
 
 // GapSkip skips gaps in the series.
 func (a *MovAvgAggregation) GapSkip() *MovAvgAggregation {
 	a.gapPolicy = "skip"
+	a.markDirty()
+	return a
+}
+
+// GapKeepValues skips gaps in the series like GapSkip, but keeps the non-null
+// values produced by the metrics the buckets path refers to (Elasticsearch 7.4+).
+func (a *MovAvgAggregation) GapKeepValues() *MovAvgAggregation {
+	a.gapPolicy = "keep_values"
+	a.markDirty()
 	return a
 }
 
@@ -58,6 +72,7 @@ func (a *MovAvgAggregation) GapSkip() *MovAvgAggregation {
 // in the series.
 func (a *MovAvgAggregation) Model(model MovAvgModel) *MovAvgAggregation {
 	a.model = model
+	a.markDirty()
 	return a
 }
 
@@ -66,6 +81,7 @@ func (a *MovAvgAggregation) Model(model MovAvgModel) *MovAvgAggregation {
 // be used to calculate the moving avg value.
 func (a *MovAvgAggregation) Window(window int) *MovAvgAggregation {
 	a.window = &window
+	a.markDirty()
 	return a
 }
 
@@ -75,6 +91,7 @@ func (a *MovAvgAggregation) Window(window int) *MovAvgAggregation {
 // histogram with the predicted values.
 func (a *MovAvgAggregation) Predict(numPredictions int) *MovAvgAggregation {
 	a.predict = &numPredictions
+	a.markDirty()
 	return a
 }
 
@@ -82,21 +99,44 @@ func (a *MovAvgAggregation) Predict(numPredictions int) *MovAvgAggregation {
 // cost minimizing algorithm.
 func (a *MovAvgAggregation) Minimize(minimize bool) *MovAvgAggregation {
 	a.minimize = &minimize
+	a.markDirty()
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *MovAvgAggregation) Meta(metaData map[string]interface{}) *MovAvgAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *MovAvgAggregation) AddMeta(key string, value interface{}) *MovAvgAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 // BucketsPath sets the paths to the buckets to use for this pipeline aggregator.
 func (a *MovAvgAggregation) BucketsPath(bucketsPaths ...string) *MovAvgAggregation {
 	a.bucketsPaths = append(a.bucketsPaths, bucketsPaths...)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *MovAvgAggregation) AggregationType() string {
+	return "moving_avg"
+}
+
+func (a *MovAvgAggregation) Validate() error {
+	if len(a.bucketsPaths) == 0 {
+		return errors.New("elastic: MovAvgAggregation requires a buckets path")
+	}
+
+	return nil
+}
+
 // Source returns the a JSON-serializable interface.
 func (a *MovAvgAggregation) Source() (interface{}, error) {
 	source := make(map[string]interface{})
@@ -107,6 +147,9 @@ func (a *MovAvgAggregation) Source() (interface{}, error) {
 		params["format"] = a.format
 	}
 	if a.gapPolicy != "" {
+		if err := ValidateGapPolicy(a.gapPolicy); err != nil {
+			return nil, err
+		}
 		params["gap_policy"] = a.gapPolicy
 	}
 	if a.model != nil {
@@ -136,9 +179,7 @@ func (a *MovAvgAggregation) Source() (interface{}, error) {
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
@@ -0,0 +1,112 @@
+package aggretastic
+
+import "errors"
+
+// MovingPercentilesAggregation is a parent pipeline aggregation which slides
+// a window across adjacent percentiles and computes the percentiles of that
+// window. Its buckets_path must point to a percentiles aggregation inside
+// a histogram or date_histogram.
+//
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.9/search-aggregations-pipeline-moving-percentiles-aggregation.html
+type MovingPercentilesAggregation struct {
+	*notInjectable
+	metaHolder
+
+	window *int
+	shift  *int
+
+	bucketsPaths []string
+}
+
+// NewMovingPercentilesAggregation creates and initializes a new MovingPercentilesAggregation.
+func NewMovingPercentilesAggregation() *MovingPercentilesAggregation {
+	a := &MovingPercentilesAggregation{
+		bucketsPaths: make([]string, 0),
+	}
+	a.notInjectable = newNotInjectable(a)
+
+	return a
+}
+
+// Window sets the size of window to "slide" across the histogram.
+// The window is required.
+func (a *MovingPercentilesAggregation) Window(window int) *MovingPercentilesAggregation {
+	a.window = &window
+	a.markDirty()
+	return a
+}
+
+// Shift sets the shift of the window position.
+func (a *MovingPercentilesAggregation) Shift(shift int) *MovingPercentilesAggregation {
+	a.shift = &shift
+	a.markDirty()
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *MovingPercentilesAggregation) Meta(metaData map[string]interface{}) *MovingPercentilesAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *MovingPercentilesAggregation) AddMeta(key string, value interface{}) *MovingPercentilesAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// BucketsPath sets the paths to the buckets to use for this pipeline aggregator.
+func (a *MovingPercentilesAggregation) BucketsPath(bucketsPaths ...string) *MovingPercentilesAggregation {
+	a.bucketsPaths = append(a.bucketsPaths, bucketsPaths...)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *MovingPercentilesAggregation) AggregationType() string {
+	return "moving_percentiles"
+}
+
+func (a *MovingPercentilesAggregation) Validate() error {
+	if a.window == nil {
+		return errors.New("elastic: MovingPercentilesAggregation requires a window")
+	}
+	if len(a.bucketsPaths) == 0 {
+		return errors.New("elastic: MovingPercentilesAggregation requires a buckets path")
+	}
+
+	return nil
+}
+
+// Source returns the a JSON-serializable interface.
+func (a *MovingPercentilesAggregation) Source() (interface{}, error) {
+	if a.window == nil {
+		return nil, errors.New("elastic: MovingPercentilesAggregation requires a window")
+	}
+
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["moving_percentiles"] = params
+
+	params["window"] = *a.window
+	if a.shift != nil {
+		params["shift"] = *a.shift
+	}
+
+	// Add buckets paths
+	switch len(a.bucketsPaths) {
+	case 0:
+	case 1:
+		params["buckets_path"] = a.bucketsPaths[0]
+	default:
+		params["buckets_path"] = a.bucketsPaths
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
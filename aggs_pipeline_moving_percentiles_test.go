@@ -0,0 +1,25 @@
+package aggretastic
+
+import "testing"
+
+func TestMovingPercentilesAggregation(t *testing.T) {
+	agg := NewDateHistogramAggregation().Field("date").Interval("1M").
+		SubAggregation("the_percentile", NewPercentilesAggregation().Field("price").Percentiles(1, 99)).
+		SubAggregation("the_movperc", NewMovingPercentilesAggregation().BucketsPath("the_percentile").Window(10))
+
+	want := `{"aggregations":{"the_movperc":{"moving_percentiles":{"buckets_path":"the_percentile","window":10}},` +
+		`"the_percentile":{"percentiles":{"field":"price","percents":[1,99]}}},"date_histogram":{"field":"date","interval":"1M"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestMovingPercentilesAggregationRequiresWindow(t *testing.T) {
+	agg := NewMovingPercentilesAggregation().BucketsPath("the_percentile").Shift(1)
+	if err := agg.Validate(); err == nil {
+		t.Fatal("expected Validate to fail without a window")
+	}
+	if _, err := agg.Source(); err == nil {
+		t.Fatal("expected Source to fail without a window")
+	}
+}
@@ -0,0 +1,112 @@
+package aggretastic
+
+import "errors"
+
+// NormalizeAggregation is a parent pipeline aggregation which calculates
+// the specific normalized/rescaled value for a specific bucket value.
+// Values that cannot be normalized will be skipped using the skip gap policy.
+//
+// For more details, see
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.9/search-aggregations-pipeline-normalize-aggregation.html
+type NormalizeAggregation struct {
+	*notInjectable
+	metaHolder
+
+	format string
+	method string
+
+	bucketsPaths []string
+}
+
+// NewNormalizeAggregation creates and initializes a new NormalizeAggregation.
+func NewNormalizeAggregation() *NormalizeAggregation {
+	a := &NormalizeAggregation{
+		bucketsPaths: make([]string, 0),
+	}
+	a.notInjectable = newNotInjectable(a)
+
+	return a
+}
+
+// Format to use on the output of this aggregation.
+func (a *NormalizeAggregation) Format(format string) *NormalizeAggregation {
+	a.format = format
+	a.markDirty()
+	return a
+}
+
+// Method is the specific method to apply. Valid values include
+// "rescale_0_1", "rescale_0_100", "percent_of_sum", "mean", "z-score"
+// and "softmax". The method is required.
+func (a *NormalizeAggregation) Method(method string) *NormalizeAggregation {
+	a.method = method
+	a.markDirty()
+	return a
+}
+
+// Meta sets the meta data to be included in the aggregation response.
+func (a *NormalizeAggregation) Meta(metaData map[string]interface{}) *NormalizeAggregation {
+	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *NormalizeAggregation) AddMeta(key string, value interface{}) *NormalizeAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
+	return a
+}
+
+// BucketsPath sets the paths to the buckets to use for this pipeline aggregator.
+func (a *NormalizeAggregation) BucketsPath(bucketsPaths ...string) *NormalizeAggregation {
+	a.bucketsPaths = append(a.bucketsPaths, bucketsPaths...)
+	a.markDirty()
+	return a
+}
+
+// AggregationType returns the type key of the aggregation in its source.
+func (a *NormalizeAggregation) AggregationType() string {
+	return "normalize"
+}
+
+func (a *NormalizeAggregation) Validate() error {
+	if a.method == "" {
+		return errors.New("elastic: NormalizeAggregation requires a method")
+	}
+	if len(a.bucketsPaths) == 0 {
+		return errors.New("elastic: NormalizeAggregation requires a buckets path")
+	}
+
+	return nil
+}
+
+// Source returns the a JSON-serializable interface.
+func (a *NormalizeAggregation) Source() (interface{}, error) {
+	if a.method == "" {
+		return nil, errors.New("elastic: NormalizeAggregation requires a method")
+	}
+
+	source := make(map[string]interface{})
+	params := make(map[string]interface{})
+	source["normalize"] = params
+
+	if a.format != "" {
+		params["format"] = a.format
+	}
+	params["method"] = a.method
+
+	// Add buckets paths
+	switch len(a.bucketsPaths) {
+	case 0:
+	case 1:
+		params["buckets_path"] = a.bucketsPaths[0]
+	default:
+		params["buckets_path"] = a.bucketsPaths
+	}
+
+	// Add Meta data if available
+	a.renderMeta(source)
+
+	return source, nil
+}
@@ -0,0 +1,25 @@
+package aggretastic
+
+import "testing"
+
+func TestNormalizeAggregation(t *testing.T) {
+	agg := NewDateHistogramAggregation().Field("date").Interval("month").
+		SubAggregation("sales", NewSumAggregation().Field("price")).
+		SubAggregation("percent_of_total_sales", NewNormalizeAggregation().BucketsPath("sales").Method("percent_of_sum").Format("00.00%"))
+
+	want := `{"aggregations":{"percent_of_total_sales":{"normalize":{"buckets_path":"sales","format":"00.00%","method":"percent_of_sum"}},` +
+		`"sales":{"sum":{"field":"price"}}},"date_histogram":{"field":"date","interval":"month"}}`
+	if agg.String() != want {
+		t.Fatalf("expected %s, got %s", want, agg)
+	}
+}
+
+func TestNormalizeAggregationRequiresMethod(t *testing.T) {
+	agg := NewNormalizeAggregation().BucketsPath("sales")
+	if err := agg.Validate(); err == nil {
+		t.Fatal("expected Validate to fail without a method")
+	}
+	if _, err := agg.Source(); err == nil {
+		t.Fatal("expected Source to fail without a method")
+	}
+}
@@ -1,5 +1,7 @@
 package aggretastic
 
+import "errors"
+
 // PercentilesBucketAggregation is a sibling pipeline aggregation which calculates
 // percentiles across all bucket of a specified metric in a sibling aggregation.
 // The specified metric must be numeric and the sibling aggregation must
@@ -9,13 +11,12 @@ package aggretastic
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-pipeline-percentiles-bucket-aggregation.html
 type PercentilesBucketAggregation struct {
 	*notInjectable
+	metaHolder
 
 	format       string
 	gapPolicy    string
 	percents     []float64
 	bucketsPaths []string
-
-	meta map[string]interface{}
 }
 
 // NewPercentilesBucketAggregation creates and initializes a new PercentilesBucketAggregation.
@@ -29,46 +30,81 @@ func NewPercentilesBucketAggregation() *PercentilesBucketAggregation {
 // Format to apply the output value of this aggregation.
 func (p *PercentilesBucketAggregation) Format(format string) *PercentilesBucketAggregation {
 	p.format = format
+	p.markDirty()
 	return p
 }
 
 // Percents to calculate percentiles for in this aggregation.
 func (p *PercentilesBucketAggregation) Percents(percents ...float64) *PercentilesBucketAggregation {
 	p.percents = percents
+	p.markDirty()
 	return p
 }
 
 // GapPolicy defines what should be done when a gap in the series is discovered.
-// Valid values include "insert_zeros" or "skip". Default is "insert_zeros".
+// Valid values include "insert_zeros", "skip" or "keep_values". Default is "insert_zeros".
 func (p *PercentilesBucketAggregation) GapPolicy(gapPolicy string) *PercentilesBucketAggregation {
 	p.gapPolicy = gapPolicy
+	p.markDirty()
 	return p
 }
 
 // GapInsertZeros inserts zeros for gaps in the series.
 func (p *PercentilesBucketAggregation) GapInsertZeros() *PercentilesBucketAggregation {
 	p.gapPolicy = "insert_zeros"
+	p.markDirty()
 	return p
 }
 
 // GapSkip skips gaps in the series.
 func (p *PercentilesBucketAggregation) GapSkip() *PercentilesBucketAggregation {
 	p.gapPolicy = "skip"
+	p.markDirty()
+	return p
+}
+
+// GapKeepValues skips gaps in the series like GapSkip, but keeps the non-null
+// values produced by the metrics the buckets path refers to (Elasticsearch 7.4+).
+func (p *PercentilesBucketAggregation) GapKeepValues() *PercentilesBucketAggregation {
+	p.gapPolicy = "keep_values"
+	p.markDirty()
 	return p
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (p *PercentilesBucketAggregation) Meta(metaData map[string]interface{}) *PercentilesBucketAggregation {
 	p.meta = metaData
+	p.markDirty()
+	return p
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (p *PercentilesBucketAggregation) AddMeta(key string, value interface{}) *PercentilesBucketAggregation {
+	p.addMeta(key, value)
+	p.markDirty()
 	return p
 }
 
 // BucketsPath sets the paths to the buckets to use for this pipeline aggregator.
 func (p *PercentilesBucketAggregation) BucketsPath(bucketsPaths ...string) *PercentilesBucketAggregation {
 	p.bucketsPaths = append(p.bucketsPaths, bucketsPaths...)
+	p.markDirty()
 	return p
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (p *PercentilesBucketAggregation) AggregationType() string {
+	return "percentiles_bucket"
+}
+
+func (p *PercentilesBucketAggregation) Validate() error {
+	if len(p.bucketsPaths) == 0 {
+		return errors.New("elastic: PercentilesBucketAggregation requires a buckets path")
+	}
+
+	return nil
+}
+
 // Source returns the a JSON-serializable interface.
 func (p *PercentilesBucketAggregation) Source() (interface{}, error) {
 	source := make(map[string]interface{})
@@ -79,6 +115,9 @@ func (p *PercentilesBucketAggregation) Source() (interface{}, error) {
 		params["format"] = p.format
 	}
 	if p.gapPolicy != "" {
+		if err := ValidateGapPolicy(p.gapPolicy); err != nil {
+			return nil, err
+		}
 		params["gap_policy"] = p.gapPolicy
 	}
 
@@ -97,9 +136,7 @@ func (p *PercentilesBucketAggregation) Source() (interface{}, error) {
 	}
 
 	// Add Meta data if available
-	if len(p.meta) > 0 {
-		source["meta"] = p.meta
-	}
+	p.renderMeta(source)
 
 	return source, nil
 }
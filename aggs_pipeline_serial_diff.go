@@ -1,5 +1,7 @@
 package aggretastic
 
+import "errors"
+
 // SerialDiffAggregation implements serial differencing.
 // Serial differencing is a technique where values in a time series are
 // subtracted from itself at different time lags or periods.
@@ -8,12 +10,12 @@ package aggretastic
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-pipeline-serialdiff-aggregation.html
 type SerialDiffAggregation struct {
 	*notInjectable
+	metaHolder
 
 	format    string
 	gapPolicy string
 	lag       *int
 
-	meta         map[string]interface{}
 	bucketsPaths []string
 }
 
@@ -30,25 +32,37 @@ func NewSerialDiffAggregation() *SerialDiffAggregation {
 // Format to use on the output of this aggregation.
 func (a *SerialDiffAggregation) Format(format string) *SerialDiffAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 // GapPolicy defines what should be done when a gap in the series is discovered.
-// Valid values include "insert_zeros" or "skip". Default is "insert_zeros".
+// Valid values include "insert_zeros", "skip" or "keep_values". Default is "insert_zeros".
 func (a *SerialDiffAggregation) GapPolicy(gapPolicy string) *SerialDiffAggregation {
 	a.gapPolicy = gapPolicy
+	a.markDirty()
 	return a
 }
 
 // GapInsertZeros inserts zeros for gaps in the series.
 func (a *SerialDiffAggregation) GapInsertZeros() *SerialDiffAggregation {
 	a.gapPolicy = "insert_zeros"
+	a.markDirty()
 	return a
 }
 
 // GapSkip skips gaps in the series.
 func (a *SerialDiffAggregation) GapSkip() *SerialDiffAggregation {
 	a.gapPolicy = "skip"
+	a.markDirty()
+	return a
+}
+
+// GapKeepValues skips gaps in the series like GapSkip, but keeps the non-null
+// values produced by the metrics the buckets path refers to (Elasticsearch 7.4+).
+func (a *SerialDiffAggregation) GapKeepValues() *SerialDiffAggregation {
+	a.gapPolicy = "keep_values"
+	a.markDirty()
 	return a
 }
 
@@ -57,21 +71,44 @@ func (a *SerialDiffAggregation) GapSkip() *SerialDiffAggregation {
 // ago. Lag must be a positive, non-zero integer.
 func (a *SerialDiffAggregation) Lag(lag int) *SerialDiffAggregation {
 	a.lag = &lag
+	a.markDirty()
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *SerialDiffAggregation) Meta(metaData map[string]interface{}) *SerialDiffAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *SerialDiffAggregation) AddMeta(key string, value interface{}) *SerialDiffAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 // BucketsPath sets the paths to the buckets to use for this pipeline aggregator.
 func (a *SerialDiffAggregation) BucketsPath(bucketsPaths ...string) *SerialDiffAggregation {
 	a.bucketsPaths = append(a.bucketsPaths, bucketsPaths...)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *SerialDiffAggregation) AggregationType() string {
+	return "serial_diff"
+}
+
+func (a *SerialDiffAggregation) Validate() error {
+	if len(a.bucketsPaths) == 0 {
+		return errors.New("elastic: SerialDiffAggregation requires a buckets path")
+	}
+
+	return nil
+}
+
 // Source returns the a JSON-serializable interface.
 func (a *SerialDiffAggregation) Source() (interface{}, error) {
 	source := make(map[string]interface{})
@@ -82,6 +119,9 @@ func (a *SerialDiffAggregation) Source() (interface{}, error) {
 		params["format"] = a.format
 	}
 	if a.gapPolicy != "" {
+		if err := ValidateGapPolicy(a.gapPolicy); err != nil {
+			return nil, err
+		}
 		params["gap_policy"] = a.gapPolicy
 	}
 	if a.lag != nil {
@@ -98,9 +138,7 @@ func (a *SerialDiffAggregation) Source() (interface{}, error) {
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
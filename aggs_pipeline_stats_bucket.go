@@ -1,5 +1,7 @@
 package aggretastic
 
+import "errors"
+
 // StatsBucketAggregation is a sibling pipeline aggregation which calculates
 // a variety of stats across all bucket of a specified metric in a sibling aggregation.
 // The specified metric must be numeric and the sibling aggregation must
@@ -9,11 +11,11 @@ package aggretastic
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-pipeline-stats-bucket-aggregation.html
 type StatsBucketAggregation struct {
 	*notInjectable
+	metaHolder
 
 	format    string
 	gapPolicy string
 
-	meta         map[string]interface{}
 	bucketsPaths []string
 }
 
@@ -30,40 +32,74 @@ func NewStatsBucketAggregation() *StatsBucketAggregation {
 // Format to use on the output of this aggregation.
 func (s *StatsBucketAggregation) Format(format string) *StatsBucketAggregation {
 	s.format = format
+	s.markDirty()
 	return s
 }
 
 // GapPolicy defines what should be done when a gap in the series is discovered.
-// Valid values include "insert_zeros" or "skip". Default is "insert_zeros".
+// Valid values include "insert_zeros", "skip" or "keep_values". Default is "insert_zeros".
 func (s *StatsBucketAggregation) GapPolicy(gapPolicy string) *StatsBucketAggregation {
 	s.gapPolicy = gapPolicy
+	s.markDirty()
 	return s
 }
 
 // GapInsertZeros inserts zeros for gaps in the series.
 func (s *StatsBucketAggregation) GapInsertZeros() *StatsBucketAggregation {
 	s.gapPolicy = "insert_zeros"
+	s.markDirty()
 	return s
 }
 
 // GapSkip skips gaps in the series.
 func (s *StatsBucketAggregation) GapSkip() *StatsBucketAggregation {
 	s.gapPolicy = "skip"
+	s.markDirty()
+	return s
+}
+
+// GapKeepValues skips gaps in the series like GapSkip, but keeps the non-null
+// values produced by the metrics the buckets path refers to (Elasticsearch 7.4+).
+func (s *StatsBucketAggregation) GapKeepValues() *StatsBucketAggregation {
+	s.gapPolicy = "keep_values"
+	s.markDirty()
 	return s
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (s *StatsBucketAggregation) Meta(metaData map[string]interface{}) *StatsBucketAggregation {
 	s.meta = metaData
+	s.markDirty()
+	return s
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (s *StatsBucketAggregation) AddMeta(key string, value interface{}) *StatsBucketAggregation {
+	s.addMeta(key, value)
+	s.markDirty()
 	return s
 }
 
 // BucketsPath sets the paths to the buckets to use for this pipeline aggregator.
 func (s *StatsBucketAggregation) BucketsPath(bucketsPaths ...string) *StatsBucketAggregation {
 	s.bucketsPaths = append(s.bucketsPaths, bucketsPaths...)
+	s.markDirty()
 	return s
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (s *StatsBucketAggregation) AggregationType() string {
+	return "stats_bucket"
+}
+
+func (s *StatsBucketAggregation) Validate() error {
+	if len(s.bucketsPaths) == 0 {
+		return errors.New("elastic: StatsBucketAggregation requires a buckets path")
+	}
+
+	return nil
+}
+
 // Source returns the a JSON-serializable interface.
 func (s *StatsBucketAggregation) Source() (interface{}, error) {
 	source := make(map[string]interface{})
@@ -74,6 +110,9 @@ func (s *StatsBucketAggregation) Source() (interface{}, error) {
 		params["format"] = s.format
 	}
 	if s.gapPolicy != "" {
+		if err := ValidateGapPolicy(s.gapPolicy); err != nil {
+			return nil, err
+		}
 		params["gap_policy"] = s.gapPolicy
 	}
 
@@ -87,9 +126,7 @@ func (s *StatsBucketAggregation) Source() (interface{}, error) {
 	}
 
 	// Add Meta data if available
-	if len(s.meta) > 0 {
-		source["meta"] = s.meta
-	}
+	s.renderMeta(source)
 
 	return source, nil
 }
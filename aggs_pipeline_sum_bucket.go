@@ -1,5 +1,7 @@
 package aggretastic
 
+import "errors"
+
 // SumBucketAggregation is a sibling pipeline aggregation which calculates
 // the sum across all buckets of a specified metric in a sibling aggregation.
 // The specified metric must be numeric and the sibling aggregation must
@@ -9,11 +11,11 @@ package aggretastic
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.2/search-aggregations-pipeline-sum-bucket-aggregation.html
 type SumBucketAggregation struct {
 	*notInjectable
+	metaHolder
 
 	format    string
 	gapPolicy string
 
-	meta         map[string]interface{}
 	bucketsPaths []string
 }
 
@@ -30,40 +32,74 @@ func NewSumBucketAggregation() *SumBucketAggregation {
 // Format to use on the output of this aggregation.
 func (a *SumBucketAggregation) Format(format string) *SumBucketAggregation {
 	a.format = format
+	a.markDirty()
 	return a
 }
 
 // GapPolicy defines what should be done when a gap in the series is discovered.
-// Valid values include "insert_zeros" or "skip". Default is "insert_zeros".
+// Valid values include "insert_zeros", "skip" or "keep_values". Default is "insert_zeros".
 func (a *SumBucketAggregation) GapPolicy(gapPolicy string) *SumBucketAggregation {
 	a.gapPolicy = gapPolicy
+	a.markDirty()
 	return a
 }
 
 // GapInsertZeros inserts zeros for gaps in the series.
 func (a *SumBucketAggregation) GapInsertZeros() *SumBucketAggregation {
 	a.gapPolicy = "insert_zeros"
+	a.markDirty()
 	return a
 }
 
 // GapSkip skips gaps in the series.
 func (a *SumBucketAggregation) GapSkip() *SumBucketAggregation {
 	a.gapPolicy = "skip"
+	a.markDirty()
+	return a
+}
+
+// GapKeepValues skips gaps in the series like GapSkip, but keeps the non-null
+// values produced by the metrics the buckets path refers to (Elasticsearch 7.4+).
+func (a *SumBucketAggregation) GapKeepValues() *SumBucketAggregation {
+	a.gapPolicy = "keep_values"
+	a.markDirty()
 	return a
 }
 
 // Meta sets the meta data to be included in the aggregation response.
 func (a *SumBucketAggregation) Meta(metaData map[string]interface{}) *SumBucketAggregation {
 	a.meta = metaData
+	a.markDirty()
+	return a
+}
+
+// AddMeta adds the key to the meta data keeping the rest of it.
+func (a *SumBucketAggregation) AddMeta(key string, value interface{}) *SumBucketAggregation {
+	a.addMeta(key, value)
+	a.markDirty()
 	return a
 }
 
 // BucketsPath sets the paths to the buckets to use for this pipeline aggregator.
 func (a *SumBucketAggregation) BucketsPath(bucketsPaths ...string) *SumBucketAggregation {
 	a.bucketsPaths = append(a.bucketsPaths, bucketsPaths...)
+	a.markDirty()
 	return a
 }
 
+// AggregationType returns the type key of the aggregation in its source.
+func (a *SumBucketAggregation) AggregationType() string {
+	return "sum_bucket"
+}
+
+func (a *SumBucketAggregation) Validate() error {
+	if len(a.bucketsPaths) == 0 {
+		return errors.New("elastic: SumBucketAggregation requires a buckets path")
+	}
+
+	return nil
+}
+
 // Source returns the a JSON-serializable interface.
 func (a *SumBucketAggregation) Source() (interface{}, error) {
 	source := make(map[string]interface{})
@@ -74,6 +110,9 @@ func (a *SumBucketAggregation) Source() (interface{}, error) {
 		params["format"] = a.format
 	}
 	if a.gapPolicy != "" {
+		if err := ValidateGapPolicy(a.gapPolicy); err != nil {
+			return nil, err
+		}
 		params["gap_policy"] = a.gapPolicy
 	}
 
@@ -87,9 +126,7 @@ func (a *SumBucketAggregation) Source() (interface{}, error) {
 	}
 
 	// Add Meta data if available
-	if len(a.meta) > 0 {
-		source["meta"] = a.meta
-	}
+	a.renderMeta(source)
 
 	return source, nil
 }
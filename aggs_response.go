@@ -0,0 +1,239 @@
+package aggretastic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// The decoders below parse the results of the common bucket aggregations, so the responses
+// can be read without olivere/elastic. Each of them takes the raw JSON of a single aggregation
+// result (the value of its name in the "aggregations" of the response) and doesn't depend on
+// how the aggregation was built. The subAggregations of every bucket are kept raw, keyed by
+// their names, to be decoded with the same functions further.
+
+// TermsBucket is a bucket of the terms aggregation result
+type TermsBucket struct {
+	// Key is either a string or a json.Number, depending on the field type
+	Key             interface{}
+	KeyAsString     string
+	DocCount        int64
+	SubAggregations map[string]json.RawMessage
+}
+
+// DateHistogramBucket is a bucket of the date_histogram (or auto_date_histogram) aggregation result
+type DateHistogramBucket struct {
+	// Key is the start of the bucket in milliseconds since the epoch
+	Key             int64
+	KeyAsString     string
+	DocCount        int64
+	SubAggregations map[string]json.RawMessage
+}
+
+// FiltersBucket is a bucket of the filters aggregation result
+type FiltersBucket struct {
+	// Name is the name of the filter, empty for the anonymous filters
+	Name            string
+	DocCount        int64
+	SubAggregations map[string]json.RawMessage
+}
+
+// RangeBucket is a bucket of the range (or date_range, geo_distance) aggregation result
+type RangeBucket struct {
+	Key             string
+	From            *float64
+	To              *float64
+	FromAsString    string
+	ToAsString      string
+	DocCount        int64
+	SubAggregations map[string]json.RawMessage
+}
+
+// DecodeTermsBuckets decodes the buckets of the terms aggregation result
+func DecodeTermsBuckets(raw json.RawMessage) ([]TermsBucket, error) {
+	items, err := decodeBucketList(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]TermsBucket, len(items))
+	for i, item := range items {
+		b := &buckets[i]
+		if err := item.take("key", &b.Key); err != nil {
+			return nil, err
+		}
+		if err := item.take("key_as_string", &b.KeyAsString); err != nil {
+			return nil, err
+		}
+		if err := item.take("doc_count", &b.DocCount); err != nil {
+			return nil, err
+		}
+		b.SubAggregations = item.rest()
+	}
+
+	return buckets, nil
+}
+
+// DecodeDateHistogramBuckets decodes the buckets of the date_histogram aggregation result
+func DecodeDateHistogramBuckets(raw json.RawMessage) ([]DateHistogramBucket, error) {
+	items, err := decodeBucketList(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]DateHistogramBucket, len(items))
+	for i, item := range items {
+		b := &buckets[i]
+		if err := item.take("key", &b.Key); err != nil {
+			return nil, err
+		}
+		if err := item.take("key_as_string", &b.KeyAsString); err != nil {
+			return nil, err
+		}
+		if err := item.take("doc_count", &b.DocCount); err != nil {
+			return nil, err
+		}
+		b.SubAggregations = item.rest()
+	}
+
+	return buckets, nil
+}
+
+// DecodeFiltersBuckets decodes the buckets of the filters aggregation result.
+// The named buckets are returned in the order of the response (the order of the filters).
+func DecodeFiltersBuckets(raw json.RawMessage) ([]FiltersBucket, error) {
+	items, err := decodeBucketList(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]FiltersBucket, len(items))
+	for i, item := range items {
+		b := &buckets[i]
+		b.Name = item.name
+		if err := item.take("doc_count", &b.DocCount); err != nil {
+			return nil, err
+		}
+		b.SubAggregations = item.rest()
+	}
+
+	return buckets, nil
+}
+
+// DecodeRangeBuckets decodes the buckets of the range aggregation result
+func DecodeRangeBuckets(raw json.RawMessage) ([]RangeBucket, error) {
+	items, err := decodeBucketList(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]RangeBucket, len(items))
+	for i, item := range items {
+		b := &buckets[i]
+		b.Key = item.name
+		for key, dst := range map[string]interface{}{
+			"key":            &b.Key,
+			"from":           &b.From,
+			"to":             &b.To,
+			"from_as_string": &b.FromAsString,
+			"to_as_string":   &b.ToAsString,
+			"doc_count":      &b.DocCount,
+		} {
+			if err := item.take(key, dst); err != nil {
+				return nil, err
+			}
+		}
+		b.SubAggregations = item.rest()
+	}
+
+	return buckets, nil
+}
+
+// bucketItem is a bucket with the fields not taken yet
+type bucketItem struct {
+	// name is the key of the bucket in the keyed results
+	name   string
+	fields map[string]json.RawMessage
+}
+
+// take decodes the field into dst (if it's present) and removes it from the bucket
+func (b *bucketItem) take(field string, dst interface{}) error {
+	raw, ok := b.fields[field]
+	if !ok {
+		return nil
+	}
+	delete(b.fields, field)
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(dst); err != nil {
+		return fmt.Errorf("bucket field %q: %v", field, err)
+	}
+
+	return nil
+}
+
+// rest returns the fields left, which are the subAggregations
+func (b *bucketItem) rest() map[string]json.RawMessage {
+	return b.fields
+}
+
+// decodeBucketList decodes the "buckets" of an aggregation result,
+// which is either an array or an object of the buckets keyed by their names (keyed results)
+func decodeBucketList(raw json.RawMessage) ([]bucketItem, error) {
+	var result struct {
+		Buckets json.RawMessage `json:"buckets"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Buckets) == 0 {
+		return nil, errors.New("aggregation result has no buckets")
+	}
+
+	switch result.Buckets[0] {
+	case '[':
+		var list []map[string]json.RawMessage
+		if err := json.Unmarshal(result.Buckets, &list); err != nil {
+			return nil, err
+		}
+		items := make([]bucketItem, len(list))
+		for i, fields := range list {
+			items[i] = bucketItem{fields: fields}
+		}
+		return items, nil
+	case '{':
+		return decodeKeyedBuckets(result.Buckets)
+	default:
+		return nil, errors.New("aggregation result buckets are neither an array nor an object")
+	}
+}
+
+// decodeKeyedBuckets decodes the object of buckets keeping the order of its keys
+func decodeKeyedBuckets(raw json.RawMessage) ([]bucketItem, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	var items []bucketItem
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		name, ok := token.(string)
+		if !ok {
+			return nil, errors.New("aggregation result buckets have a malformed key")
+		}
+
+		var fields map[string]json.RawMessage
+		if err := dec.Decode(&fields); err != nil {
+			return nil, err
+		}
+		items = append(items, bucketItem{name: name, fields: fields})
+	}
+
+	return items, nil
+}
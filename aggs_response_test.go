@@ -0,0 +1,88 @@
+package aggretastic
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeTermsBuckets(t *testing.T) {
+	raw := json.RawMessage(`{"doc_count_error_upper_bound":0,"buckets":[
+		{"key":"kimchy","doc_count":3,"avg":{"value":1.5}},
+		{"key":17,"key_as_string":"17","doc_count":1}
+	]}`)
+
+	buckets, err := DecodeTermsBuckets(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 2 {
+		t.Fatalf("expected 2 buckets, got %d", len(buckets))
+	}
+	if buckets[0].Key != "kimchy" || buckets[0].DocCount != 3 || string(buckets[0].SubAggregations["avg"]) != `{"value":1.5}` {
+		t.Fatalf("unexpected bucket %+v", buckets[0])
+	}
+	if key, ok := buckets[1].Key.(json.Number); !ok || key.String() != "17" || buckets[1].KeyAsString != "17" || len(buckets[1].SubAggregations) != 0 {
+		t.Fatalf("unexpected bucket %+v", buckets[1])
+	}
+
+	if _, err := DecodeTermsBuckets(json.RawMessage(`{"value":1}`)); err == nil {
+		t.Fatal("expected an error for a response without buckets")
+	}
+}
+
+func TestDecodeDateHistogramBuckets(t *testing.T) {
+	raw := json.RawMessage(`{"buckets":[{"key_as_string":"2020-01-01","key":1577836800000,"doc_count":2}]}`)
+
+	buckets, err := DecodeDateHistogramBuckets(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 1 || buckets[0].Key != 1577836800000 || buckets[0].KeyAsString != "2020-01-01" || buckets[0].DocCount != 2 {
+		t.Fatalf("unexpected buckets %+v", buckets)
+	}
+}
+
+func TestDecodeFiltersBuckets(t *testing.T) {
+	raw := json.RawMessage(`{"buckets":{"warnings":{"doc_count":1},"errors":{"doc_count":2,"users":{"buckets":[]}}}}`)
+
+	buckets, err := DecodeFiltersBuckets(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 2 || buckets[0].Name != "warnings" || buckets[1].Name != "errors" || buckets[1].DocCount != 2 {
+		t.Fatalf("expected the buckets in the response order, got %+v", buckets)
+	}
+	users, err := DecodeTermsBuckets(buckets[1].SubAggregations["users"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 0 {
+		t.Fatalf("expected no buckets of the subAggregation, got %+v", users)
+	}
+
+	anonymous, err := DecodeFiltersBuckets(json.RawMessage(`{"buckets":[{"doc_count":4}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anonymous) != 1 || anonymous[0].Name != "" || anonymous[0].DocCount != 4 {
+		t.Fatalf("unexpected buckets %+v", anonymous)
+	}
+}
+
+func TestDecodeRangeBuckets(t *testing.T) {
+	raw := json.RawMessage(`{"buckets":{"cheap":{"to":50,"doc_count":2},"*-":{"key":"pricey","from":50,"doc_count":1}}}`)
+
+	buckets, err := DecodeRangeBuckets(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 2 {
+		t.Fatalf("expected 2 buckets, got %d", len(buckets))
+	}
+	if buckets[0].Key != "cheap" || buckets[0].From != nil || buckets[0].To == nil || *buckets[0].To != 50 || buckets[0].DocCount != 2 {
+		t.Fatalf("unexpected bucket %+v", buckets[0])
+	}
+	if buckets[1].Key != "pricey" || buckets[1].From == nil || *buckets[1].From != 50 || buckets[1].To != nil {
+		t.Fatalf("unexpected bucket %+v", buckets[1])
+	}
+}
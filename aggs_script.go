@@ -0,0 +1,27 @@
+package aggretastic
+
+import "github.com/olivere/elastic"
+
+// Script languages known by Elasticsearch
+const (
+	ScriptLangPainless   = "painless"
+	ScriptLangExpression = "expression"
+	ScriptLangMustache   = "mustache"
+)
+
+// InlineScript makes the inline script to be passed to the Script setters of the aggregations.
+func InlineScript(source string) *elastic.Script {
+	return elastic.NewScriptInline(source)
+}
+
+// InlineScriptWithParams makes the inline script with params, e.g.
+// InlineScriptWithParams("doc['price'].value * params.rate", map[string]interface{}{"rate": 1.2})
+func InlineScriptWithParams(source string, params map[string]interface{}) *elastic.Script {
+	return elastic.NewScriptInline(source).Params(params)
+}
+
+// Lang sets the language of script (see ScriptLangPainless etc.) and returns it.
+// Elasticsearch uses painless if it's not set.
+func Lang(script *elastic.Script, lang string) *elastic.Script {
+	return script.Lang(lang)
+}
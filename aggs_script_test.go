@@ -0,0 +1,25 @@
+package aggretastic
+
+import "testing"
+
+func TestScriptHelpers(t *testing.T) {
+	params := map[string]interface{}{"rate": 2}
+
+	tests := []struct {
+		name string
+		agg  Aggregation
+		want string
+	}{
+		{"inline", NewStatsAggregation().Script(InlineScript("doc['price'].value")), `{"stats":{"script":{"source":"doc['price'].value"}}}`},
+		{"with params", NewValueCountAggregation().Script(InlineScriptWithParams("doc['price'].value * params.rate", params)), `{"value_count":{"script":{"params":{"rate":2},"source":"doc['price'].value * params.rate"}}}`},
+		{"with lang", NewSumAggregation().Script(Lang(InlineScript("doc['price'].value"), ScriptLangExpression)), `{"sum":{"script":{"lang":"expression","source":"doc['price'].value"}}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.agg.String() != test.want {
+				t.Fatalf("expected %s, got %s", test.want, test.agg)
+			}
+		})
+	}
+}